@@ -74,6 +74,11 @@ func main() {
 	flags.BoolVarP(&opt.CreateComment, "create-comment", "", false, "Indicate if want to create a status comment")
 	flags.StringVarP(&opt.CommentTemplate, "comment-template", "", "", "The template of the comment")
 	flags.StringVarP(&opt.CommentIdentity, "comment-identity", "", pkg.CommentEndMarker, "The identity for matching exiting comment")
+	flags.StringVarP(&opt.NotifyConfig, "notify-config", "", "", "The config file for choosing the notify channels per user or group")
+	flags.StringVarP(&opt.UserDirectory, "user-directory", "", "",
+		"The user directory file which maps the git logins to the emails, chat identities and notification preferences")
+	flags.StringVarP(&opt.UserDirectoryConfigMap, "user-directory-configmap", "", "",
+		"The ConfigMap of the user directory, format: namespace/name. The data key must be "+userDirectoryKey)
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -86,7 +91,12 @@ func (o *option) runE(cmd *cobra.Command, args []string) (err error) {
 	}
 	client := wfclientset.NewForConfigOrDie(config)
 
-	http.HandleFunc("/api/v1/template.execute", plugin(&DefaultPluginExecutor{option: o}, client))
+	pluginExecutor := &DefaultPluginExecutor{option: o}
+	if pluginExecutor.notifyConfig, pluginExecutor.userDirectory, err = o.loadNotifySettings(cmd.Context(), config); err != nil {
+		return
+	}
+
+	http.HandleFunc("/api/v1/template.execute", plugin(pluginExecutor, client))
 	err = http.ListenAndServe(fmt.Sprintf(":%d", o.Port), nil)
 	return
 }
@@ -103,6 +113,13 @@ type option struct {
 	CommentTemplate string
 	CommentIdentity string
 
+	NotifyConfig           string
+	UserDirectory          string
+	UserDirectoryConfigMap string
+	// Notify indicates if want to notify the users of the pull request, NotifyMessage is the optional message
+	Notify        bool
+	NotifyMessage string
+
 	Owner       string
 	Repo        string
 	PR          string
@@ -113,7 +130,9 @@ type option struct {
 }
 
 type DefaultPluginExecutor struct {
-	option *option
+	option        *option
+	notifyConfig  *pkg.NotifyConfig
+	userDirectory *pkg.UserDirectory
 }
 
 type pluginOption struct {
@@ -283,6 +302,20 @@ func (e *DefaultPluginExecutor) Execute(args executor.ExecuteTemplateArgs, wf *w
 			fmt.Println("failed to create comment", err)
 		}
 	}
+
+	if err == nil && opt.Option.Notify {
+		text := EmptyThen(opt.Option.NotifyMessage,
+			fmt.Sprintf("Workflow %s is %s: %s", repo.Label, repo.Status, targetAddress))
+		resolver := &pkg.NotifyResolver{
+			Provider:  repo.Provider,
+			Repo:      repo.GetRepoPath(),
+			Config:    e.notifyConfig,
+			Directory: e.userDirectory,
+		}
+		if notifyErr := pkg.NewMaker(ctx, repo).NotifyPullRequest(ctx, resolver, text, os.Stdout); notifyErr != nil {
+			fmt.Println("failed to notify", notifyErr)
+		}
+	}
 	return
 }
 
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/linuxsuren/gogit/pkg"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// userDirectoryKey is the data key of the user directory in the ConfigMap
+const userDirectoryKey = "users.yaml"
+
+func (o *option) loadNotifySettings(ctx context.Context, config *rest.Config) (
+	notifyConfig *pkg.NotifyConfig, directory *pkg.UserDirectory, err error) {
+	if o.NotifyConfig != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.NotifyConfig); err != nil {
+			return
+		}
+		if notifyConfig, err = pkg.ParseNotifyConfig(data); err != nil {
+			return
+		}
+	}
+
+	switch {
+	case o.UserDirectory != "":
+		var data []byte
+		if data, err = os.ReadFile(o.UserDirectory); err == nil {
+			directory, err = pkg.ParseUserDirectory(data)
+		}
+	case o.UserDirectoryConfigMap != "":
+		var client kubernetes.Interface
+		if client, err = kubernetes.NewForConfig(config); err == nil {
+			directory, err = loadUserDirectoryFromConfigMap(ctx, client, o.UserDirectoryConfigMap)
+		}
+	}
+	return
+}
+
+// loadUserDirectoryFromConfigMap loads the user directory from the ConfigMap, the ref format is namespace/name
+func loadUserDirectoryFromConfigMap(ctx context.Context, client kubernetes.Interface, ref string) (directory *pkg.UserDirectory, err error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		err = fmt.Errorf("invalid ConfigMap %q, the format should be namespace/name", ref)
+		return
+	}
+
+	var cm *corev1.ConfigMap
+	if cm, err = client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		err = fmt.Errorf("failed to get the ConfigMap %q: %v", ref, err)
+		return
+	}
+
+	data, found := cm.Data[userDirectoryKey]
+	if !found {
+		err = fmt.Errorf("cannot find %q in the ConfigMap %q", userDirectoryKey, ref)
+		return
+	}
+	directory, err = pkg.ParseUserDirectory([]byte(data))
+	return
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoadUserDirectoryFromConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "argo", Name: "users"},
+		Data: map[string]string{
+			userDirectoryKey: `
+users:
+  - name: Rick
+    logins:
+      github: linuxsuren
+    email: rick@example.com`,
+		},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "argo", Name: "empty"},
+	})
+
+	ctx := context.Background()
+	directory, err := loadUserDirectoryFromConfigMap(ctx, client, "argo/users")
+	assert.NoError(t, err)
+	if user := directory.Find("github", "linuxsuren"); assert.NotNil(t, user) {
+		assert.Equal(t, "rick@example.com", user.Email)
+	}
+
+	_, err = loadUserDirectoryFromConfigMap(ctx, client, "users")
+	assert.Error(t, err)
+
+	_, err = loadUserDirectoryFromConfigMap(ctx, client, "argo/empty")
+	assert.Error(t, err)
+
+	_, err = loadUserDirectoryFromConfigMap(ctx, client, "argo/not-found")
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newChangelogCmd() (c *cobra.Command) {
+	opt := &changelogOption{}
+	c = &cobra.Command{
+		Use:   "changelog",
+		Short: "Generate the release notes from the merged pull requests between two revisions",
+		Example: `gogit changelog --provider github --username linuxsuren --repo gogit --token $GITHUB_TOKEN \
+  --from v1.2.0 --to v1.3.0`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.From, "from", "", "", "The start revision (exclusive), all the history is used if it's empty")
+	flags.StringVarP(&opt.To, "to", "", "HEAD", "The end revision (inclusive)")
+	flags.StringVarP(&opt.workDir, "work-dir", "", ".", "The local git repository directory")
+	flags.StringVarP(&opt.GroupBy, "group-by", "", pkg.ChangelogGroupByType,
+		"Group the changes by the conventional commit type or the pull request label, supported: type, label")
+	flags.StringSliceVarP(&opt.Labels, "labels", "", []string{},
+		"The labels as the sections in order when grouping by label, all the labels are used if it's empty")
+	flags.StringVarP(&opt.templateFile, "template", "", "",
+		"The Go template file of the Markdown output, see also pkg.Changelog")
+	flags.StringVarP(&opt.output, "output", "", "markdown", "The output format, supported: markdown, json")
+	return
+}
+
+func (o *changelogOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	switch o.GroupBy {
+	case pkg.ChangelogGroupByType, pkg.ChangelogGroupByLabel:
+	default:
+		err = fmt.Errorf("unsupported group %q, supported: type, label", o.GroupBy)
+		return
+	}
+
+	switch o.output {
+	case "markdown", "json":
+	default:
+		err = fmt.Errorf("unsupported output format %q, supported: markdown, json", o.output)
+		return
+	}
+	o.template, err = readOptionalFile(o.templateFile)
+	return
+}
+
+func (o *changelogOption) runE(c *cobra.Command, args []string) (err error) {
+	var commits []*object.Commit
+	if commits, err = pkg.ListCommits(o.workDir, o.From, o.To); err != nil {
+		return
+	}
+
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		Username: o.username,
+		Token:    o.token,
+	})
+
+	var changelog *pkg.Changelog
+	if changelog, err = maker.GenerateChangelog(c.Context(), commits, o.ChangelogOptions); err != nil {
+		return
+	}
+
+	if o.output == "json" {
+		encoder := json.NewEncoder(c.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(changelog)
+		return
+	}
+
+	var text string
+	if text, err = pkg.RenderChangelog(changelog, o.template); err == nil {
+		_, err = fmt.Fprint(c.OutOrStdout(), text)
+	}
+	return
+}
+
+type changelogOption struct {
+	gitProviderOption
+	pkg.ChangelogOptions
+	workDir      string
+	templateFile string
+	output       string
+
+	template string
+}
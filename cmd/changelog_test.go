@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelogCmd(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		expect string
+	}{{
+		name:   "invalid group",
+		args:   []string{"--group-by", "author"},
+		expect: `unsupported group "author"`,
+	}, {
+		name:   "invalid output",
+		args:   []string{"--output", "yaml"},
+		expect: `unsupported output format "yaml"`,
+	}, {
+		name:   "template not found",
+		args:   []string{"--template", "fake.tpl"},
+		expect: "fake.tpl",
+	}, {
+		name:   "not a git repository",
+		args:   []string{"--work-dir", "/"},
+		expect: "cannot open git repository",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRootCommand()
+			c.SetOut(io.Discard)
+			c.SetErr(io.Discard)
+
+			c.SetArgs(append([]string{"changelog", "--repo=xxx/xxx", "--token=token", "--username=xxx"}, tt.args...))
+			err := c.Execute()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expect)
+		})
+	}
+}
@@ -1,9 +1,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"github.com/mitchellh/go-homedir"
-	"github.com/pkg/errors"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,8 +14,9 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/linuxsuren/gogit/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -25,32 +26,48 @@ func newCheckoutCommand() (c *cobra.Command) {
 	c = &cobra.Command{
 		Use:     "checkout",
 		Aliases: []string{"co"},
-		Short:   "Clone and checkout the git repository with branch, tag, or pull request",
+		Short:   "Clone and checkout the git repository with branch, tag, pull request, commit or ref",
 		Example: "gogit checkout https://github.com/linuxsuren/gogit",
 		PreRunE: opt.preRunE,
 		RunE:    opt.runE,
 	}
 
-	userHomeDir, err := homedir.Dir()
-	if err != nil {
-		panic(errors.Wrap(err, "cannot find the home directory"))
-	}
-
 	flags := c.Flags()
 	flags.StringVarP(&opt.url, "url", "", "", "The git repository URL")
 	flags.StringVarP(&opt.remote, "remote", "", "origin", "The remote name")
-	flags.StringVarP(&opt.sshPrivateKey, "ssh-private-key", "", filepath.Join(userHomeDir, ".ssh/id_rsa"),
-		"The SSH private key file path")
-	flags.StringVarP(&opt.username, "username", "", "", "The username of the git repository")
-	flags.StringVarP(&opt.password, "password", "", "", "The password of the git repository")
+	opt.addAuthFlags(c)
 	flags.StringVarP(&opt.branch, "branch", "b", "master", "The branch want to checkout. It could be a short name or fullname. Such as master or refs/heads/master")
 	flags.StringVarP(&opt.tag, "tag", "", "", "The tag want to checkout")
 	flags.IntVarP(&opt.pr, "pr", "p", -1, "The pr number want to checkout, -1 means do nothing")
+	flags.StringVarP(&opt.prMode, "pr-mode", "", prModeHead,
+		"The result of the pr want to checkout, one of head, merge (the pr is merged into the base) and rebase (the pr is rebased onto the base). "+
+			"merge uses the merge ref of the provider if it exists, otherwise the merge or rebase is done locally")
+	flags.StringVarP(&opt.prBase, "pr-base", "", "", "The base branch for merging or rebasing the pr locally, it's required unless --branch is given")
+	flags.StringVarP(&opt.commit, "commit", "", "", "The full SHA of the commit want to checkout, HEAD is detached at it")
+	flags.StringVarP(&opt.ref, "ref", "", "",
+		"The ref want to checkout, such as refs/merge-requests/5/merge or refs/changes/34/1234/2, HEAD is detached at it")
 	flags.StringVarP(&opt.target, "target", "", ".", "Clone git repository to the target path")
+	flags.IntVarP(&opt.depth, "depth", "", 0, "Create a shallow clone, and fetch the pull request, with the number of commits, 0 means the full history")
+	flags.BoolVarP(&opt.singleBranch, "single-branch", "", false, "Clone only the history of the branch")
+	flags.BoolVarP(&opt.noTags, "no-tags", "", false, "Do not fetch any tags")
+	flags.IntVarP(&opt.deepenMax, "deepen-max", "", 1000,
+		"Deepen the shallow clone up to the number of commits when the nearest tag is needed by --version-format, "+
+			"or the merge base is needed by --pr-mode, 0 means never")
+	flags.BoolVarP(&opt.recurseSubmodules, "recurse-submodules", "", false, "Checkout the submodules, and the nested ones")
+	flags.IntVarP(&opt.submoduleDepth, "submodule-depth", "", 0, "Fetch the submodules with the number of commits, 0 means the full history")
+	flags.BoolVarP(&opt.submoduleRemote, "submodule-remote", "", false,
+		"Checkout the tip of the tracked branch of the submodules instead of the recorded commits")
+	flags.StringToStringVarP(&opt.submoduleCredentials, "submodule-credential", "", nil,
+		"The HTTP credentials of the submodules by host, such as gitlab.com=username:token. The auth of the repository is used by default")
 	flags.StringVarP(&opt.versionOutput, "version-output", "", "", "Write the version to target file")
 	flags.StringVarP(&opt.trimVersionPrefix, "version-trim-prefix", "", "", "Trim the prefix of the version")
+	flags.StringVarP(&opt.versionFormat, "version-format", "", pkg.VersionFormatRef,
+		"The format of the version, one of ref (branch, tag, pr-N, or the SHA of --commit and --ref), "+
+			"describe (v1.4.2-13-gabc1234-dirty), short-sha, sha, semver (v1.4.3-dev.13+gabc1234), or a Go template of pkg.Description, such as: {{.Tag}}-{{.Distance}}")
 	flags.StringVarP(&opt.timestampOutput, "timestamp-output", "", "", "Write the current time to the target file")
 	flags.StringVarP(&opt.timestampFormat, "timestamp-format", "", "2006-01-02-150405", "The format of the time stamp")
+	c.MarkFlagsMutuallyExclusive("commit", "ref", "tag")
+	c.MarkFlagsMutuallyExclusive("commit", "ref", "pr")
 	return
 }
 
@@ -59,6 +76,16 @@ func (o *checkoutOption) preRunE(c *cobra.Command, args []string) (err error) {
 		o.url = args[0]
 	}
 	o.branch = strings.TrimPrefix(o.branch, "refs/heads/")
+	if o.prMode == "" {
+		o.prMode = prModeHead
+	}
+	if o.prMode != prModeHead && o.prMode != prModeMerge && o.prMode != prModeRebase {
+		err = fmt.Errorf("the pr mode should be one of head, merge and rebase, but it's %q", o.prMode)
+	} else if o.commit != "" && !plumbing.IsHash(o.commit) {
+		err = fmt.Errorf("the commit should be a full SHA, but it's %q", o.commit)
+	} else if o.ref != "" && !strings.HasPrefix(o.ref, "refs/") {
+		err = fmt.Errorf("the ref should start with refs/, but it's %q", o.ref)
+	}
 	return
 }
 
@@ -74,13 +101,25 @@ func (o *checkoutOption) runE(c *cobra.Command, args []string) (err error) {
 	}
 
 	var repo *git.Repository
-	if _, serr := os.Stat(filepath.Join(repoDir, ".git")); serr != nil {
+	if _, serr := os.Stat(filepath.Join(repoDir, ".git")); serr != nil && o.detached() {
+		// only the objects of the commit or ref are fetched later
+		if repo, err = git.PlainInit(repoDir, false); err == nil {
+			_, err = repo.CreateRemote(&config.RemoteConfig{Name: o.remote, URLs: []string{o.url}})
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to init git repository '%s', error: %v", repoDir, err)
+			return
+		}
+	} else if serr != nil {
 		if repo, err = git.PlainCloneContext(c.Context(), repoDir, false, &git.CloneOptions{
 			RemoteName:    o.remote,
 			Auth:          gitAuth,
 			URL:           o.url,
 			ReferenceName: plumbing.NewBranchReferenceName(o.branch),
 			Progress:      c.OutOrStdout(),
+			Depth:         o.depth,
+			SingleBranch:  o.singleBranch,
+			Tags:          o.tagMode(),
 		}); err != nil {
 			err = fmt.Errorf("failed to clone git repository '%s' into '%s', error: %v", o.url, repoDir, err)
 			return
@@ -132,16 +171,27 @@ func (o *checkoutOption) runE(c *cobra.Command, args []string) (err error) {
 				return
 			}
 			c.Printf("Switched to tag '%s'\n", o.tag)
+			version = tagRef.Short()
+		}
+
+		if o.detached() {
+			var hash plumbing.Hash
+			if hash, err = o.fetchDetached(c, repo, gitAuth); err != nil {
+				return
+			}
+
+			if err = wd.Checkout(&git.CheckoutOptions{
+				Hash: hash,
+			}); err != nil {
+				err = fmt.Errorf("unable to checkout commit: %s, error: %v", hash, err)
+				return
+			}
+			c.Printf("HEAD is now at %s\n", hash)
+			version = hash.String()
 		}
 
 		if o.pr > 0 {
-			if err = repo.Fetch(&git.FetchOptions{
-				RemoteName: o.remote,
-				Auth:       gitAuth,
-				Progress:   c.OutOrStdout(),
-				RefSpecs:   []config.RefSpec{config.RefSpec(prRef(o.pr, kind))},
-			}); err != nil && err != git.NoErrAlreadyUpToDate {
-				err = fmt.Errorf("failed to fetch '%s', error: %v", o.remote, err)
+			if err = o.fetchPullRequest(c, repo, gitAuth, kind); err != nil {
 				return
 			}
 
@@ -155,7 +205,26 @@ func (o *checkoutOption) runE(c *cobra.Command, args []string) (err error) {
 			version = fmt.Sprintf("pr-%d", o.pr)
 		}
 
+		if o.recurseSubmodules {
+			var submodules []pkg.UpdatedSubmodule
+			if submodules, err = pkg.UpdateSubmodules(c.Context(), repo, pkg.SubmoduleOptions{
+				Depth:     o.submoduleDepth,
+				Recursive: true,
+				Remote:    o.submoduleRemote,
+				Auth:      o.submoduleAuth,
+				Progress:  c.OutOrStdout(),
+			}); err != nil {
+				return
+			}
+			for _, submodule := range submodules {
+				c.Printf("Submodule path '%s': checked out '%s'\n", submodule.Path, submodule.Commit)
+			}
+		}
+
 		if o.versionOutput != "" {
+			if version, err = o.formatVersion(c, repo, gitAuth, version); err != nil {
+				return
+			}
 			err = os.WriteFile(o.versionOutput, []byte(strings.TrimPrefix(version, o.trimVersionPrefix)), 0444)
 		}
 
@@ -170,19 +239,229 @@ func (o *checkoutOption) runE(c *cobra.Command, args []string) (err error) {
 	return
 }
 
-func (o *checkoutOption) getAuth(remote string) (auth transport.AuthMethod, err error) {
-	if strings.HasPrefix(remote, "git@") {
-		rsa := os.ExpandEnv(o.sshPrivateKey)
-		auth, err = ssh.NewPublicKeysFromFile("git", rsa, "")
-	} else if o.username != "" && o.password != "" {
-		auth = &http.BasicAuth{
-			Username: o.username,
-			Password: o.password,
+// formatVersion formats the checked out ref by --version-format, HEAD is described only when it's needed
+func (o *checkoutOption) formatVersion(c *cobra.Command, repo *git.Repository, gitAuth transport.AuthMethod, ref string) (version string, err error) {
+	desc := pkg.Description{Ref: ref}
+	switch o.versionFormat {
+	case pkg.VersionFormatRef, "":
+	case pkg.VersionFormatSha, pkg.VersionFormatShortSha:
+		desc, err = pkg.DescribeHead(repo)
+	default:
+		desc, err = o.describeWithDeepen(c, repo, gitAuth)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to describe HEAD: %v", err)
+		return
+	}
+	desc.Ref = ref
+	version, err = pkg.FormatVersion(o.versionFormat, desc)
+	return
+}
+
+// describeWithDeepen deepens the shallow clone until the nearest tag is found, or it reaches --deepen-max.
+// The describer is reused, so the commits which are already walked are not loaded again
+func (o *checkoutOption) describeWithDeepen(c *cobra.Command, repo *git.Repository, gitAuth transport.AuthMethod) (desc pkg.Description, err error) {
+	depth := o.depth
+	describer := pkg.NewDescriber(repo)
+	for {
+		if desc, err = describer.Describe(); err != nil || desc.Tag != "" || o.noTags || depth >= o.deepenMax {
+			return
+		}
+
+		var shallows []plumbing.Hash
+		if shallows, err = repo.Storer.Shallow(); err != nil || len(shallows) == 0 {
+			return
+		}
+
+		if depth = depth * 2; depth == 0 {
+			depth = 1
+		}
+		if depth > o.deepenMax {
+			depth = o.deepenMax
+		}
+		var refSpecs []config.RefSpec
+		if refSpecs, err = o.deepenRefSpecs(repo); err != nil {
+			return
+		}
+		c.Printf("Deepen the shallow clone to %d commits for the version\n", depth)
+		if err = repo.FetchContext(c.Context(), &git.FetchOptions{
+			RemoteName: o.remote,
+			Auth:       gitAuth,
+			Progress:   c.OutOrStdout(),
+			RefSpecs:   refSpecs,
+			Depth:      depth,
+			Tags:       git.TagFollowing,
+		}); err != nil && err != git.NoErrAlreadyUpToDate {
+			err = fmt.Errorf("failed to deepen the shallow clone, error: %v", err)
+			return
+		}
+		if err = pruneShallows(repo); err != nil {
+			return
+		}
+	}
+}
+
+// detached returns true if HEAD is detached at --commit or --ref
+func (o *checkoutOption) detached() bool {
+	return o.commit != "" || o.ref != ""
+}
+
+// detachedRefSpec fetches --commit or --ref into FETCH_HEAD like `git fetch <remote> <ref>`
+func (o *checkoutOption) detachedRefSpec() config.RefSpec {
+	src := o.ref
+	if o.commit != "" {
+		src = o.commit
+	}
+	return config.RefSpec(fmt.Sprintf("+%s:%s", src, fetchHead))
+}
+
+// fetchDetached fetches the minimal objects of --commit or --ref, and returns the commit
+func (o *checkoutOption) fetchDetached(c *cobra.Command, repo *git.Repository, gitAuth transport.AuthMethod) (hash plumbing.Hash, err error) {
+	if o.commit != "" {
+		hash = plumbing.NewHash(o.commit)
+		if _, commitErr := repo.CommitObject(hash); commitErr == nil {
+			return
+		}
+	}
+
+	refSpec := o.detachedRefSpec()
+	fetchOptions := &git.FetchOptions{
+		RemoteName: o.remote,
+		Auth:       gitAuth,
+		Progress:   c.OutOrStdout(),
+		RefSpecs:   []config.RefSpec{refSpec},
+		Depth:      o.depth,
+		Tags:       o.tagMode(),
+	}
+	if err = repo.FetchContext(c.Context(), fetchOptions); err == git.ErrExactSHA1NotSupported {
+		c.Println("The server does not support fetching a commit, fetching all the branches instead")
+		fetchOptions.RefSpecs = nil
+		fetchOptions.Depth = 0
+		err = repo.FetchContext(c.Context(), fetchOptions)
+	}
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		err = fmt.Errorf("failed to fetch '%s', error: %v", refSpec.Src(), err)
+		return
+	}
+
+	if o.ref != "" {
+		var ref *plumbing.Reference
+		if ref, err = repo.Reference(fetchHead, true); err != nil {
+			err = fmt.Errorf("cannot find the fetched ref '%s', error: %v", o.ref, err)
+			return
+		}
+		hash = ref.Hash()
+		// peel the annotated tag
+		if tag, tagErr := repo.TagObject(hash); tagErr == nil {
+			var commit *object.Commit
+			if commit, err = tag.Commit(); err != nil {
+				return
+			}
+			hash = commit.Hash
+		}
+	}
+
+	if _, err = repo.CommitObject(hash); err != nil {
+		err = fmt.Errorf("cannot find commit '%s', error: %v", hash, err)
+	}
+	return
+}
+
+// deepenRefSpecs returns the refspecs for deepening, the pull request is deepened as well. The tags are fetched
+// explicitly if the refspecs are not all wildcards, because go-git follows the tags only in that case
+func (o *checkoutOption) deepenRefSpecs(repo *git.Repository) (refSpecs []config.RefSpec, err error) {
+	var remote *git.Remote
+	if remote, err = repo.Remote(o.remote); err != nil {
+		return
+	}
+
+	if o.detached() {
+		refSpecs = append(refSpecs, o.detachedRefSpec())
+	} else {
+		refSpecs = append(refSpecs, remote.Config().Fetch...)
+	}
+	if o.pr > 0 {
+		// the checked out pr-N might be merged or rebased locally, so the pr is deepened into another ref
+		mode := o.prMode
+		if o.prLocalBase != "" {
+			mode = prModeHead
+			refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("+refs/heads/%s:%s", o.prLocalBase,
+				plumbing.NewRemoteReferenceName(o.remote, o.prLocalBase))))
+		}
+		refSpecs = append(refSpecs, config.RefSpec(fmt.Sprintf("+%s:%s", prSource(o.pr, detectGitKind(remote.Config().URLs[0]), mode),
+			plumbing.NewRemoteReferenceName(o.remote, fmt.Sprintf("pr-%d", o.pr)))))
+	}
+	for _, refSpec := range refSpecs {
+		if !refSpec.IsWildcard() {
+			refSpecs = append(refSpecs, "+refs/tags/*:refs/tags/*")
+			break
+		}
+	}
+	return
+}
+
+// pruneShallows removes the commits whose parents are fetched from the shallow list, go-git only appends to it
+func pruneShallows(repo *git.Repository) (err error) {
+	var shallows []plumbing.Hash
+	if shallows, err = repo.Storer.Shallow(); err != nil {
+		return
+	}
+
+	var kept []plumbing.Hash
+	for _, hash := range shallows {
+		var commit *object.Commit
+		if commit, err = repo.CommitObject(hash); err != nil {
+			return
 		}
+		for _, parent := range commit.ParentHashes {
+			if _, parentErr := repo.Storer.EncodedObject(plumbing.CommitObject, parent); parentErr != nil {
+				kept = append(kept, hash)
+				break
+			}
+		}
+	}
+	if len(kept) != len(shallows) {
+		err = repo.Storer.SetShallow(kept)
 	}
 	return
 }
 
+// tagMode returns the tags to fetch, only the tags in the fetched history are fetched for the shallow clone
+func (o *checkoutOption) tagMode() git.TagMode {
+	switch {
+	case o.noTags:
+		return git.NoTags
+	case o.depth > 0:
+		return git.TagFollowing
+	}
+	return git.InvalidTagMode
+}
+
+// submoduleAuth returns the credential of the submodule host, or the auth of the repository
+func (o *checkoutOption) submoduleAuth(gitURL string) (auth transport.AuthMethod, err error) {
+	if u, parseErr := url.Parse(gitURL); parseErr == nil && strings.HasPrefix(u.Scheme, "http") {
+		if credential, ok := o.submoduleCredentials[u.Hostname()]; ok {
+			username, password, _ := strings.Cut(credential, ":")
+			auth = &http.BasicAuth{
+				Username: username,
+				Password: password,
+			}
+			return
+		}
+	}
+	return o.getAuth(gitURL)
+}
+
+// fetchHead is the ref of the fetched commit or ref
+const fetchHead plumbing.ReferenceName = "FETCH_HEAD"
+
+// The results of the pr to checkout
+const (
+	prModeHead   = "head"
+	prModeMerge  = "merge"
+	prModeRebase = "rebase"
+)
+
 func detectGitKind(gitURL string) (kind string) {
 	kind = "gitlab"
 	if strings.Contains(gitURL, "github.com") {
@@ -193,27 +472,192 @@ func detectGitKind(gitURL string) (kind string) {
 
 // see also https://docs.github.com/en/pull-requests/collaborating-with-pull-requests/reviewing-changes-in-pull-requests/checking-out-pull-requests-locally?gt
 func prRef(pr int, kind string) (ref string) {
+	if src := prSource(pr, kind, prModeHead); src != "" {
+		ref = fmt.Sprintf("%s:pr-%d", src, pr)
+	}
+	return
+}
+
+// prSource returns the ref of the pr in the remote, it's the merge result of the provider in the merge mode
+func prSource(pr int, kind, mode string) (ref string) {
+	suffix := "head"
+	if mode == prModeMerge {
+		suffix = "merge"
+	}
+
 	switch kind {
 	case "gitlab":
-		ref = fmt.Sprintf("refs/merge-requests/%d/head:pr-%d", pr, pr)
+		ref = fmt.Sprintf("refs/merge-requests/%d/%s", pr, suffix)
 	case "github":
-		ref = fmt.Sprintf("refs/pull/%d/head:pr-%d", pr, pr)
+		ref = fmt.Sprintf("refs/pull/%d/%s", pr, suffix)
+	}
+	return
+}
+
+// fetchPullRequest fetches the pr into pr-N by --pr-mode. The pr is merged or rebased locally if the provider has no merge ref
+func (o *checkoutOption) fetchPullRequest(c *cobra.Command, repo *git.Repository, gitAuth transport.AuthMethod, kind string) (err error) {
+	local := plumbing.ReferenceName(fmt.Sprintf("pr-%d", o.pr))
+	if o.prMode == prModeMerge {
+		mergeRef := fmt.Sprintf("%s:%s", prSource(o.pr, kind, prModeMerge), local)
+		if err = o.fetchRefSpec(c, repo, gitAuth, mergeRef); err == nil {
+			var merged *object.Commit
+			if merged, err = resolveCommit(repo, local); err == nil && merged.NumParents() > 0 {
+				c.Printf("Merged pr-%d into the base at %s\n", o.pr, merged.ParentHashes[0])
+			}
+			return
+		} else if !errors.Is(err, git.NoMatchingRefSpecError{}) {
+			err = fmt.Errorf("failed to fetch '%s', error: %v", mergeRef, err)
+			return
+		}
+		c.Printf("There is no merge ref of pr-%d, merging it locally\n", o.pr)
+	}
+
+	if err = o.fetchRefSpec(c, repo, gitAuth, prRef(o.pr, kind)); err != nil {
+		err = fmt.Errorf("failed to fetch '%s', error: %v", o.remote, err)
+		return
+	}
+	if o.prMode == prModeHead {
+		return
+	}
+
+	// the default --branch is not the base of every pr, so it's used only if it's given explicitly
+	base := o.prBase
+	if base == "" && c.Flags().Changed("branch") {
+		base = o.branch
+	}
+	if base == "" {
+		err = fmt.Errorf("the base branch of pr-%d is unknown, please set --pr-base to %s it locally", o.pr, o.prMode)
+		return
+	}
+	baseRef := plumbing.NewRemoteReferenceName(o.remote, base)
+	if err = o.fetchRefSpec(c, repo, gitAuth, fmt.Sprintf("+refs/heads/%s:%s", base, baseRef)); err != nil {
+		err = fmt.Errorf("failed to fetch the base branch '%s', error: %v", base, err)
+		return
+	}
+	o.prLocalBase = base
+
+	var baseCommit, headCommit, result *object.Commit
+	if baseCommit, headCommit, err = o.deepenForMergeBase(c, repo, gitAuth, baseRef, local,
+		fmt.Sprintf("+refs/heads/%s:%s", base, baseRef), prRef(o.pr, kind)); err != nil {
+		return
+	}
+
+	signature := o.signature(repo)
+	if o.prMode == prModeRebase {
+		result, err = pkg.RebaseCommits(repo, baseCommit, headCommit, signature)
+	} else {
+		result, err = pkg.MergeCommits(repo, baseCommit, headCommit, fmt.Sprintf("Merge pull request #%d into %s", o.pr, base), signature)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to %s pr-%d with the base '%s' at %s: %v", o.prMode, o.pr, base, baseCommit.Hash, err)
+		return
+	}
+	c.Printf("The base '%s' of pr-%d is at %s\n", base, o.pr, baseCommit.Hash)
+	err = repo.Storer.SetReference(plumbing.NewHashReference(local, result.Hash))
+	return
+}
+
+// deepenForMergeBase resolves the base and the pr, the shallow clone is deepened until they have a merge base,
+// or it reaches --deepen-max
+func (o *checkoutOption) deepenForMergeBase(c *cobra.Command, repo *git.Repository, gitAuth transport.AuthMethod,
+	baseRef, headRef plumbing.ReferenceName, refSpecs ...string) (baseCommit, headCommit *object.Commit, err error) {
+	depth := o.depth
+	for {
+		if baseCommit, err = resolveCommit(repo, baseRef); err != nil {
+			return
+		}
+		if headCommit, err = resolveCommit(repo, headRef); err != nil {
+			return
+		}
+		// the parents of the shallow commits are missing, so there is no merge base if they are reached
+		if _, mergeErr := baseCommit.MergeBase(headCommit); mergeErr == nil || depth == 0 || depth >= o.deepenMax {
+			return
+		}
+
+		if depth = depth * 2; depth > o.deepenMax {
+			depth = o.deepenMax
+		}
+		fetchOptions := &git.FetchOptions{
+			RemoteName: o.remote,
+			Auth:       gitAuth,
+			Progress:   c.OutOrStdout(),
+			Depth:      depth,
+			Tags:       o.tagMode(),
+		}
+		for _, refSpec := range refSpecs {
+			fetchOptions.RefSpecs = append(fetchOptions.RefSpecs, config.RefSpec(refSpec))
+		}
+		c.Printf("Deepen the shallow clone to %d commits for the merge base of pr-%d\n", depth, o.pr)
+		if err = repo.FetchContext(c.Context(), fetchOptions); err != nil && err != git.NoErrAlreadyUpToDate {
+			err = fmt.Errorf("failed to deepen the shallow clone, error: %v", err)
+			return
+		}
+		if err = pruneShallows(repo); err != nil {
+			return
+		}
+	}
+}
+
+// fetchRefSpec fetches the refspec from the remote with --depth, it's fine if it's already up to date
+func (o *checkoutOption) fetchRefSpec(c *cobra.Command, repo *git.Repository, gitAuth transport.AuthMethod, refSpec string) (err error) {
+	if err = repo.FetchContext(c.Context(), &git.FetchOptions{
+		RemoteName: o.remote,
+		Auth:       gitAuth,
+		Progress:   c.OutOrStdout(),
+		RefSpecs:   []config.RefSpec{config.RefSpec(refSpec)},
+		Depth:      o.depth,
+		Tags:       o.tagMode(),
+	}); err == git.NoErrAlreadyUpToDate {
+		err = nil
+	}
+	return
+}
+
+// signature returns the committer of the local merge or rebase from the git config
+func (o *checkoutOption) signature(repo *git.Repository) (signature object.Signature) {
+	signature = object.Signature{Name: "gogit", When: time.Now()}
+	if cfg, err := repo.ConfigScoped(config.SystemScope); err == nil && cfg.User.Name != "" {
+		signature.Name, signature.Email = cfg.User.Name, cfg.User.Email
+	}
+	return
+}
+
+// resolveCommit returns the commit of the ref
+func resolveCommit(repo *git.Repository, name plumbing.ReferenceName) (commit *object.Commit, err error) {
+	var ref *plumbing.Reference
+	if ref, err = repo.Reference(name, true); err == nil {
+		commit, err = repo.CommitObject(ref.Hash())
 	}
 	return
 }
 
 type checkoutOption struct {
+	gitAuthOption
 	url               string
 	remote            string
 	branch            string
 	tag               string
 	pr                int
+	prMode            string
+	prBase            string
+	commit            string
+	ref               string
 	target            string
-	sshPrivateKey     string
+	depth             int
+	singleBranch      bool
+	noTags            bool
+	deepenMax         int
 	versionOutput     string
 	trimVersionPrefix string
+	versionFormat     string
 	timestampOutput   string
 	timestampFormat   string
-	username          string
-	password          string
+
+	recurseSubmodules    bool
+	submoduleDepth       int
+	submoduleRemote      bool
+	submoduleCredentials map[string]string
+
+	// prLocalBase is the base branch if the pr is merged or rebased locally
+	prLocalBase string
 }
@@ -1,8 +1,18 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,17 +82,19 @@ func Test_detectGitKind(t *testing.T) {
 	}
 }
 
-func TestGetAuth(t *testing.T) {
+func TestSubmoduleAuth(t *testing.T) {
 	opt := &checkoutOption{
-		sshPrivateKey: "/tmp",
+		gitAuthOption:        gitAuthOption{username: "rick", password: "secret"},
+		submoduleCredentials: map[string]string{"gitlab.com": "bot:token"},
 	}
-	auth, err := opt.getAuth("git@fake.com")
-	assert.Nil(t, auth)
-	assert.NotNil(t, err)
 
-	auth, err = opt.getAuth("fake.com")
-	assert.Nil(t, auth)
-	assert.Nil(t, err)
+	auth, err := opt.submoduleAuth("https://gitlab.com/linuxsuren/lib.git")
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "bot", Password: "token"}, auth)
+
+	auth, err = opt.submoduleAuth("https://github.com/linuxsuren/lib.git")
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "rick", Password: "secret"}, auth)
 }
 
 func TestPreRunE(t *testing.T) {
@@ -118,6 +130,16 @@ func TestPreRunE(t *testing.T) {
 		verify: func(t *testing.T, opt *checkoutOption) {
 			assert.Equal(t, "master", opt.branch)
 		},
+	}, {
+		name:      "commit is not a full SHA",
+		opt:       &checkoutOption{commit: "abc1234"},
+		expectErr: true,
+		verify:    func(t *testing.T, opt *checkoutOption) {},
+	}, {
+		name:      "ref is not a fullname",
+		opt:       &checkoutOption{ref: "master"},
+		expectErr: true,
+		verify:    func(t *testing.T, opt *checkoutOption) {},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -131,3 +153,289 @@ func TestPreRunE(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckoutVersionFormat(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/linuxsuren/gogit"}})
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	head, err := worktree.Commit("init", &git.CommitOptions{
+		Author: &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	versionFile := filepath.Join(t.TempDir(), "version")
+	c := NewRootCommand()
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+	c.SetArgs([]string{"checkout", "--target", dir, "--version-output", versionFile, "--version-format", "short-sha"})
+	assert.NoError(t, c.Execute())
+
+	data, err := os.ReadFile(versionFile)
+	assert.NoError(t, err)
+	assert.Equal(t, head.String()[:7], string(data))
+}
+
+func TestCheckoutShallowDeepen(t *testing.T) {
+	source := t.TempDir()
+	repo, err := git.PlainInit(source, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	var head plumbing.Hash
+	for i := 0; i < 4; i++ {
+		head, err = worktree.Commit(fmt.Sprintf("commit %d", i), &git.CommitOptions{
+			Author: &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		if i == 0 {
+			_, err = repo.CreateTag("v1.0.0", head, nil)
+			assert.NoError(t, err)
+		}
+	}
+
+	checkout := func(t *testing.T, args ...string) (target, version string) {
+		target = t.TempDir()
+		versionFile := filepath.Join(t.TempDir(), "version")
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs(append([]string{"checkout", "file://" + source, "--target", target, "--depth", "1",
+			"--version-output", versionFile}, args...))
+		assert.NoError(t, c.Execute())
+
+		data, err := os.ReadFile(versionFile)
+		assert.NoError(t, err)
+		version = string(data)
+		return
+	}
+
+	t.Run("shallow clone", func(t *testing.T) {
+		target, version := checkout(t, "--version-format", "short-sha")
+		assert.Equal(t, head.String()[:7], version)
+
+		cloned, err := git.PlainOpen(target)
+		assert.NoError(t, err)
+		shallows, err := cloned.Storer.Shallow()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, shallows)
+		_, err = cloned.Tag("v1.0.0")
+		assert.Error(t, err)
+	})
+
+	t.Run("deepen for the nearest tag", func(t *testing.T) {
+		_, version := checkout(t, "--version-format", "describe")
+		assert.Equal(t, "v1.0.0-3-g"+head.String()[:7], version)
+	})
+
+	t.Run("deepen the single branch", func(t *testing.T) {
+		_, version := checkout(t, "--version-format", "describe", "--single-branch")
+		assert.Equal(t, "v1.0.0-3-g"+head.String()[:7], version)
+	})
+
+	t.Run("deepen is disabled", func(t *testing.T) {
+		_, version := checkout(t, "--version-format", "describe", "--deepen-max", "0")
+		assert.Equal(t, head.String()[:7], version)
+	})
+}
+
+func TestCheckoutDetached(t *testing.T) {
+	source := t.TempDir()
+	repo, err := git.PlainInit(source, false)
+	assert.NoError(t, err)
+	cfg, err := repo.Config()
+	assert.NoError(t, err)
+	cfg.Raw.Section("uploadpack").SetOption("allowReachableSHA1InWant", "true")
+	assert.NoError(t, repo.SetConfig(cfg))
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	var commits []plumbing.Hash
+	for i := 0; i < 3; i++ {
+		hash, err := worktree.Commit(fmt.Sprintf("commit %d", i), &git.CommitOptions{
+			Author: &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		commits = append(commits, hash)
+	}
+	// the last commit is only reachable from the merge request ref
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/merge-requests/5/merge", commits[2])))
+	assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.Master, commits[1])))
+
+	checkout := func(t *testing.T, args ...string) (target, version string) {
+		target = t.TempDir()
+		versionFile := filepath.Join(t.TempDir(), "version")
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs(append([]string{"checkout", "file://" + source, "--target", target, "--version-output", versionFile}, args...))
+		assert.NoError(t, c.Execute())
+
+		data, err := os.ReadFile(versionFile)
+		assert.NoError(t, err)
+		version = string(data)
+		return
+	}
+
+	t.Run("ref", func(t *testing.T) {
+		target, version := checkout(t, "--ref", "refs/merge-requests/5/merge", "--depth", "1")
+		assert.Equal(t, commits[2].String(), version)
+
+		cloned, err := git.PlainOpen(target)
+		assert.NoError(t, err)
+		head, err := cloned.Head()
+		assert.NoError(t, err)
+		assert.Equal(t, plumbing.HEAD, head.Name())
+		assert.Equal(t, commits[2], head.Hash())
+		shallows, err := cloned.Storer.Shallow()
+		assert.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{commits[2]}, shallows)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		_, version := checkout(t, "--commit", commits[0].String(), "--version-format", "short-sha")
+		assert.Equal(t, commits[0].String()[:7], version)
+	})
+
+	t.Run("commit and tag", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs([]string{"checkout", "file://" + source, "--target", t.TempDir(), "--commit", commits[0].String(), "--tag", "v1"})
+		assert.Error(t, c.Execute())
+	})
+}
+
+func TestCheckoutPullRequestMode(t *testing.T) {
+	source := t.TempDir()
+	repo, err := git.PlainInit(source, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	commitFile := func(content, message string) plumbing.Hash {
+		assert.NoError(t, os.WriteFile(filepath.Join(source, "a.txt"), []byte(content), 0644))
+		_, err := worktree.Add("a.txt")
+		assert.NoError(t, err)
+		hash, err := worktree.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+		})
+		assert.NoError(t, err)
+		return hash
+	}
+	setRef := func(name string, hash plumbing.Hash) {
+		assert.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), hash)))
+	}
+
+	initial := commitFile("1\n2\n3\n4\n5\n", "init")
+	base := commitFile("one\n2\n3\n4\n5\n", "fix: base")
+	assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{
+		Hash: initial, Branch: plumbing.NewBranchReferenceName("feature"), Create: true,
+	}))
+	head := commitFile("1\n2\n3\n4\nfive\n", "feat: five")
+	setRef("refs/merge-requests/1/head", head)
+	setRef("refs/merge-requests/2/head", head)
+	setRef("refs/merge-requests/2/merge", base)
+	setRef("refs/merge-requests/3/head", commitFile("ONE\n2\n3\n4\nfive\n", "fix: conflict"))
+
+	var target string
+	checkout := func(t *testing.T, args ...string) (cloned *git.Repository, err error) {
+		target = t.TempDir()
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs(append([]string{"checkout", "file://" + source, "--target", target}, args...))
+		if err = c.Execute(); err == nil {
+			cloned, err = git.PlainOpen(target)
+		}
+		return
+	}
+	headCommit := func(t *testing.T, repo *git.Repository) *object.Commit {
+		ref, err := repo.Head()
+		assert.NoError(t, err)
+		commit, err := repo.CommitObject(ref.Hash())
+		assert.NoError(t, err)
+		return commit
+	}
+
+	t.Run("head", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, head, headCommit(t, cloned).Hash)
+	})
+
+	t.Run("merge locally", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "1", "--pr-mode", "merge", "--pr-base", "master")
+		assert.NoError(t, err)
+		merged := headCommit(t, cloned)
+		assert.Equal(t, []plumbing.Hash{base, head}, merged.ParentHashes)
+		data, err := os.ReadFile(filepath.Join(target, "a.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "one\n2\n3\n4\nfive\n", string(data))
+	})
+
+	t.Run("rebase locally", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "1", "--pr-mode", "rebase", "--pr-base", "master")
+		assert.NoError(t, err)
+		rebased := headCommit(t, cloned)
+		assert.Equal(t, []plumbing.Hash{base}, rebased.ParentHashes)
+		assert.Equal(t, "feat: five", rebased.Message)
+	})
+
+	t.Run("merge a shallow clone locally", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "1", "--pr-mode", "merge", "--depth", "1", "--pr-base", "master")
+		assert.NoError(t, err)
+		merged := headCommit(t, cloned)
+		assert.Equal(t, []plumbing.Hash{base, head}, merged.ParentHashes)
+		data, err := os.ReadFile(filepath.Join(target, "a.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "one\n2\n3\n4\nfive\n", string(data))
+	})
+
+	t.Run("rebase a shallow clone locally", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "1", "--pr-mode", "rebase", "--depth", "1", "--pr-base", "master")
+		assert.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{base}, headCommit(t, cloned).ParentHashes)
+	})
+
+	t.Run("merge base is out of --deepen-max", func(t *testing.T) {
+		_, err := checkout(t, "--pr", "1", "--pr-mode", "merge", "--depth", "1", "--deepen-max", "1", "--pr-base", "master")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "too shallow")
+		}
+	})
+
+	t.Run("the base is unknown", func(t *testing.T) {
+		_, err := checkout(t, "--pr", "1", "--pr-mode", "merge")
+		assert.ErrorContains(t, err, "please set --pr-base")
+	})
+
+	t.Run("the base is --branch", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "1", "--pr-mode", "rebase", "--branch", "master")
+		assert.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{base}, headCommit(t, cloned).ParentHashes)
+	})
+
+	t.Run("merge ref of the provider", func(t *testing.T) {
+		cloned, err := checkout(t, "--pr", "2", "--pr-mode", "merge")
+		assert.NoError(t, err)
+		assert.Equal(t, base, headCommit(t, cloned).Hash)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := checkout(t, "--pr", "3", "--pr-mode", "rebase", "--pr-base", "master")
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "conflicts in")
+			assert.Contains(t, err.Error(), base.String())
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := checkout(t, "--pr", "1", "--pr-mode", "squash")
+		assert.Error(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/jenkins-x/go-scm/scm"
 	"github.com/linuxsuren/gogit/pkg"
 	"github.com/spf13/cobra"
 )
@@ -8,41 +9,108 @@ import (
 func newCommentCommand() (c *cobra.Command) {
 	opt := &commentOption{}
 	c = &cobra.Command{
-		Use:     "comment",
-		Short:   "Create a comment against the pull request",
-		Example: `gogit comment --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN -m LGTM`,
+		Use:   "comment",
+		Short: "Create a comment against the pull request, issue or commit",
+		Example: `gogit comment --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN -m LGTM
+gogit comment --provider github --username linuxsuren --repo test --sha $COMMIT_SHA --token $GITHUB_TOKEN -m LGTM`,
 		Aliases: []string{"c"},
 		PreRunE: opt.preRunE,
 		RunE:    opt.runE,
 	}
 
-	opt.addFlags(c)
+	opt.addTargetFlags(c, true)
 	flags := c.Flags()
 	flags.StringVarP(&opt.message, "message", "m", "", "The comment body")
 	flags.StringVarP(&opt.identity, "identity", "", pkg.CommentEndMarker, "The identity for matching exiting comment")
-	return
-}
 
-func (o *commentOption) runE(c *cobra.Command, args []string) (err error) {
-	err = pkg.CreateComment(c.Context(), pkg.RepoInformation{
-		Provider: o.provider,
-		Server:   o.server,
-		Owner:    o.owner,
-		Repo:     o.repo,
-		PrNumber: o.pr,
-		Username: o.username,
-		Token:    o.token,
-	}, o.message, o.identity)
+	c.AddCommand(newCommentListCommand(), newCommentDeleteCommand())
 	return
 }
 
-func (o *commentOption) preRunE(c *cobra.Command, args []string) (err error) {
-	o.preHandle()
+func (o *commentOption) runE(c *cobra.Command, args []string) (err error) {
+	err = pkg.CreateComment(c.Context(), o.getRepoInformation(), o.message, o.identity)
 	return
 }
 
 type commentOption struct {
-	gitProviderOption
+	targetOption
 	message  string
 	identity string
 }
+
+func newCommentListCommand() (c *cobra.Command) {
+	opt := &commentOption{}
+	c = &cobra.Command{
+		Use:     "list",
+		Short:   "List the comments which have the identity",
+		Example: `gogit comment list --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN`,
+		Aliases: []string{"ls"},
+		PreRunE: opt.preRunE,
+		RunE:    opt.runList,
+	}
+
+	opt.addTargetFlags(c, true)
+	flags := c.Flags()
+	flags.StringVarP(&opt.identity, "identity", "", pkg.CommentEndMarker, "The identity for matching exiting comments")
+	return
+}
+
+func (o *commentOption) runList(c *cobra.Command, args []string) (err error) {
+	if maker := pkg.NewMaker(c.Context(), o.getRepoInformation()); maker != nil {
+		var comments []*scm.Comment
+		if comments, err = maker.ListComments(c.Context(), o.identity); err == nil {
+			for _, comment := range comments {
+				c.Println(comment.ID, comment.Author.Login, comment.Link)
+			}
+		}
+	}
+	return
+}
+
+func newCommentDeleteCommand() (c *cobra.Command) {
+	opt := &commentDeleteOption{}
+	c = &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete, or hide, the comments which have the identity",
+		Example: `gogit comment delete --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN`,
+		Aliases: []string{"rm"},
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addTargetFlags(c, true)
+	flags := c.Flags()
+	flags.StringVarP(&opt.identity, "identity", "", pkg.CommentEndMarker, "The identity for matching exiting comments")
+	flags.BoolVarP(&opt.minimize, "minimize", "", false, "Minimize the comments instead of deleting them, only supported by GitHub")
+	flags.StringVarP(&opt.reason, "reason", "", "outdated",
+		"The reason of minimizing the comments, such as: outdated, resolved, duplicate, off_topic")
+	flags.BoolVarP(&opt.resolve, "resolve", "", false, "Resolve the discussions instead of deleting the comments, only supported by GitLab. "+
+		"The comments which are not resolvable are still deleted")
+	c.MarkFlagsMutuallyExclusive("minimize", "resolve")
+	return
+}
+
+func (o *commentDeleteOption) runE(c *cobra.Command, args []string) (err error) {
+	if maker := pkg.NewMaker(c.Context(), o.getRepoInformation()); maker != nil {
+		var count int
+		switch {
+		case o.minimize:
+			count, err = maker.MinimizeComments(c.Context(), o.identity, o.reason)
+			c.Printf("minimized %d comment(s)\n", count)
+		case o.resolve:
+			count, err = maker.ResolveComments(c.Context(), o.identity)
+			c.Printf("resolved %d comment(s)\n", count)
+		default:
+			count, err = maker.DeleteComments(c.Context(), o.identity)
+			c.Printf("deleted %d comment(s)\n", count)
+		}
+	}
+	return
+}
+
+type commentDeleteOption struct {
+	commentOption
+	minimize bool
+	reason   string
+	resolve  bool
+}
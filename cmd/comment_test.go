@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentCmd(t *testing.T) {
+	t.Run("sub-commands", func(t *testing.T) {
+		c := newCommentCommand()
+		assert.Len(t, c.Commands(), 2)
+	})
+
+	t.Run("minimize and resolve are mutually exclusive", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"comment", "delete", "--repo=xxx/xxx", "--pr=1", "--token=token", "--username=xxx",
+			"--minimize", "--resolve"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "none of the others can be")
+	})
+
+	t.Run("target is required", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"comment", "--repo=xxx/xxx", "--token=token", "--username=xxx", "-m", "msg"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "one of the flags --pr, --issue or --sha is required")
+	})
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshPassphraseEnv is the environment variable of the SSH private key passphrase
+const sshPassphraseEnv = "GOGIT_SSH_PASSPHRASE"
+
+// sshKeyNames are the default SSH private keys in the order of preference
+var sshKeyNames = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+type gitAuthOption struct {
+	sshPrivateKey         string
+	sshPassphraseFile     string
+	sshAgent              bool
+	knownHosts            []string
+	insecureIgnoreHostKey bool
+	username              string
+	password              string
+}
+
+// addAuthFlags adds the flags of the SSH key or the HTTP basic auth
+func (o *gitAuthOption) addAuthFlags(c *cobra.Command) {
+	flags := c.Flags()
+	flags.StringVarP(&o.sshPrivateKey, "ssh-private-key", "", "",
+		"The SSH private key file path. It's discovered from ~/.ssh/id_ed25519, id_ecdsa and id_rsa if it's empty")
+	flags.StringVarP(&o.sshPassphraseFile, "ssh-passphrase-file", "", "",
+		"The file of the passphrase of the encrypted SSH private key, the environment variable "+sshPassphraseEnv+" is used if it's empty")
+	flags.BoolVarP(&o.sshAgent, "ssh-agent", "", true, "Use the ssh-agent of SSH_AUTH_SOCK if there is no --ssh-private-key")
+	flags.StringSliceVarP(&o.knownHosts, "known-hosts", "", nil,
+		"The known_hosts files for verifying the SSH host keys. They are $SSH_KNOWN_HOSTS, ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts if it's empty")
+	flags.BoolVarP(&o.insecureIgnoreHostKey, "insecure-ignore-host-key", "", false,
+		"Skip the verification of the SSH host keys, it's vulnerable to the man-in-the-middle attack")
+	flags.StringVarP(&o.username, "username", "", "", "The username of the git repository")
+	flags.StringVarP(&o.password, "password", "", "", "The password of the git repository")
+}
+
+func (o *gitAuthOption) getAuth(remote string) (auth transport.AuthMethod, err error) {
+	if user, ok := sshUser(remote); ok {
+		auth, err = o.getSSHAuth(user, sshAddress(remote))
+	} else if o.username != "" && o.password != "" {
+		auth = &http.BasicAuth{
+			Username: o.username,
+			Password: o.password,
+		}
+	}
+	return
+}
+
+// getSSHAuth returns the auth of the private key, or the ssh-agent. The host keys are verified by the known_hosts,
+// and the host key algorithms are the types of the known keys of the address
+func (o *gitAuthOption) getSSHAuth(user, address string) (auth transport.AuthMethod, err error) {
+	keyFile := os.ExpandEnv(o.sshPrivateKey)
+	useAgent := keyFile == "" && o.sshAgent && os.Getenv("SSH_AUTH_SOCK") != ""
+
+	var keys *ssh.PublicKeys
+	if !useAgent {
+		if keyFile == "" {
+			var sshDir string
+			if sshDir, err = homedir.Expand("~/.ssh"); err != nil {
+				return
+			}
+			if keyFile, err = discoverSSHKey(sshDir); err != nil {
+				return
+			}
+		}
+
+		var passphrase string
+		if passphrase, err = o.sshPassphrase(); err != nil {
+			return
+		}
+		if keys, err = ssh.NewPublicKeysFromFile(user, keyFile, passphrase); err != nil {
+			err = fmt.Errorf("failed to load the SSH private key %q: %v", keyFile, err)
+			return
+		}
+	}
+
+	var hostKeyCallback gossh.HostKeyCallback
+	if o.insecureIgnoreHostKey {
+		hostKeyCallback = gossh.InsecureIgnoreHostKey()
+	} else if hostKeyCallback, err = ssh.NewKnownHostsCallback(o.knownHosts...); err != nil {
+		err = fmt.Errorf("failed to load the known_hosts, see also --known-hosts and --insecure-ignore-host-key: %v", err)
+		return
+	}
+
+	var sshAuth ssh.AuthMethod
+	if useAgent {
+		var agentAuth *ssh.PublicKeysCallback
+		if agentAuth, err = ssh.NewSSHAgentAuth(user); err != nil {
+			return
+		}
+		agentAuth.HostKeyCallback = hostKeyCallback
+		sshAuth = agentAuth
+	} else {
+		keys.HostKeyCallback = hostKeyCallback
+		sshAuth = keys
+	}
+
+	auth = sshAuth
+	if algorithms := knownHostKeyAlgorithms(hostKeyCallback, address); len(algorithms) > 0 {
+		auth = &hostKeyAlgorithmsAuth{AuthMethod: sshAuth, algorithms: algorithms}
+	}
+	return
+}
+
+// hostKeyAlgorithmsAuth sets the host key algorithms, go-git does not set them. Otherwise, the server might offer
+// a host key whose type is not in the known_hosts, such as ecdsa instead of the known ed25519, then it fails
+type hostKeyAlgorithmsAuth struct {
+	ssh.AuthMethod
+	algorithms []string
+}
+
+// ClientConfig returns the config of the SSH client with the host key algorithms
+func (a *hostKeyAlgorithmsAuth) ClientConfig() (config *gossh.ClientConfig, err error) {
+	if config, err = a.AuthMethod.ClientConfig(); err == nil {
+		config.HostKeyAlgorithms = a.algorithms
+	}
+	return
+}
+
+// knownHostKeyAlgorithms returns the types of the known keys of the address, they are empty if the host is unknown.
+// The known keys are listed in the error of the known_hosts callback for a key which could never match
+func knownHostKeyAlgorithms(hostKeyCallback gossh.HostKeyCallback, address string) (algorithms []string) {
+	if address == "" {
+		return
+	}
+
+	var keyErr *knownhosts.KeyError
+	if err := hostKeyCallback(address, &net.TCPAddr{IP: net.IPv4zero}, unknownHostKey{}); !errors.As(err, &keyErr) {
+		return
+	}
+	for _, known := range keyErr.Want {
+		keyTypes := []string{known.Key.Type()}
+		if keyTypes[0] == gossh.KeyAlgoRSA {
+			// the SHA-2 signatures of the RSA key are preferred
+			keyTypes = []string{gossh.KeyAlgoRSASHA512, gossh.KeyAlgoRSASHA256, gossh.KeyAlgoRSA}
+		}
+		for _, keyType := range keyTypes {
+			if !slices.Contains(algorithms, keyType) {
+				algorithms = append(algorithms, keyType)
+			}
+		}
+	}
+	return
+}
+
+// unknownHostKey is a public key which is never in the known_hosts
+type unknownHostKey struct{}
+
+func (unknownHostKey) Type() string {
+	return "unknown-host-key"
+}
+
+func (unknownHostKey) Marshal() []byte {
+	return []byte("unknown-host-key")
+}
+
+func (unknownHostKey) Verify([]byte, *gossh.Signature) error {
+	return errors.New("the unknown host key cannot verify any signature")
+}
+
+// sshPassphrase reads the passphrase from --ssh-passphrase-file, or the environment variable
+func (o *gitAuthOption) sshPassphrase() (passphrase string, err error) {
+	if o.sshPassphraseFile == "" {
+		passphrase = os.Getenv(sshPassphraseEnv)
+		return
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(o.sshPassphraseFile); err == nil {
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	}
+	return
+}
+
+// discoverSSHKey returns the first existing default private key in the directory
+func discoverSSHKey(sshDir string) (keyFile string, err error) {
+	for _, name := range sshKeyNames {
+		keyFile = filepath.Join(sshDir, name)
+		if _, statErr := os.Stat(keyFile); statErr == nil {
+			return
+		}
+	}
+	err = fmt.Errorf("no SSH private key %v found in %q, please set --ssh-private-key", sshKeyNames, sshDir)
+	return
+}
+
+// sshAddress returns the host and port of the SSH URL, it's empty if the URL is invalid
+func sshAddress(remote string) (address string) {
+	if endpoint, err := transport.NewEndpoint(remote); err == nil && endpoint.Host != "" {
+		port := endpoint.Port
+		if port == 0 {
+			port = 22
+		}
+		address = net.JoinHostPort(endpoint.Host, strconv.Itoa(port))
+	}
+	return
+}
+
+// sshUser returns the user of the SSH URL, such as ssh://user@host:port/path or user@host:path. The default user is git
+func sshUser(remote string) (user string, ok bool) {
+	if endpoint, err := transport.NewEndpoint(remote); err == nil && endpoint.Protocol == "ssh" {
+		user, ok = endpoint.User, true
+	} else {
+		ok = strings.HasPrefix(remote, "git@")
+	}
+	if ok && user == "" {
+		user = "git"
+	}
+	return
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestGetAuth(t *testing.T) {
+	opt := &gitAuthOption{
+		sshPrivateKey: "/tmp",
+	}
+	auth, err := opt.getAuth("git@fake.com")
+	assert.Nil(t, auth)
+	assert.NotNil(t, err)
+
+	auth, err = opt.getAuth("fake.com")
+	assert.Nil(t, auth)
+	assert.Nil(t, err)
+}
+
+func TestGetSSHAuth(t *testing.T) {
+	dir := t.TempDir()
+	signer := writeSSHKeys(t, dir)
+	knownHosts := filepath.Join(dir, "known_hosts")
+	assert.NoError(t, os.WriteFile(knownHosts,
+		[]byte("[example.com]:2222 "+string(gossh.MarshalAuthorizedKey(signer.PublicKey()))), 0644))
+	remoteAddr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}
+	passphraseFile := filepath.Join(dir, "passphrase")
+	assert.NoError(t, os.WriteFile(passphraseFile, []byte("secret\n"), 0600))
+
+	t.Run("encrypted key with custom user and port", func(t *testing.T) {
+		opt := &gitAuthOption{
+			sshPrivateKey:     filepath.Join(dir, "encrypted"),
+			sshPassphraseFile: passphraseFile,
+			knownHosts:        []string{knownHosts},
+		}
+		auth, err := opt.getAuth("ssh://deploy@example.com:2222/linuxsuren/gogit.git")
+		assert.NoError(t, err)
+		algorithmsAuth, ok := auth.(*hostKeyAlgorithmsAuth)
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.Equal(t, []string{gossh.KeyAlgoED25519}, algorithmsAuth.algorithms)
+		keys, ok := algorithmsAuth.AuthMethod.(*ssh.PublicKeys)
+		if assert.True(t, ok) {
+			assert.Equal(t, "deploy", keys.User)
+			assert.NoError(t, keys.HostKeyCallback("example.com:2222", remoteAddr, signer.PublicKey()))
+			assert.Error(t, keys.HostKeyCallback("example.com:22", remoteAddr, signer.PublicKey()))
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		t.Setenv(sshPassphraseEnv, "wrong")
+		opt := &gitAuthOption{sshPrivateKey: filepath.Join(dir, "encrypted"), knownHosts: []string{knownHosts}}
+		_, err := opt.getAuth("git@github.com:linuxsuren/gogit.git")
+		assert.Error(t, err)
+	})
+
+	t.Run("passphrase from the environment", func(t *testing.T) {
+		t.Setenv(sshPassphraseEnv, "secret")
+		opt := &gitAuthOption{sshPrivateKey: filepath.Join(dir, "encrypted"), knownHosts: []string{knownHosts}}
+		auth, err := opt.getAuth("git@github.com:linuxsuren/gogit.git")
+		assert.NoError(t, err)
+		assert.Equal(t, "git", auth.(*ssh.PublicKeys).User)
+	})
+
+	t.Run("missing known_hosts", func(t *testing.T) {
+		opt := &gitAuthOption{sshPrivateKey: filepath.Join(dir, "id_ed25519"), knownHosts: []string{filepath.Join(dir, "missing")}}
+		_, err := opt.getAuth("git@github.com:linuxsuren/gogit.git")
+		assert.Error(t, err)
+	})
+
+	t.Run("ignore the host key", func(t *testing.T) {
+		opt := &gitAuthOption{sshPrivateKey: filepath.Join(dir, "id_ed25519"), insecureIgnoreHostKey: true,
+			knownHosts: []string{filepath.Join(dir, "missing")}}
+		auth, err := opt.getAuth("git@github.com:linuxsuren/gogit.git")
+		assert.NoError(t, err)
+		assert.NoError(t, auth.(*ssh.PublicKeys).HostKeyCallback("github.com:22", remoteAddr, signer.PublicKey()))
+	})
+}
+
+func TestKnownHostKeyAlgorithms(t *testing.T) {
+	dir := t.TempDir()
+	ed25519Signer := writeSSHKeys(t, dir)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ecdsaSigner, err := gossh.NewSignerFromKey(ecdsaKey)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaSigner, err := gossh.NewSignerFromKey(rsaKey)
+	assert.NoError(t, err)
+
+	// the server offers both the ecdsa and ed25519 host keys, only the ed25519 one is known
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+	go func() {
+		config := &gossh.ServerConfig{NoClientAuth: true}
+		config.AddHostKey(ecdsaSigner)
+		config.AddHostKey(ed25519Signer)
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				if serverConn, _, _, handshakeErr := gossh.NewServerConn(conn, config); handshakeErr == nil {
+					_ = serverConn.Close()
+				}
+			}()
+		}
+	}()
+	address := listener.Addr().String()
+	host, port, err := net.SplitHostPort(address)
+	assert.NoError(t, err)
+
+	knownHosts := filepath.Join(dir, "known_hosts")
+	assert.NoError(t, os.WriteFile(knownHosts, []byte(
+		"other.com "+string(gossh.MarshalAuthorizedKey(ecdsaSigner.PublicKey()))+
+			"["+host+"]:"+port+" "+string(gossh.MarshalAuthorizedKey(ed25519Signer.PublicKey()))+
+			"["+host+"]:"+port+" "+string(gossh.MarshalAuthorizedKey(rsaSigner.PublicKey()))), 0644))
+
+	opt := &gitAuthOption{sshPrivateKey: filepath.Join(dir, "id_ed25519"), knownHosts: []string{knownHosts}}
+	auth, err := opt.getAuth("ssh://git@" + address + "/linuxsuren/gogit.git")
+	assert.NoError(t, err)
+	sshAuth, ok := auth.(ssh.AuthMethod)
+	if !assert.True(t, ok) {
+		return
+	}
+	config, err := sshAuth.ClientConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{gossh.KeyAlgoED25519, gossh.KeyAlgoRSASHA512, gossh.KeyAlgoRSASHA256, gossh.KeyAlgoRSA},
+		config.HostKeyAlgorithms)
+
+	dial := func(config *gossh.ClientConfig) error {
+		conn, dialErr := net.Dial("tcp", address)
+		if dialErr != nil {
+			return dialErr
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		clientConn, _, _, dialErr := gossh.NewClientConn(conn, address, config)
+		if dialErr == nil {
+			_ = clientConn.Close()
+		}
+		return dialErr
+	}
+	assert.NoError(t, dial(config))
+
+	// the ecdsa host key is preferred by default, it's not known
+	config.HostKeyAlgorithms = nil
+	assert.Error(t, dial(config))
+
+	assert.Empty(t, knownHostKeyAlgorithms(config.HostKeyCallback, "unknown.com:22"))
+	assert.Equal(t, []string{gossh.KeyAlgoECDSA256}, knownHostKeyAlgorithms(config.HostKeyCallback, "other.com:22"))
+	assert.Empty(t, knownHostKeyAlgorithms(config.HostKeyCallback, ""))
+}
+
+func TestDiscoverSSHKey(t *testing.T) {
+	dir := t.TempDir()
+	_, err := discoverSSHKey(dir)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "id_rsa"), nil, 0600))
+	keyFile, err := discoverSSHKey(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "id_rsa"), keyFile)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "id_ed25519"), nil, 0600))
+	keyFile, err = discoverSSHKey(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "id_ed25519"), keyFile)
+}
+
+func TestSSHAddress(t *testing.T) {
+	assert.Equal(t, "github.com:22", sshAddress("git@github.com:linuxsuren/gogit.git"))
+	assert.Equal(t, "example.com:2222", sshAddress("ssh://deploy@example.com:2222/linuxsuren/gogit.git"))
+	assert.Empty(t, sshAddress("/tmp/gogit"))
+}
+
+func TestSSHUser(t *testing.T) {
+	tests := []struct {
+		remote string
+		user   string
+		ok     bool
+	}{
+		{remote: "git@github.com:linuxsuren/gogit.git", user: "git", ok: true},
+		{remote: "deploy@example.com:linuxsuren/gogit.git", user: "deploy", ok: true},
+		{remote: "ssh://deploy@example.com:2222/linuxsuren/gogit.git", user: "deploy", ok: true},
+		{remote: "ssh://example.com/linuxsuren/gogit.git", user: "git", ok: true},
+		{remote: "git@fake.com", user: "git", ok: true},
+		{remote: "https://github.com/linuxsuren/gogit.git"},
+		{remote: "/tmp/gogit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			user, ok := sshUser(tt.remote)
+			assert.Equal(t, tt.user, user)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+// writeSSHKeys writes an ed25519 key, and a RSA key encrypted by "secret", the signer of the ed25519 key is returned
+func writeSSHKeys(t *testing.T, dir string) gossh.Signer {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	data, err := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "id_ed25519"),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}), 0600))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	//nolint:staticcheck // the legacy PEM encryption is still supported by ssh.ParsePrivateKeyWithPassphrase
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey),
+		[]byte("secret"), x509.PEMCipherAES256)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "encrypted"), pem.EncodeToMemory(block), 0600))
+
+	signer, err := gossh.NewSignerFromKey(ed25519Key)
+	assert.NoError(t, err)
+	return signer
+}
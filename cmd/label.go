@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newLabelCommand() (c *cobra.Command) {
+	c = &cobra.Command{
+		Use:   "label",
+		Short: "Manage the labels of the pull request, issue or repository",
+	}
+
+	c.AddCommand(newLabelAddCommand(), newLabelRemoveCommand(),
+		newLabelListCommand(), newLabelSyncCommand())
+	return
+}
+
+func newLabelAddCommand() (c *cobra.Command) {
+	opt := &labelOption{}
+	c = &cobra.Command{
+		Use:     "add",
+		Short:   "Add labels to the pull request or issue",
+		Example: `gogit label add ci/failed --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN`,
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: opt.preRunE,
+		RunE: func(c *cobra.Command, args []string) (err error) {
+			if maker := pkg.NewMaker(c.Context(), opt.getRepoInformation()); maker != nil {
+				err = maker.AddLabels(c.Context(), args...)
+			}
+			return
+		},
+	}
+	opt.addTargetFlags(c, false)
+	return
+}
+
+func newLabelRemoveCommand() (c *cobra.Command) {
+	opt := &labelOption{}
+	c = &cobra.Command{
+		Use:     "remove",
+		Short:   "Remove labels from the pull request or issue",
+		Example: `gogit label remove needs-rebase --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN`,
+		Aliases: []string{"rm"},
+		Args:    cobra.MinimumNArgs(1),
+		PreRunE: opt.preRunE,
+		RunE: func(c *cobra.Command, args []string) (err error) {
+			if maker := pkg.NewMaker(c.Context(), opt.getRepoInformation()); maker != nil {
+				err = maker.RemoveLabels(c.Context(), args...)
+			}
+			return
+		},
+	}
+	opt.addTargetFlags(c, false)
+	return
+}
+
+func newLabelListCommand() (c *cobra.Command) {
+	opt := &labelOption{}
+	c = &cobra.Command{
+		Use:     "list",
+		Short:   "List the labels of the pull request or issue",
+		Aliases: []string{"ls"},
+		PreRunE: opt.preRunE,
+		RunE: func(c *cobra.Command, args []string) (err error) {
+			if maker := pkg.NewMaker(c.Context(), opt.getRepoInformation()); maker != nil {
+				var labels []*scm.Label
+				if labels, err = maker.ListLabels(c.Context()); err == nil {
+					for _, label := range labels {
+						c.Println(label.Name)
+					}
+				}
+			}
+			return
+		},
+	}
+	opt.addTargetFlags(c, false)
+	return
+}
+
+func newLabelSyncCommand() (c *cobra.Command) {
+	opt := &labelSyncOption{}
+	c = &cobra.Command{
+		Use:   "sync",
+		Short: "Make the labels of the repository match the declared labels",
+		Long: `Make the labels of the repository match the declared labels. Only GitHub and GitLab are supported.
+The labels file is a YAML (or JSON) list, each item has the name, color and description.
+The labels which are not declared are kept unless --prune is set, so it's safe to sync a subset of the labels.`,
+		Example: `gogit label sync -f labels.yaml --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN
+gogit label sync -f labels.yaml --prune --dry-run --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.file, "file", "f", "", "The file of the declared labels")
+	flags.BoolVarP(&opt.prune, "prune", "", false, "Delete the labels which are not declared, they are kept by default")
+	flags.BoolVarP(&opt.dryRun, "dry-run", "", false, "Print the changes without applying them")
+	_ = c.MarkFlagRequired("file")
+	return
+}
+
+func (o *labelSyncOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	var data []byte
+	if data, err = os.ReadFile(o.file); err == nil {
+		o.labels, err = pkg.ParseLabels(data)
+	}
+	return
+}
+
+func (o *labelSyncOption) runE(c *cobra.Command, args []string) (err error) {
+	if maker := pkg.NewMaker(c.Context(), o.getRepoInformation()); maker != nil {
+		var changes pkg.LabelChanges
+		changes, err = maker.SyncLabels(c.Context(), o.labels, o.prune, o.dryRun)
+		printLabelChanges(c, changes, o.dryRun)
+	}
+	return
+}
+
+// printLabelChanges prints the changes of the labels, they are prefixed with (dry-run) if they are not applied
+func printLabelChanges(c *cobra.Command, changes pkg.LabelChanges, dryRun bool) {
+	var prefix string
+	if dryRun {
+		prefix = "(dry-run) "
+	}
+	for _, label := range changes.Create {
+		c.Printf("%screate %s\n", prefix, label.Name)
+	}
+	for _, label := range changes.Update {
+		c.Printf("%supdate %s\n", prefix, label.Name)
+	}
+	for _, label := range changes.Delete {
+		c.Printf("%sdelete %s\n", prefix, label.Name)
+	}
+}
+
+type labelOption struct {
+	targetOption
+}
+
+type labelSyncOption struct {
+	labelOption
+	file   string
+	prune  bool
+	dryRun bool
+	labels []pkg.Label
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelCmd(t *testing.T) {
+	t.Run("sub-commands", func(t *testing.T) {
+		c := newLabelCommand()
+		assert.Len(t, c.Commands(), 4)
+	})
+
+	t.Run("target is required", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"label", "add", "bug", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "one of the flags --pr or --issue is required")
+	})
+
+	t.Run("invalid labels file", func(t *testing.T) {
+		f, err := os.CreateTemp(t.TempDir(), "labels")
+		assert.NoError(t, err)
+		_, _ = f.WriteString(`[{"color": "ffffff"}]`)
+		_ = f.Close()
+
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"label", "sync", "-f", f.Name(), "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err = c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is empty")
+	})
+}
+
+func TestPrintLabelChanges(t *testing.T) {
+	changes := pkg.LabelChanges{
+		Create: []pkg.Label{{Name: "bug"}},
+		Update: []pkg.Label{{Name: "size/XL"}},
+		Delete: []pkg.Label{{Name: "wontfix"}},
+	}
+
+	buf := new(bytes.Buffer)
+	c := newLabelSyncCommand()
+	c.SetOut(buf)
+	printLabelChanges(c, changes, false)
+	assert.Equal(t, "create bug\nupdate size/XL\ndelete wontfix\n", buf.String())
+
+	buf.Reset()
+	printLabelChanges(c, changes, true)
+	assert.Equal(t, "(dry-run) create bug\n(dry-run) update size/XL\n(dry-run) delete wontfix\n", buf.String())
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newLintCmd() (c *cobra.Command) {
+	c = &cobra.Command{
+		Use:   "lint",
+		Short: "Lint the pull request, and publish the result as a status",
+	}
+
+	c.AddCommand(newLintCommitsCmd())
+	return
+}
+
+func newLintCommitsCmd() (c *cobra.Command) {
+	opt := &lintCommitsOption{}
+	c = &cobra.Command{
+		Use:   "commits",
+		Short: "Validate the title and all the commit messages of the pull request against the conventional commits",
+		Example: `gogit lint commits --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN \
+  --config commitlint.yaml`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addFlags(c)
+	opt.addOutputFlag(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.configFile, "config", "", "",
+		"The YAML file of the rules, such as types, scopes and maxLength. The conventional commits rules are used if it's empty")
+	flags.StringVarP(&opt.label, "label", "", "gogit/commit-lint", "The label of the status")
+	flags.StringVarP(&opt.target, "target", "", "", "The target URL of the status")
+	flags.BoolVarP(&opt.comment, "comment", "", true,
+		"Create a sticky comment with the violations, or delete it if there is no violation")
+	flags.StringVarP(&opt.identity, "identity", "", pkg.CommitLintCommentMarker, "The identity for matching exiting comment")
+	return
+}
+
+func (o *lintCommitsOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if err = validateOutputFormat(o.output); err != nil {
+		return
+	}
+
+	o.rules = pkg.DefaultCommitLintRules()
+	if o.configFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.configFile); err == nil {
+			o.rules, err = pkg.ParseCommitLintRules(data)
+		}
+	}
+	return
+}
+
+func (o *lintCommitsOption) runE(c *cobra.Command, args []string) (err error) {
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		PrNumber: o.pr,
+		Target:   o.target,
+		Username: o.username,
+		Token:    o.token,
+	})
+	if maker == nil {
+		return
+	}
+
+	var violations []pkg.CommitLintViolation
+	if violations, err = maker.LintCommits(c.Context(), o.rules); err != nil {
+		return
+	}
+	if err = printOutput(c.OutOrStdout(), o.output, commitLintViolations(violations)); err != nil {
+		return
+	}
+
+	state, desc := scm.StateSuccess, "The title and commits follow the conventional commits"
+	if len(violations) > 0 {
+		state, desc = scm.StateFailure, fmt.Sprintf("%d violation(s) of the conventional commits", len(violations))
+	}
+	if err = maker.CreateStatus(c.Context(), state, o.label, desc); err != nil {
+		return
+	}
+
+	if o.comment {
+		if len(violations) > 0 {
+			err = maker.CreateComment(c.Context(), pkg.CommitLintReport(violations), o.identity)
+		} else {
+			_, err = maker.DeleteComments(c.Context(), o.identity)
+		}
+	}
+	return
+}
+
+type commitLintViolations []pkg.CommitLintViolation
+
+func (v commitLintViolations) Rows() (rows [][]string) {
+	rows = append(rows, []string{"SHA", "HEADER", "REASONS"})
+	for _, violation := range v {
+		sha := violation.Sha
+		if sha == "" {
+			sha = "(title)"
+		} else if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		rows = append(rows, []string{sha, violation.Header, strings.Join(violation.Reasons, "; ")})
+	}
+	return
+}
+
+type lintCommitsOption struct {
+	pullRequestQueryOption
+	configFile string
+	label      string
+	target     string
+	comment    bool
+	identity   string
+
+	rules pkg.CommitLintRules
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintCommitsCmd(t *testing.T) {
+	t.Run("invalid config", func(t *testing.T) {
+		config := filepath.Join(t.TempDir(), "commitlint.yaml")
+		assert.NoError(t, os.WriteFile(config, []byte("ignore: ['(']"), 0644))
+
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"lint", "commits", "--config", config, "--pr=1", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid ignore pattern")
+	})
+
+	t.Run("violation rows", func(t *testing.T) {
+		rows := commitLintViolations([]pkg.CommitLintViolation{
+			{Header: "wip", Reasons: []string{"a", "b"}},
+			{Sha: "1234567890", Header: "fix: bug", Reasons: []string{"c"}},
+		}).Rows()
+		assert.Equal(t, [][]string{
+			{"SHA", "HEADER", "REASONS"},
+			{"(title)", "wip", "a; b"},
+			{"1234567", "fix: bug", "c"},
+		}, rows)
+	})
+}
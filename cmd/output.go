@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormats are the supported formats of the structured output
+var outputFormats = []string{"table", "json", "yaml"}
+
+// tableRows converts an object to the rows of the table output, the first row is the header
+type tableRows interface {
+	Rows() [][]string
+}
+
+func validateOutputFormat(format string) (err error) {
+	if !slices.Contains(outputFormats, format) {
+		err = fmt.Errorf("invalid output format %q, should be one of %v", format, outputFormats)
+	}
+	return
+}
+
+func printOutput(w io.Writer, format string, obj tableRows) (err error) {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(obj)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		err = encoder.Encode(obj)
+	default:
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, row := range obj.Rows() {
+			for i, cell := range row {
+				if i > 0 {
+					fmt.Fprint(writer, "\t")
+				}
+				fmt.Fprint(writer, cell)
+			}
+			fmt.Fprintln(writer)
+		}
+		err = writer.Flush()
+	}
+	return
+}
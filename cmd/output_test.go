@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintOutput(t *testing.T) {
+	summaries := newPullRequestSummaries([]*scm.PullRequest{{
+		Number: 1,
+		Title:  "fix",
+		Merged: true,
+		Closed: true,
+		Source: "feature",
+		Target: "master",
+		Labels: []*scm.Label{{Name: "bug"}},
+		Author: scm.User{Login: "rick"},
+	}})
+
+	tests := []struct {
+		name   string
+		format string
+		expect string
+	}{{
+		name:   "table",
+		format: "table",
+		expect: "NUMBER  TITLE  STATE   AUTHOR  BASE    HEAD     LINK\n1       fix    merged  rick    master  feature  \n",
+	}, {
+		name:   "json",
+		format: "json",
+		expect: `"labels": [
+      "bug"
+    ]`,
+	}, {
+		name:   "yaml",
+		format: "yaml",
+		expect: "- number: 1\n  title: fix\n  state: merged\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			assert.NoError(t, printOutput(buf, tt.format, summaries))
+			assert.Contains(t, buf.String(), tt.expect)
+		})
+	}
+
+	assert.Error(t, validateOutputFormat("xml"))
+}
@@ -1,15 +1,15 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"os"
+	"sort"
 	"strings"
-	"sync"
-
 	"text/template"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/linuxsuren/gogit/pkg"
@@ -31,21 +31,43 @@ func newPullRequestCmd() (c *cobra.Command) {
 	flags.BoolVarP(&opt.printAssignee, "assignee", "", false, "Print the assignees of the pull request")
 	flags.StringVarP(&opt.msg, "msg", "", "", "The message of the pull request")
 	flags.StringSliceVarP(&opt.dingdingTokenPairs, "dingding-tokens", "", []string{}, "The dingding token pairs of the pull request, format: login=token")
+	flags.StringSliceVarP(&opt.dingdingSecretPairs, "dingding-secrets", "", []string{}, "The dingding signing secret pairs of the pull request, format: login=secret")
+	flags.StringVarP(&opt.dingdingMsgType, "dingding-msg-type", "", pkg.DingDingText, "The dingding message type, one of text, markdown, actionCard and link")
+	opt.addNotifyFlags(c)
 	flags.BoolVarP(&opt.skipInvalidPR, "skip-invalid-pr", "", true, "Skip the invalid pull request")
+	flags.StringSliceVarP(&opt.addLabels, "add-label", "", []string{}, "The labels to add to the pull request")
+	flags.StringSliceVarP(&opt.removeLabels, "remove-label", "", []string{}, "The labels to remove from the pull request")
+	flags.StringVarP(&opt.status, "status", "", "",
+		"The current build status, such as: pending, success, failure. Notify only when the status of --status-label changes")
+	flags.StringVarP(&opt.statusLabel, "status-label", "", "gogit", "The label of the status which is compared with --status")
+	flags.StringVarP(&opt.statusTarget, "status-target", "", "", "The target URL of the current build status")
+
+	c.AddCommand(newPullRequestCreateCmd(), newPullRequestMergeCmd(),
+		newPullRequestListCmd(), newPullRequestFindCmd(), newPullRequestAssignReviewersCmd(), newPullRequestDigestCmd(),
+		newPullRequestStatsCmd())
 	return
 }
 
 func (o *pullRequestOption) preRunE(c *cobra.Command, args []string) (err error) {
-	o.dingdingTokenMap = make(map[string]string)
-	for _, pair := range o.dingdingTokenPairs {
-		keyVal := strings.Split(pair, "=")
-		if len(keyVal) == 2 {
-			o.dingdingTokenMap[keyVal[0]] = keyVal[1]
-		} else {
-			err = fmt.Errorf("invalid dingding token pair: %q", pair)
-			return
-		}
+	if o.dingdingTokenMap, err = parsePairs(o.dingdingTokenPairs, "dingding token"); err != nil {
+		return
+	}
+	if o.dingdingSecretMap, err = parsePairs(o.dingdingSecretPairs, "dingding secret"); err != nil {
+		return
 	}
+	switch o.dingdingMsgType {
+	case pkg.DingDingText, pkg.DingDingMarkdown, pkg.DingDingActionCard, pkg.DingDingLink:
+	default:
+		err = fmt.Errorf("unsupported dingding message type %q", o.dingdingMsgType)
+		return
+	}
+
+	if o.status != "" && scm.ToState(strings.ToLower(o.status)) == scm.StateUnknown {
+		err = fmt.Errorf("unsupported status %q", o.status)
+		return
+	}
+
+	err = o.loadNotifyFiles()
 	return
 }
 
@@ -67,6 +89,36 @@ func (o *pullRequestOption) runE(c *cobra.Command, args []string) (err error) {
 		return
 	}
 
+	if err = o.updateLabels(c.Context(), scmClient); err != nil {
+		return
+	}
+
+	var transition *pkg.StatusTransition
+	// sent is true if the notification is sent to anyone
+	var sent bool
+	if o.status != "" {
+		maker := pkg.NewStatusMaker(o.repo, o.token).WithProvider(o.provider).WithPR(o.pr)
+		current := &scm.StatusInput{
+			Label:  o.statusLabel,
+			State:  scm.ToState(strings.ToLower(o.status)),
+			Target: o.statusTarget,
+		}
+		if transition, err = maker.FindStatusTransition(c.Context(), scmClient, pr.Sha, current); err != nil {
+			return
+		}
+		// record the status once the notifications are sent, then a failed notification is sent again next time
+		defer func() {
+			if err == nil && sent {
+				err = maker.RecordStatus(c.Context(), scmClient, pr.Sha, current)
+			}
+		}()
+
+		if transition == nil {
+			c.Printf("skip the notification due to the status %q is not changed\n", o.statusLabel)
+			return
+		}
+	}
+
 	users := make(map[string]string, 0)
 	addToMap(users, pr.Author.Login)
 
@@ -86,57 +138,78 @@ func (o *pullRequestOption) runE(c *cobra.Command, args []string) (err error) {
 		addToMap(users, user.Login)
 	}
 
-	var wait sync.WaitGroup
-	for login, _ := range users {
-		wait.Add(1)
-		go func(login string) {
-			defer wait.Done()
-			if token, ok := o.dingdingTokenMap[login]; ok {
-				sendToDingDing(login, token, o.msg, pr, c.OutOrStderr())
+	logins := make([]string, 0, len(users))
+	for login := range users {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	var recipients []string
+	var notifiers map[string]pkg.Notifier
+	if recipients, notifiers, err = o.getNotifiers(logins); err != nil {
+		return
+	}
+
+	formattedMsg, fmtErr := formatMessage(o.msg, pr, transition)
+	if fmtErr != nil {
+		log.Printf("cannot format the message %q: %v\n", o.msg, fmtErr)
+		formattedMsg = o.msg
+	}
+	msg := pkg.Message{Title: pr.Title, Text: formattedMsg, Link: pr.Link, Recipients: recipients,
+		PullRequest: pr, Transition: transition}
+	err = pkg.SendNotifications(c.Context(), notifiers, msg, c.OutOrStderr())
+	sent = len(notifiers) > 0
+	return
+}
+
+// getNotifiers returns the users who want to be notified and their notifiers, the key is the name of login or channel
+func (o *pullRequestOption) getNotifiers(logins []string) (recipients []string, notifiers map[string]pkg.Notifier, err error) {
+	resolver := &pkg.NotifyResolver{
+		Provider:  o.provider,
+		Repo:      o.repo,
+		Config:    o.notifyConfig,
+		Directory: o.userDirectory,
+	}
+	if recipients, notifiers, err = resolver.Resolve(logins, time.Now()); err != nil {
+		return
+	}
+
+	for _, login := range recipients {
+		if token, ok := o.dingdingTokenMap[login]; ok {
+			notifiers[login] = &pkg.DingDingNotifier{
+				Client:  pkg.NewDingDingClient(token, o.dingdingSecretMap[login]),
+				MsgType: o.dingdingMsgType,
 			}
-		}(login)
+		}
 	}
-	wait.Wait()
 	return
 }
 
-func sendToDingDing(login, token, msg string, pr *scm.PullRequest, c io.Writer) (err error) {
-	formattedMsg, fmtErr := formatMessage(msg, pr)
-	if fmtErr != nil {
-		log.Printf("cannot format the message %q: %v\n", msg, fmtErr)
-		formattedMsg = msg
-	}
-
-	api := fmt.Sprintf("https://oapi.dingtalk.com/robot/send?access_token=%s", token)
-	payload := strings.NewReader(`{"msgtype": "text", "text": {"content": "` + formattedMsg + `"}}`)
-	fmt.Println(`{"msgtype": "text", "text": {"content": "`+formattedMsg+`"}}`, api)
-
-	var resp *http.Response
-	resp, err = http.Post(api, "application/json", payload)
-	if err != nil {
-		fmt.Fprintln(c, err)
-	} else if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(c, "send message to %q failed, received code %d instead of 200\n", login, resp.StatusCode)
-	} else {
-		body, _ := io.ReadAll(resp.Body)
-
-		dingdingResp := &DingDingResponse{}
-		if err := json.Unmarshal(body, dingdingResp); err != nil {
-			fmt.Fprintf(c, "cannot unmarshal the response for %q: %v\n", login, err)
-		} else if dingdingResp.ErrCode != 0 {
-			fmt.Fprintf(c, "receive error response for %q: %q\n", login, dingdingResp.ErrMsg)
-		} else {
-			fmt.Fprintf(c, "send message to %q successfully\n", login)
+func (o *pullRequestOption) updateLabels(ctx context.Context, scmClient *scm.Client) (err error) {
+	for _, label := range o.addLabels {
+		if _, addErr := scmClient.PullRequests.AddLabel(ctx, o.repo, o.pr, label); addErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add label %q: %v", label, addErr))
+		}
+	}
+	for _, label := range o.removeLabels {
+		if _, removeErr := scmClient.PullRequests.DeleteLabel(ctx, o.repo, o.pr, label); removeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to remove label %q: %v", label, removeErr))
 		}
 	}
 	return
 }
 
-func formatMessage(msg string, pr *scm.PullRequest) (result string, err error) {
+// messageData is the data of the message template, the fields of the pull request are promoted
+type messageData struct {
+	*scm.PullRequest
+	Transition *pkg.StatusTransition
+}
+
+func formatMessage(msg string, pr *scm.PullRequest, transition *pkg.StatusTransition) (result string, err error) {
 	var tpl *template.Template
 	if tpl, err = template.New("message").Parse(msg); err == nil {
 		var b strings.Builder
-		if err = tpl.Execute(&b, pr); err == nil {
+		if err = tpl.Execute(&b, messageData{PullRequest: pr, Transition: transition}); err == nil {
 			result = b.String()
 		}
 		err = pkg.WrapError(err, "cannot format the message %q: %v", msg)
@@ -145,9 +218,18 @@ func formatMessage(msg string, pr *scm.PullRequest) (result string, err error) {
 	return
 }
 
-type DingDingResponse struct {
-	ErrCode int    `json:"errcode"`
-	ErrMsg  string `json:"errmsg"`
+// parsePairs parses the pairs with format key=value
+func parsePairs(pairs []string, kind string) (result map[string]string, err error) {
+	result = make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		keyVal := strings.Split(pair, "=")
+		if len(keyVal) != 2 {
+			err = fmt.Errorf("invalid %s pair: %q", kind, pair)
+			return
+		}
+		result[keyVal[0]] = keyVal[1]
+	}
+	return
 }
 
 func addToMap(m map[string]string, k string) {
@@ -158,11 +240,55 @@ func addToMap(m map[string]string, k string) {
 
 type pullRequestOption struct {
 	gitProviderOption
-	printAuthor        bool
-	printReviewer      bool
-	printAssignee      bool
-	msg                string
-	dingdingTokenPairs []string
-	dingdingTokenMap   map[string]string
-	skipInvalidPR      bool
+	notifyOption
+	printAuthor         bool
+	printReviewer       bool
+	printAssignee       bool
+	msg                 string
+	dingdingTokenPairs  []string
+	dingdingTokenMap    map[string]string
+	dingdingSecretPairs []string
+	dingdingSecretMap   map[string]string
+	dingdingMsgType     string
+	skipInvalidPR       bool
+	addLabels           []string
+	removeLabels        []string
+	status              string
+	statusLabel         string
+	statusTarget        string
+}
+
+// notifyOption is the option of the notify config and the user directory
+type notifyOption struct {
+	notifyConfigFile  string
+	notifyConfig      *pkg.NotifyConfig
+	userDirectoryFile string
+	userDirectory     *pkg.UserDirectory
+}
+
+func (o *notifyOption) addNotifyFlags(c *cobra.Command) {
+	flags := c.Flags()
+	flags.StringVarP(&o.notifyConfigFile, "notify-config", "", "", "The config file for choosing the notify channels per user or group")
+	flags.StringVarP(&o.userDirectoryFile, "user-directory", "", "",
+		"The user directory file which maps the git logins to the emails, chat identities and notification preferences")
+}
+
+func (o *notifyOption) loadNotifyFiles() (err error) {
+	if o.notifyConfigFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.notifyConfigFile); err != nil {
+			return
+		}
+		if o.notifyConfig, err = pkg.ParseNotifyConfig(data); err != nil {
+			return
+		}
+	}
+
+	if o.userDirectoryFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.userDirectoryFile); err == nil {
+			o.userDirectory, err = pkg.ParseUserDirectory(data)
+		}
+	}
+	return
 }
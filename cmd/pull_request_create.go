@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newPullRequestCreateCmd() (c *cobra.Command) {
+	opt := &pullRequestCreateOption{}
+	c = &cobra.Command{
+		Use:   "create",
+		Short: "Create a pull request from the current branch, or update the existing one",
+		Example: `gogit pr create --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN \
+  --title 'chore: bump dependencies' --body-file body.md --label dependencies`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.Head, "head", "", "", "The head branch, or owner:branch for a branch of a fork. The current branch will be used if it's empty")
+	flags.StringVarP(&opt.Base, "base", "", "", "The base branch, the default branch of the repository will be used if it's empty")
+	flags.StringVarP(&opt.Title, "title", "", "", "The title of the pull request")
+	flags.StringVarP(&opt.Body, "body", "", "", "The body of the pull request")
+	flags.StringVarP(&opt.bodyFile, "body-file", "", "", "Read the body of the pull request from a file")
+	flags.StringVarP(&opt.bodyTemplate, "body-template", "", "",
+		"Render the body of the pull request from a Go template file, available fields: Head, Base, Title")
+	flags.BoolVarP(&opt.Draft, "draft", "", false, "Create the pull request as a draft")
+	flags.StringSliceVarP(&opt.Reviewers, "reviewer", "", []string{}, "The reviewers of the pull request")
+	flags.StringSliceVarP(&opt.Assignees, "assignee", "", []string{}, "The assignees of the pull request")
+	flags.StringSliceVarP(&opt.Labels, "label", "", []string{}, "The labels of the pull request")
+	flags.StringVarP(&opt.workDir, "work-dir", "", ".", "The git repository directory for detecting the current branch")
+	_ = c.MarkFlagRequired("title")
+	c.MarkFlagsMutuallyExclusive("body", "body-file", "body-template")
+	return
+}
+
+func (o *pullRequestCreateOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if o.Head == "" {
+		if o.Head, err = currentBranch(o.workDir); err != nil {
+			return
+		}
+	}
+
+	if o.bodyFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(o.bodyFile); err == nil {
+			o.Body = string(data)
+		}
+	}
+	return
+}
+
+func (o *pullRequestCreateOption) runE(c *cobra.Command, args []string) (err error) {
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		Username: o.username,
+		Token:    o.token,
+	})
+
+	if err = o.renderBody(c.Context(), maker); err != nil {
+		return
+	}
+
+	var pr *scm.PullRequest
+	var created bool
+	pr, created, err = maker.CreateOrUpdatePullRequest(c.Context(), o.PullRequestInput)
+	if pr != nil {
+		if created {
+			c.Println("created pull request", pr.Number, pr.Link)
+		} else {
+			c.Println("updated pull request", pr.Number, pr.Link)
+		}
+	}
+	return
+}
+
+// renderBody renders the body template after the base branch is resolved, then {{.Base}} is never empty
+func (o *pullRequestCreateOption) renderBody(ctx context.Context, maker *pkg.StatusMaker) (err error) {
+	if o.bodyTemplate == "" {
+		return
+	}
+	if o.Base == "" {
+		if o.Base, err = maker.DefaultBranch(ctx); err != nil {
+			return
+		}
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(o.bodyTemplate); err == nil {
+		o.Body, err = renderPullRequestBody(string(data), o.PullRequestInput)
+	}
+	return
+}
+
+func renderPullRequestBody(text string, input pkg.PullRequestInput) (body string, err error) {
+	var tpl *template.Template
+	if tpl, err = template.New("body").Parse(text); err == nil {
+		var b strings.Builder
+		if err = tpl.Execute(&b, input); err == nil {
+			body = b.String()
+		}
+	}
+	err = pkg.WrapError(err, "cannot render the body template: %v")
+	return
+}
+
+func currentBranch(dir string) (branch string, err error) {
+	var repo *git.Repository
+	if repo, err = git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true}); err != nil {
+		err = fmt.Errorf("cannot open git repository %q: %v", dir, err)
+		return
+	}
+
+	var head *plumbing.Reference
+	if head, err = repo.Head(); err != nil {
+		return
+	}
+
+	if !head.Name().IsBranch() {
+		err = fmt.Errorf("HEAD is not a branch, please provide the head branch")
+		return
+	}
+	branch = head.Name().Short()
+	return
+}
+
+type pullRequestCreateOption struct {
+	gitProviderOption
+	pkg.PullRequestInput
+	bodyFile     string
+	bodyTemplate string
+	workDir      string
+}
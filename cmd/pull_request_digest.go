@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newPullRequestDigestCmd() (c *cobra.Command) {
+	opt := &pullRequestDigestOption{}
+	c = &cobra.Command{
+		Use:   "digest",
+		Short: "Send the digests of the stale or failed pull requests to the reviewers, and the summaries to the team",
+		Example: `gogit pr digest --provider github --username linuxsuren --token $GITHUB_TOKEN \
+  --repo gogit,linuxsuren/api-testing --stale-after 48h --user-directory users.yaml \
+  --notify-config notify.yaml --team-channel backend`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	opt.addNotifyFlags(c)
+	flags := c.Flags()
+	flags.Lookup("repo").Usage = "Names of the target git repositories, separated by comma"
+	flags.DurationVarP(&opt.staleAfter, "stale-after", "", 24*time.Hour, "Flag the pull requests without any review after the duration")
+	flags.StringSliceVarP(&opt.teamChannels, "team-channel", "", []string{},
+		"The channels of --notify-config which receive the summaries of the repositories")
+	flags.StringVarP(&opt.userTemplateFile, "user-template", "", "",
+		"The Go template file of the personalized digest, see also pkg.UserDigest")
+	flags.StringVarP(&opt.repoTemplateFile, "repo-template", "", "",
+		"The Go template file of the repository summary, see also pkg.RepoDigest")
+	flags.BoolVarP(&opt.dryRun, "dry-run", "", false, "Print the pull requests without sending the digests")
+	return
+}
+
+func (o *pullRequestDigestOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if err = o.loadNotifyFiles(); err != nil {
+		return
+	}
+
+	var userTemplate, repoTemplate string
+	if userTemplate, err = readOptionalFile(o.userTemplateFile); err != nil {
+		return
+	}
+	if repoTemplate, err = readOptionalFile(o.repoTemplateFile); err != nil {
+		return
+	}
+
+	team := make(map[string]pkg.Notifier, len(o.teamChannels))
+	for _, name := range o.teamChannels {
+		var channel *pkg.NotifyChannel
+		if o.notifyConfig != nil {
+			for i := range o.notifyConfig.Channels {
+				if o.notifyConfig.Channels[i].Name == name {
+					channel = &o.notifyConfig.Channels[i]
+				}
+			}
+		}
+		if channel == nil {
+			err = fmt.Errorf("the team channel %q is not found in the notify config", name)
+			return
+		}
+		if team[name], err = channel.Notifier(); err != nil {
+			return
+		}
+	}
+
+	o.sender, err = pkg.NewDigestSender(&pkg.NotifyResolver{
+		Provider:  o.provider,
+		Config:    o.notifyConfig,
+		Directory: o.userDirectory,
+	}, team, userTemplate, repoTemplate)
+	return
+}
+
+func (o *pullRequestDigestOption) runE(c *cobra.Command, args []string) (err error) {
+	repos := strings.Split(o.repo, ",")
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     repos[0],
+		Username: o.username,
+		Token:    o.token,
+	})
+
+	now := time.Now()
+	var digests []pkg.RepoDigest
+	if digests, err = maker.CollectDigest(c.Context(), repos, o.staleAfter, now); err != nil {
+		return
+	}
+	if err = printOutput(c.OutOrStdout(), "table", digestRows(digests)); err != nil || o.dryRun {
+		return
+	}
+	err = o.sender.Send(c.Context(), digests, now, c.OutOrStderr())
+	return
+}
+
+func readOptionalFile(file string) (content string, err error) {
+	if file != "" {
+		var data []byte
+		if data, err = os.ReadFile(file); err == nil {
+			content = string(data)
+		}
+	}
+	return
+}
+
+type digestRows []pkg.RepoDigest
+
+func (d digestRows) Rows() (rows [][]string) {
+	rows = append(rows, []string{"REPO", "NUMBER", "TITLE", "STALE", "FAILED", "RECIPIENTS"})
+	for _, digest := range d {
+		for _, item := range digest.Items {
+			rows = append(rows, []string{item.Repo, strconv.Itoa(item.PullRequest.Number), item.PullRequest.Title,
+				strconv.FormatBool(item.Stale), strings.Join(item.FailedStatuses, ","), strings.Join(item.Recipients(), ",")})
+		}
+	}
+	return
+}
+
+type pullRequestDigestOption struct {
+	gitProviderOption
+	notifyOption
+	staleAfter       time.Duration
+	teamChannels     []string
+	userTemplateFile string
+	repoTemplateFile string
+	dryRun           bool
+
+	sender *pkg.DigestSender
+}
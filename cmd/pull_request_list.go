@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newPullRequestListCmd() (c *cobra.Command) {
+	opt := &pullRequestListOption{}
+	c = &cobra.Command{
+		Use:     "list",
+		Short:   "List the pull requests which match the filters",
+		Aliases: []string{"ls"},
+		Example: `gogit pr list --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN \
+  --state merged --base master --label bug --newer-than 168h --output json`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.State, "state", "", "open", fmt.Sprintf("The state of the pull requests, one of %v", pkg.PullRequestStates))
+	flags.StringVarP(&opt.Author, "author", "", "", "The login of the author")
+	flags.StringSliceVarP(&opt.Labels, "label", "", []string{}, "The labels which the pull requests must have")
+	flags.StringVarP(&opt.Base, "base", "", "", "The base branch")
+	flags.StringVarP(&opt.HeadSha, "head-sha", "", "", "The (prefix of) head commit SHA")
+	flags.DurationVarP(&opt.newerThan, "newer-than", "", 0, "Only the pull requests created within the duration, e.g. 24h")
+	flags.DurationVarP(&opt.olderThan, "older-than", "", 0, "Only the pull requests created before the duration, e.g. 720h")
+	opt.addOutputFlag(c)
+	return
+}
+
+func newPullRequestFindCmd() (c *cobra.Command) {
+	opt := &pullRequestFindOption{}
+	c = &cobra.Command{
+		Use:     "find",
+		Short:   "Find the pull requests which contain the commit",
+		Example: `gogit pr find --sha $(git rev-parse HEAD) --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN`,
+		PreRunE: func(c *cobra.Command, args []string) error {
+			opt.preHandle()
+			return validateOutputFormat(opt.output)
+		},
+		RunE: opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.sha, "sha", "", "", "The commit SHA")
+	_ = c.MarkFlagRequired("sha")
+	opt.addOutputFlag(c)
+	return
+}
+
+func (o *pullRequestListOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if !slices.Contains(pkg.PullRequestStates, o.State) {
+		err = fmt.Errorf("invalid state %q, should be one of %v", o.State, pkg.PullRequestStates)
+		return
+	}
+
+	now := time.Now()
+	if o.newerThan > 0 {
+		o.CreatedAfter = now.Add(-o.newerThan)
+	}
+	if o.olderThan > 0 {
+		o.CreatedBefore = now.Add(-o.olderThan)
+	}
+	err = validateOutputFormat(o.output)
+	return
+}
+
+func (o *pullRequestListOption) runE(c *cobra.Command, args []string) (err error) {
+	var prs []*scm.PullRequest
+	if prs, err = o.getMaker(c).ListPullRequests(c.Context(), o.PullRequestFilter); err == nil {
+		err = printOutput(c.OutOrStdout(), o.output, newPullRequestSummaries(prs))
+	}
+	return
+}
+
+func (o *pullRequestFindOption) runE(c *cobra.Command, args []string) (err error) {
+	var prs []*scm.PullRequest
+	if prs, err = o.getMaker(c).FindPullRequestsBySha(c.Context(), o.sha); err == nil {
+		err = printOutput(c.OutOrStdout(), o.output, newPullRequestSummaries(prs))
+	}
+	return
+}
+
+func (o *pullRequestQueryOption) addOutputFlag(c *cobra.Command) {
+	c.Flags().StringVarP(&o.output, "output", "", "table", fmt.Sprintf("The output format, one of %v", outputFormats))
+}
+
+func (o *pullRequestQueryOption) getMaker(c *cobra.Command) *pkg.StatusMaker {
+	return pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		Username: o.username,
+		Token:    o.token,
+	})
+}
+
+// pullRequestSummary is the structured output of a pull request
+type pullRequestSummary struct {
+	Number  int       `json:"number" yaml:"number"`
+	Title   string    `json:"title" yaml:"title"`
+	State   string    `json:"state" yaml:"state"`
+	Author  string    `json:"author" yaml:"author"`
+	Base    string    `json:"base" yaml:"base"`
+	Head    string    `json:"head" yaml:"head"`
+	Sha     string    `json:"sha" yaml:"sha"`
+	Labels  []string  `json:"labels" yaml:"labels"`
+	Link    string    `json:"link" yaml:"link"`
+	Created time.Time `json:"created" yaml:"created"`
+}
+
+type pullRequestSummaries []pullRequestSummary
+
+func newPullRequestSummaries(prs []*scm.PullRequest) (summaries pullRequestSummaries) {
+	summaries = make(pullRequestSummaries, 0, len(prs))
+	for _, pr := range prs {
+		state := "open"
+		if pr.Merged {
+			state = "merged"
+		} else if pr.Closed {
+			state = "closed"
+		}
+
+		head := pr.Source
+		if head == "" {
+			head = pr.Head.Ref
+		}
+		base := pr.Target
+		if base == "" {
+			base = pr.Base.Ref
+		}
+
+		labels := make([]string, 0, len(pr.Labels))
+		for _, label := range pr.Labels {
+			labels = append(labels, label.Name)
+		}
+
+		summaries = append(summaries, pullRequestSummary{
+			Number:  pr.Number,
+			Title:   pr.Title,
+			State:   state,
+			Author:  pr.Author.Login,
+			Base:    base,
+			Head:    head,
+			Sha:     pr.Sha,
+			Labels:  labels,
+			Link:    pr.Link,
+			Created: pr.Created,
+		})
+	}
+	return
+}
+
+func (s pullRequestSummaries) Rows() (rows [][]string) {
+	rows = append(rows, []string{"NUMBER", "TITLE", "STATE", "AUTHOR", "BASE", "HEAD", "LINK"})
+	for _, item := range s {
+		rows = append(rows, []string{strconv.Itoa(item.Number), item.Title, item.State,
+			item.Author, item.Base, item.Head, item.Link})
+	}
+	return
+}
+
+type pullRequestQueryOption struct {
+	gitProviderOption
+	output string
+}
+
+type pullRequestListOption struct {
+	pullRequestQueryOption
+	pkg.PullRequestFilter
+	newerThan time.Duration
+	olderThan time.Duration
+}
+
+type pullRequestFindOption struct {
+	pullRequestQueryOption
+	sha string
+}
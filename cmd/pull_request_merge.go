@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newPullRequestMergeCmd() (c *cobra.Command) {
+	opt := &pullRequestMergeOption{}
+	c = &cobra.Command{
+		Use:   "merge",
+		Short: "Merge the pull request once the required statuses are successful and it has enough approvals",
+		Example: `gogit pr merge --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN \
+  --method squash --require-status build --require-status test --min-approvals 1 --delete-branch`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.Method, "method", "", "merge", fmt.Sprintf("The merge method, one of %v", pkg.MergeMethods))
+	flags.StringSliceVarP(&opt.RequireStatus, "require-status", "", []string{}, "The labels of the status which must be successful")
+	flags.IntVarP(&opt.MinApprovals, "min-approvals", "", 0, "The minimum number of approvals, the author is not counted")
+	flags.BoolVarP(&opt.DeleteBranch, "delete-branch", "", false, "Delete the head branch after merged, the branch of a fork is kept")
+	return
+}
+
+func (o *pullRequestMergeOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if !slices.Contains(pkg.MergeMethods, o.Method) {
+		err = fmt.Errorf("invalid merge method %q, should be one of %v", o.Method, pkg.MergeMethods)
+	}
+	return
+}
+
+func (o *pullRequestMergeOption) runE(c *cobra.Command, args []string) (err error) {
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		PrNumber: o.pr,
+		Username: o.username,
+		Token:    o.token,
+	})
+	if maker == nil {
+		return
+	}
+
+	var result pkg.MergeResult
+	if result, err = maker.MergePullRequest(c.Context(), o.MergeOptions); err != nil {
+		return
+	}
+	c.Println("merged pull request", o.pr)
+	switch {
+	case result.DeletedBranch != "":
+		c.Println("deleted branch", result.DeletedBranch)
+	case result.Fork != "":
+		c.Println("skipped deleting the branch of fork", result.Fork)
+	}
+	return
+}
+
+type pullRequestMergeOption struct {
+	gitProviderOption
+	pkg.MergeOptions
+}
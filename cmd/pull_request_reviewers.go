@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newPullRequestAssignReviewersCmd() (c *cobra.Command) {
+	opt := &pullRequestAssignReviewersOption{}
+	c = &cobra.Command{
+		Use:   "assign-reviewers",
+		Short: "Request the reviews from the CODEOWNERS of the changed files",
+		Example: `gogit pr assign-reviewers --provider gitlab --username linuxsuren --repo test --pr 45 --token $GITLAB_TOKEN \
+  --max 2 --codeowners .gitlab/CODEOWNERS`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.codeOwnersFile, "codeowners", "", "",
+		"The local CODEOWNERS file, it will be loaded from the base branch if it's empty")
+	flags.IntVarP(&opt.Max, "max", "", 2, "The maximum number of the new reviewers, zero means no limit")
+	flags.BoolVarP(&opt.Assignee, "assignee", "", false, "Assign the owners instead of requesting their reviews")
+	flags.BoolVarP(&opt.DryRun, "dry-run", "", false, "Print the reviewers without requesting their reviews")
+	return
+}
+
+func (o *pullRequestAssignReviewersOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if o.codeOwnersFile != "" {
+		o.CodeOwners, err = os.ReadFile(o.codeOwnersFile)
+	}
+	return
+}
+
+func (o *pullRequestAssignReviewersOption) runE(c *cobra.Command, args []string) (err error) {
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		PrNumber: o.pr,
+		Username: o.username,
+		Token:    o.token,
+	})
+	if maker == nil {
+		return
+	}
+
+	var reviewers []string
+	if reviewers, err = maker.AssignReviewers(c.Context(), o.AssignReviewersOptions); err == nil {
+		if len(reviewers) == 0 {
+			c.Println("no reviewers found from CODEOWNERS")
+		} else {
+			c.Println("reviewers:", strings.Join(reviewers, ", "))
+		}
+	}
+	return
+}
+
+type pullRequestAssignReviewersOption struct {
+	gitProviderOption
+	pkg.AssignReviewersOptions
+	codeOwnersFile string
+}
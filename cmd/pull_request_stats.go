@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newPullRequestStatsCmd() (c *cobra.Command) {
+	opt := &pullRequestStatsOption{}
+	c = &cobra.Command{
+		Use:   "stats",
+		Short: "Print the added and removed lines of the pull request, and optionally apply the size label",
+		Example: `gogit pr stats --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN \
+  --group 'pkg/' --group 'cmd/' --ignore 'docs/' --label --comment --output json`,
+		PreRunE: func(c *cobra.Command, args []string) error {
+			opt.preHandle()
+			return validateOutputFormat(opt.output)
+		},
+		RunE: opt.runE,
+	}
+
+	opt.addFlags(c)
+	opt.addOutputFlag(c)
+	flags := c.Flags()
+	flags.StringSliceVarP(&opt.Groups, "group", "", []string{}, "The path globs which the statistics are aggregated by")
+	flags.StringSliceVarP(&opt.Ignore, "ignore", "", pkg.DefaultStatsIgnore,
+		"The path globs which are not counted in the size, such as the generated and vendor files")
+	flags.BoolVarP(&opt.label, "label", "", false, "Apply the size label, such as size/M, to the pull request")
+	flags.BoolVarP(&opt.comment, "comment", "", false,
+		"Create a sticky comment when the changed lines exceed --warn-lines, or delete it otherwise")
+	flags.IntVarP(&opt.warnLines, "warn-lines", "", 1000, "The number of the changed lines of a huge pull request")
+	flags.StringVarP(&opt.identity, "identity", "", pkg.StatsCommentMarker, "The identity for matching exiting comment")
+	return
+}
+
+func (o *pullRequestStatsOption) runE(c *cobra.Command, args []string) (err error) {
+	maker := pkg.NewMaker(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		PrNumber: o.pr,
+		Username: o.username,
+		Token:    o.token,
+	})
+	if maker == nil {
+		return
+	}
+
+	var stats *pkg.DiffStats
+	if stats, err = maker.DiffStats(c.Context(), o.StatsOptions); err != nil {
+		return
+	}
+	if err = printOutput(c.OutOrStdout(), o.output, (*diffStatsOutput)(stats)); err != nil {
+		return
+	}
+
+	if o.label {
+		if err = maker.ApplySizeLabel(c.Context(), stats.Size); err != nil {
+			return
+		}
+	}
+	if o.comment {
+		if stats.Lines() >= o.warnLines {
+			err = maker.CreateComment(c.Context(), fmt.Sprintf("This pull request changes %d lines (+%d -%d), "+
+				"which exceeds %d. Please consider splitting it into smaller ones.",
+				stats.Lines(), stats.Additions, stats.Deletions, o.warnLines), o.identity)
+		} else {
+			_, err = maker.DeleteComments(c.Context(), o.identity)
+		}
+	}
+	return
+}
+
+type diffStatsOutput pkg.DiffStats
+
+func (d *diffStatsOutput) Rows() (rows [][]string) {
+	rows = append(rows, []string{"PATH", "ADDITIONS", "DELETIONS"})
+	for _, file := range d.Files {
+		path := file.Path
+		if file.Ignored {
+			path += " (ignored)"
+		}
+		rows = append(rows, []string{path, strconv.Itoa(file.Additions), strconv.Itoa(file.Deletions)})
+	}
+	for _, group := range d.Groups {
+		rows = append(rows, []string{fmt.Sprintf("%s (%d files)", group.Pattern, group.Files),
+			strconv.Itoa(group.Additions), strconv.Itoa(group.Deletions)})
+	}
+	rows = append(rows, []string{"TOTAL " + pkg.SizeLabelPrefix + d.Size, strconv.Itoa(d.Additions), strconv.Itoa(d.Deletions)})
+	return
+}
+
+type pullRequestStatsOption struct {
+	pullRequestQueryOption
+	pkg.StatsOptions
+	label     bool
+	comment   bool
+	warnLines int
+	identity  string
+}
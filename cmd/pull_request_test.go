@@ -25,10 +25,16 @@ SOFTWARE.
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/linuxsuren/gogit/pkg"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,6 +59,36 @@ func TestPullRequestCmd(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid dingding token pair")
 	})
 
+	t.Run("unsupported status", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+
+		c.SetArgs([]string{"pr", "--status", "unknown", "--pr=1", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported status")
+	})
+
+	t.Run("invalid dingding message type", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+
+		c.SetArgs([]string{"pr", "--dingding-msg-type", "feedCard", "--pr=1", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported dingding message type")
+	})
+
+	t.Run("invalid notify config", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+
+		c.SetArgs([]string{"pr", "--notify-config", "testdata/invalid-notify-config.yaml", "--pr=1", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported notifier kind")
+	})
+
 	t.Run("invalid git kind", func(t *testing.T) {
 		c := NewRootCommand()
 		c.SetOut(io.Discard)
@@ -85,10 +121,11 @@ func TestPullRequestCmd(t *testing.T) {
 
 func TestFormatMessage(t *testing.T) {
 	tests := []struct {
-		name   string
-		msg    string
-		pr     *scm.PullRequest
-		expect string
+		name       string
+		msg        string
+		pr         *scm.PullRequest
+		transition *pkg.StatusTransition
+		expect     string
 	}{{
 		name: "normal",
 		msg:  "hello {{.Title}}",
@@ -96,6 +133,19 @@ func TestFormatMessage(t *testing.T) {
 			Title: "world",
 		},
 		expect: "hello world",
+	}, {
+		name: "with transition",
+		msg:  "{{.Title}}: {{.Transition.Label}} {{.Transition.Previous}} -> {{.Transition.Current}} {{.Transition.Target}}",
+		pr: &scm.PullRequest{
+			Title: "world",
+		},
+		transition: &pkg.StatusTransition{
+			Label:    "build",
+			Previous: scm.StateSuccess,
+			Current:  scm.StateFailure,
+			Target:   "https://ci/1",
+		},
+		expect: "world: build success -> failure https://ci/1",
 	}, {
 		name:   "invalid template syntax",
 		msg:    "hello {{.Title}",
@@ -103,8 +153,118 @@ func TestFormatMessage(t *testing.T) {
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, _ := formatMessage(tt.msg, tt.pr)
+			result, _ := formatMessage(tt.msg, tt.pr, tt.transition)
 			assert.Equal(t, tt.expect, result)
 		})
 	}
 }
+
+func TestRenderPullRequestBody(t *testing.T) {
+	body, err := renderPullRequestBody("merge {{.Head}} into {{.Base}}", pkg.PullRequestInput{Head: "feature", Base: "master"})
+	assert.NoError(t, err)
+	assert.Equal(t, "merge feature into master", body)
+
+	_, err = renderPullRequestBody("{{.Head}", pkg.PullRequestInput{})
+	assert.Error(t, err)
+}
+
+func TestPullRequestCreateRenderBody(t *testing.T) {
+	client, data := fake.NewDefault()
+	data.Repositories = []*scm.Repository{{FullName: "owner/repo", Branch: "main"}}
+	maker := pkg.NewStatusMaker("owner/repo", "").WithClient(client)
+
+	tplFile := filepath.Join(t.TempDir(), "body.tpl")
+	assert.NoError(t, os.WriteFile(tplFile, []byte("merge {{.Head}} into {{.Base}}"), 0644))
+
+	opt := &pullRequestCreateOption{bodyTemplate: tplFile}
+	opt.Head = "feature"
+	assert.NoError(t, opt.renderBody(context.Background(), maker))
+	assert.Equal(t, "main", opt.Base)
+	assert.Equal(t, "merge feature into main", opt.Body)
+
+	// the base branch is given
+	opt.Base = "release"
+	assert.NoError(t, opt.renderBody(context.Background(), maker))
+	assert.Equal(t, "merge feature into release", opt.Body)
+}
+
+func TestPullRequestCreateCmd(t *testing.T) {
+	c := NewRootCommand()
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+
+	c.SetArgs([]string{"pr", "create", "--title=title", "--head=feature", "--body-file=not-exist",
+		"--repo=xxx/xxx", "--token=token", "--username=xxx"})
+	err := c.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no such file")
+}
+
+func TestPullRequestMergeCmd(t *testing.T) {
+	c := NewRootCommand()
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+
+	c.SetArgs([]string{"pr", "merge", "--method=fast-forward", "--pr=1",
+		"--repo=xxx/xxx", "--token=token", "--username=xxx"})
+	err := c.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid merge method")
+}
+
+func TestPullRequestAssignReviewersCmd(t *testing.T) {
+	c := NewRootCommand()
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+
+	c.SetArgs([]string{"pr", "assign-reviewers", "--codeowners=not-found", "--pr=1",
+		"--repo=xxx/xxx", "--token=token", "--username=xxx"})
+	err := c.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no such file")
+}
+
+func TestPullRequestDigestCmd(t *testing.T) {
+	c := NewRootCommand()
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+
+	c.SetArgs([]string{"pr", "digest", "--team-channel=team", "--repo=gogit", "--token=token", "--username=xxx"})
+	err := c.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `the team channel "team" is not found`)
+
+	c.SetArgs([]string{"pr", "digest", "--user-template=not-found", "--repo=gogit", "--token=token", "--username=xxx"})
+	err = c.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no such file")
+}
+
+func TestPullRequestStatsCmd(t *testing.T) {
+	c := NewRootCommand()
+	c.SetOut(io.Discard)
+	c.SetErr(io.Discard)
+
+	c.SetArgs([]string{"pr", "stats", "--output=xml", "--pr=1", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+	err := c.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid output format")
+}
+
+func TestDiffStatsOutput(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := printOutput(buf, "table", &diffStatsOutput{
+		Additions: 3,
+		Deletions: 1,
+		Size:      "XS",
+		Groups:    []pkg.GroupStats{{Pattern: "pkg/", Files: 1, Additions: 3, Deletions: 1}},
+		Files:     []pkg.FileStats{{Path: "pkg/git.go", Additions: 3, Deletions: 1}, {Path: "go.sum", Additions: 9, Ignored: true}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `PATH              ADDITIONS  DELETIONS
+pkg/git.go        3          1
+go.sum (ignored)  9          0
+pkg/ (1 files)    3          1
+TOTAL size/XS     3          1
+`, buf.String())
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newReleaseCmd() (c *cobra.Command) {
+	c = &cobra.Command{
+		Use:   "release",
+		Short: "Manage the releases of GitHub, GitLab and Gitea",
+	}
+
+	c.AddCommand(newReleaseCreateCmd(), newReleaseUploadCmd(),
+		newReleaseListCmd(), newReleaseDeleteCmd())
+	return
+}
+
+func newReleaseCreateCmd() (c *cobra.Command) {
+	opt := &releaseCreateOption{}
+	c = &cobra.Command{
+		Use:   "create",
+		Short: "Create the release of the tag, or update it if the tag already has a release",
+		Example: `gogit release create v1.0.0 --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN \
+  --notes-file CHANGELOG.md --asset bin/gogit-linux-amd64.tar.gz`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.Title, "title", "", "",
+		"The title of the release. It's the tag for a new release, or the existing title if it's empty")
+	flags.StringVarP(&opt.Notes, "notes", "", "", "The release notes, the existing notes are kept if it's empty")
+	flags.StringVarP(&opt.notesFile, "notes-file", "", "", "The file of the release notes, it takes precedence over --notes")
+	flags.StringVarP(&opt.Commitish, "target", "", "", "The branch or commit which the tag is created from if the tag does not exist")
+	flags.BoolVarP(&opt.draft, "draft", "", false,
+		"Mark the release as a draft, it's not supported by GitLab. The existing release is kept as it is if it's not set")
+	flags.BoolVarP(&opt.prerelease, "prerelease", "", false,
+		"Mark the release as a prerelease, it's not supported by GitLab. The existing release is kept as it is if it's not set")
+	flags.StringSliceVarP(&opt.assets, "asset", "", []string{}, "The files to upload to the release")
+	flags.StringVarP(&opt.checksumFile, "checksum", "", pkg.DefaultChecksumFile,
+		"The name of the SHA256 checksum asset of the uploaded files, no checksum asset if it's empty")
+	return
+}
+
+func (o *releaseCreateOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	o.Tag = args[0]
+	if c.Flags().Changed("draft") {
+		o.Draft = &o.draft
+	}
+	if c.Flags().Changed("prerelease") {
+		o.Prerelease = &o.prerelease
+	}
+	if o.notesFile != "" {
+		o.Notes, err = readOptionalFile(o.notesFile)
+	}
+	return
+}
+
+func (o *releaseCreateOption) runE(c *cobra.Command, args []string) (err error) {
+	maker := o.getMaker(c)
+
+	var release *scm.Release
+	var created bool
+	if release, created, err = maker.CreateRelease(c.Context(), o.ReleaseOptions); err != nil {
+		return
+	}
+	if created {
+		c.Println("release created:", release.Link)
+	} else {
+		c.Println("release updated:", release.Link)
+	}
+
+	if len(o.assets) > 0 {
+		var assets []pkg.ReleaseAsset
+		if assets, err = maker.UploadReleaseAssets(c.Context(), o.Tag, o.assets, o.checksumFile); err == nil {
+			err = printOutput(c.OutOrStdout(), "table", releaseAssets(assets))
+		}
+	}
+	return
+}
+
+func newReleaseUploadCmd() (c *cobra.Command) {
+	opt := &releaseUploadOption{}
+	c = &cobra.Command{
+		Use:   "upload",
+		Short: "Upload the files to the release of the tag, the assets with the same names are replaced",
+		Example: `gogit release upload v1.0.0 bin/gogit-linux-amd64.tar.gz bin/gogit-darwin-amd64.tar.gz \
+  --provider gitlab --username linuxsuren --repo test --token $GITLAB_TOKEN`,
+		Args: cobra.MinimumNArgs(2),
+		PreRunE: func(c *cobra.Command, args []string) error {
+			opt.preHandle()
+			return validateOutputFormat(opt.output)
+		},
+		RunE: opt.runE,
+	}
+
+	opt.addRepoFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.checksumFile, "checksum", "", pkg.DefaultChecksumFile,
+		"The name of the SHA256 checksum asset of the uploaded files, no checksum asset if it's empty")
+	opt.addOutputFlag(c)
+	return
+}
+
+func (o *releaseUploadOption) runE(c *cobra.Command, args []string) (err error) {
+	var assets []pkg.ReleaseAsset
+	if assets, err = o.getMaker(c).UploadReleaseAssets(c.Context(), args[0], args[1:], o.checksumFile); err == nil {
+		err = printOutput(c.OutOrStdout(), o.output, releaseAssets(assets))
+	}
+	return
+}
+
+func newReleaseListCmd() (c *cobra.Command) {
+	opt := &pullRequestQueryOption{}
+	c = &cobra.Command{
+		Use:     "list",
+		Short:   "List the releases of the repository",
+		Aliases: []string{"ls"},
+		Example: `gogit release list --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN --output json`,
+		PreRunE: func(c *cobra.Command, args []string) error {
+			opt.preHandle()
+			return validateOutputFormat(opt.output)
+		},
+		RunE: func(c *cobra.Command, args []string) (err error) {
+			var releases []*scm.Release
+			if releases, err = opt.getMaker(c).ListReleases(c.Context()); err == nil {
+				err = printOutput(c.OutOrStdout(), opt.output, newReleaseSummaries(releases))
+			}
+			return
+		},
+	}
+
+	opt.addRepoFlags(c)
+	opt.addOutputFlag(c)
+	return
+}
+
+func newReleaseDeleteCmd() (c *cobra.Command) {
+	opt := &pullRequestQueryOption{}
+	c = &cobra.Command{
+		Use:     "delete",
+		Short:   "Delete the release of the tag, the tag itself is kept",
+		Example: `gogit release delete v1.0.0 --provider github --username linuxsuren --repo test --token $GITHUB_TOKEN`,
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		PreRunE: func(c *cobra.Command, args []string) error {
+			opt.preHandle()
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) (err error) {
+			if err = opt.getMaker(c).DeleteRelease(c.Context(), args[0]); err == nil {
+				c.Println("release deleted:", args[0])
+			}
+			return
+		},
+	}
+
+	opt.addRepoFlags(c)
+	return
+}
+
+type releaseAssets []pkg.ReleaseAsset
+
+func (a releaseAssets) Rows() (rows [][]string) {
+	rows = append(rows, []string{"NAME", "SIZE", "SHA256", "URL"})
+	for _, asset := range a {
+		rows = append(rows, []string{asset.Name, strconv.Itoa(asset.Size), asset.Sha256, asset.URL})
+	}
+	return
+}
+
+// releaseSummary is the structured output of a release
+type releaseSummary struct {
+	Tag        string `json:"tag" yaml:"tag"`
+	Title      string `json:"title" yaml:"title"`
+	Draft      bool   `json:"draft" yaml:"draft"`
+	Prerelease bool   `json:"prerelease" yaml:"prerelease"`
+	Link       string `json:"link" yaml:"link"`
+}
+
+type releaseSummaries []releaseSummary
+
+func newReleaseSummaries(releases []*scm.Release) (summaries releaseSummaries) {
+	summaries = make(releaseSummaries, 0, len(releases))
+	for _, release := range releases {
+		summaries = append(summaries, releaseSummary{
+			Tag:        release.Tag,
+			Title:      release.Title,
+			Draft:      release.Draft,
+			Prerelease: release.Prerelease,
+			Link:       release.Link,
+		})
+	}
+	return
+}
+
+func (s releaseSummaries) Rows() (rows [][]string) {
+	rows = append(rows, []string{"TAG", "TITLE", "DRAFT", "PRERELEASE", "LINK"})
+	for _, item := range s {
+		rows = append(rows, []string{item.Tag, item.Title, strconv.FormatBool(item.Draft),
+			strconv.FormatBool(item.Prerelease), item.Link})
+	}
+	return
+}
+
+type releaseCreateOption struct {
+	pullRequestQueryOption
+	pkg.ReleaseOptions
+	notesFile    string
+	draft        bool
+	prerelease   bool
+	assets       []string
+	checksumFile string
+}
+
+type releaseUploadOption struct {
+	pullRequestQueryOption
+	checksumFile string
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseCmd(t *testing.T) {
+	t.Run("sub-commands", func(t *testing.T) {
+		c := newReleaseCmd()
+		assert.Len(t, c.Commands(), 4)
+	})
+
+	t.Run("notes file not found", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"release", "create", "v1.0.0", "--notes-file", "fake.md",
+			"--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		err := c.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "fake.md")
+	})
+
+	t.Run("upload without files", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+
+		c.SetArgs([]string{"release", "upload", "v1.0.0", "--repo=xxx/xxx", "--token=token", "--username=xxx"})
+		assert.Error(t, c.Execute())
+	})
+}
+
+func TestReleaseSummaries(t *testing.T) {
+	summaries := newReleaseSummaries([]*scm.Release{{Tag: "v1.0.0", Title: "first", Prerelease: true, Link: "https://foo.com"}})
+	assert.Equal(t, [][]string{
+		{"TAG", "TITLE", "DRAFT", "PRERELEASE", "LINK"},
+		{"v1.0.0", "first", "false", "true", "https://foo.com"},
+	}, summaries.Rows())
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newReviewCommand() (c *cobra.Command) {
+	opt := &reviewOption{}
+	c = &cobra.Command{
+		Use:   "review",
+		Short: "Create a review with inline comments from the lint reports against the pull request",
+		Long: `Create a review with inline comments from the lint reports against the pull request.
+Only the findings on the changed lines will be commented. Supported report formats: sarif, checkstyle, golangci.
+It's only supported by GitHub, the threads of the stale comments are resolved when reviewing again.`,
+		Example: `gogit review --provider github --username linuxsuren --repo test --pr 45 --token $GITHUB_TOKEN --report golangci.json`,
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+
+	opt.addFlags(c)
+	flags := c.Flags()
+	flags.StringSliceVarP(&opt.reports, "report", "", []string{}, "The lint report files")
+	flags.StringVarP(&opt.format, "format", "", "", "The format of the reports, it will be detected from the content if it's empty")
+	flags.StringVarP(&opt.stripPrefix, "strip-prefix", "", "", "Strip the prefix of the file paths in the reports, such as the workspace directory")
+	flags.StringVarP(&opt.identity, "identity", "", pkg.CommentEndMarker, "The identity for matching exiting reviews")
+	_ = c.MarkFlagRequired("report")
+	return
+}
+
+func (o *reviewOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	if o.stripPrefix != "" && !strings.HasSuffix(o.stripPrefix, "/") {
+		o.stripPrefix += "/"
+	}
+	return
+}
+
+func (o *reviewOption) runE(c *cobra.Command, args []string) (err error) {
+	var findings []pkg.Finding
+	if findings, err = o.loadFindings(); err != nil {
+		return
+	}
+
+	err = pkg.CreateReview(c.Context(), pkg.RepoInformation{
+		Provider: o.provider,
+		Server:   o.server,
+		Owner:    o.owner,
+		Repo:     o.repo,
+		PrNumber: o.pr,
+		Username: o.username,
+		Token:    o.token,
+	}, findings, o.identity)
+	return
+}
+
+func (o *reviewOption) loadFindings() (findings []pkg.Finding, err error) {
+	for _, report := range o.reports {
+		var data []byte
+		if data, err = os.ReadFile(report); err != nil {
+			return
+		}
+
+		var items []pkg.Finding
+		if items, err = pkg.ParseReport(data, o.format); err != nil {
+			err = pkg.WrapError(err, "failed to parse report %q: %v", report)
+			return
+		}
+
+		for _, item := range items {
+			item.Path = strings.TrimPrefix(item.Path, o.stripPrefix)
+			findings = append(findings, item)
+		}
+	}
+	return
+}
+
+type reviewOption struct {
+	gitProviderOption
+	reports     []string
+	format      string
+	stripPrefix string
+	identity    string
+}
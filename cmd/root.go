@@ -11,6 +11,9 @@ func NewRootCommand() (c *cobra.Command) {
 
 	c.AddCommand(newCheckoutCommand(),
 		newStatusCmd(), newCommentCommand(),
-		newPullRequestCmd(), newCommitCmd())
+		newPullRequestCmd(), newCommitCmd(),
+		newReviewCommand(), newLabelCommand(),
+		newChangelogCmd(), newReleaseCmd(), newLintCmd(),
+		newVersionCmd(), newTagCmd())
 	return
 }
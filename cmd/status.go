@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"os"
 	"strings"
 
@@ -107,18 +108,24 @@ type gitProviderOption struct {
 }
 
 func (o *gitProviderOption) addFlags(c *cobra.Command) {
+	o.addRepoFlags(c)
+	flags := c.Flags()
+	flags.IntVarP(&o.pr, "pr", "", 1, "The pull request number")
+	_ = c.MarkFlagRequired("pr")
+}
+
+// addRepoFlags adds the flags of the git repository without the pull request number
+func (o *gitProviderOption) addRepoFlags(c *cobra.Command) {
 	flags := c.Flags()
 	flags.StringVarP(&o.provider, "provider", "p", "github", "The provider of git, such as: gitlab, github")
 	flags.StringVarP(&o.server, "server", "s", "", "The server address of target git provider, only need when it's a private provider")
 	flags.StringVarP(&o.owner, "owner", "o", "", "Owner of a git repository")
 	flags.StringVarP(&o.repo, "repo", "r", "", "Name of target git repository")
-	flags.IntVarP(&o.pr, "pr", "", 1, "The pull request number")
 	flags.StringVarP(&o.username, "username", "u", "", "Username of the git repository")
 	flags.StringVarP(&o.token, "token", "t", "",
 		"The access token of the git repository. Or you could provide a file path, such as: file:///var/token")
 
 	_ = c.MarkFlagRequired("repo")
-	_ = c.MarkFlagRequired("pr")
 	_ = c.MarkFlagRequired("username")
 	_ = c.MarkFlagRequired("token")
 }
@@ -129,6 +136,55 @@ func (o *gitProviderOption) preHandle() {
 	}
 }
 
+// targetOption is the target of the comments or labels, it's a pull request, an issue or a commit
+type targetOption struct {
+	gitProviderOption
+	issue int
+	sha   string
+}
+
+// addTargetFlags adds the flags for choosing the target, the commit is a target only if withSha is true
+func (o *targetOption) addTargetFlags(c *cobra.Command, withSha bool) {
+	o.addRepoFlags(c)
+	flags := c.Flags()
+	flags.IntVarP(&o.pr, "pr", "", 0, "The pull request number")
+	flags.IntVarP(&o.issue, "issue", "", 0, "The issue number")
+	if withSha {
+		flags.StringVarP(&o.sha, "sha", "", "", "The commit sha, only supported by GitHub and GitLab")
+		c.MarkFlagsMutuallyExclusive("pr", "issue", "sha")
+	} else {
+		c.MarkFlagsMutuallyExclusive("pr", "issue")
+	}
+}
+
+// preRunE makes sure one of the target flags is set
+func (o *targetOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.preHandle()
+	flags := c.Flags()
+	switch {
+	case flags.Changed("pr") || flags.Changed("issue") || flags.Changed("sha"):
+	case flags.Lookup("sha") != nil:
+		err = errors.New("one of the flags --pr, --issue or --sha is required")
+	default:
+		err = errors.New("one of the flags --pr or --issue is required")
+	}
+	return
+}
+
+func (o *targetOption) getRepoInformation() pkg.RepoInformation {
+	return pkg.RepoInformation{
+		Provider:    o.provider,
+		Server:      o.server,
+		Owner:       o.owner,
+		Repo:        o.repo,
+		PrNumber:    o.pr,
+		IssueNumber: o.issue,
+		Sha:         o.sha,
+		Username:    o.username,
+		Token:       o.token,
+	}
+}
+
 func (o *gitProviderOption) getClient() (scmClient *scm.Client, err error) {
 	scmClient, err = factory.NewClient(o.provider, o.server, o.token, func(c *scm.Client) {
 		c.Username = o.username
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newTagCmd() (c *cobra.Command) {
+	c = &cobra.Command{
+		Use:   "tag",
+		Short: "Git tag related commands",
+	}
+
+	c.AddCommand(newTagCreateCmd())
+	return
+}
+
+func newTagCreateCmd() (c *cobra.Command) {
+	opt := &tagCreateOption{}
+	c = &cobra.Command{
+		Use:   "create",
+		Short: "Create an annotated tag on HEAD, the next semantic version is used if the name is not given",
+		Example: `gogit tag create v1.3.0 --push
+gogit tag create --prerelease rc --push --username linuxsuren --password $GITHUB_TOKEN`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: opt.runE,
+	}
+
+	opt.addFlags(c)
+	opt.addAuthFlags(c)
+	flags := c.Flags()
+	flags.StringVarP(&opt.message, "message", "m", "", "The message of the tag, it's the tag name if it's empty")
+	flags.StringVarP(&opt.taggerName, "tagger-name", "", "", "The name of the tagger, it's read from the git config if it's empty")
+	flags.StringVarP(&opt.taggerEmail, "tagger-email", "", "", "The email of the tagger")
+	flags.BoolVarP(&opt.push, "push", "", false, "Push the tag to the remote")
+	flags.StringVarP(&opt.remote, "remote", "", "origin", "The remote name")
+	return
+}
+
+func (o *tagCreateOption) runE(c *cobra.Command, args []string) (err error) {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else {
+		var latest string
+		var next pkg.Version
+		if latest, next, err = pkg.NextVersion(o.workDir, o.NextVersionOptions); err != nil {
+			return
+		}
+		if name = next.String(); name == latest {
+			err = fmt.Errorf("there is no commit since the latest tag %q", latest)
+			return
+		}
+	}
+
+	var repo *git.Repository
+	if repo, err = git.PlainOpenWithOptions(o.workDir, &git.PlainOpenOptions{DetectDotGit: true}); err != nil {
+		err = fmt.Errorf("cannot open git repository %q: %v", o.workDir, err)
+		return
+	}
+
+	var head *plumbing.Reference
+	if head, err = repo.Head(); err != nil {
+		return
+	}
+
+	tagOpt := &git.CreateTagOptions{Message: o.message}
+	if tagOpt.Message == "" {
+		tagOpt.Message = name
+	}
+	if o.taggerName != "" {
+		tagOpt.Tagger = &object.Signature{Name: o.taggerName, Email: o.taggerEmail, When: time.Now()}
+	}
+	if _, err = repo.CreateTag(name, head.Hash(), tagOpt); err != nil {
+		err = fmt.Errorf("failed to create tag %q: %v", name, err)
+		return
+	}
+	c.Printf("Created tag '%s' on %s\n", name, head.Hash().String()[:7])
+
+	if o.push {
+		var remote *git.Remote
+		if remote, err = repo.Remote(o.remote); err != nil {
+			return
+		}
+
+		var gitAuth transport.AuthMethod
+		if gitAuth, err = o.getAuth(remote.Config().URLs[0]); err != nil {
+			return
+		}
+
+		refSpec := config.RefSpec(fmt.Sprintf("refs/tags/%s:refs/tags/%s", name, name))
+		if err = repo.PushContext(c.Context(), &git.PushOptions{
+			RemoteName: o.remote,
+			Auth:       gitAuth,
+			RefSpecs:   []config.RefSpec{refSpec},
+			Progress:   c.OutOrStdout(),
+		}); err != nil {
+			err = fmt.Errorf("failed to push tag %q to %q: %v", name, o.remote, err)
+			return
+		}
+		c.Printf("Pushed tag '%s' to '%s'\n", name, o.remote)
+	}
+	return
+}
+
+type tagCreateOption struct {
+	versionNextOption
+	gitAuthOption
+	message     string
+	taggerName  string
+	taggerEmail string
+	push        bool
+	remote      string
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagCreateCmd(t *testing.T) {
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	assert.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	_, err = worktree.Commit("feat: init", &git.CommitOptions{
+		Author: &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	t.Run("next version", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		c := NewRootCommand()
+		c.SetOut(buf)
+		c.SetErr(io.Discard)
+		c.SetArgs([]string{"version", "next", "--work-dir", dir, "--prerelease", "rc"})
+		assert.NoError(t, c.Execute())
+		assert.Equal(t, "v0.1.0-rc.1\n", buf.String())
+	})
+
+	t.Run("create and push the next version", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs([]string{"tag", "create", "--work-dir", dir, "--tagger-name", "Rick", "--push"})
+		assert.NoError(t, c.Execute())
+
+		remote, err := git.PlainOpen(remoteDir)
+		assert.NoError(t, err)
+		ref, err := remote.Reference(plumbing.NewTagReferenceName("v0.1.0"), false)
+		assert.NoError(t, err)
+		tag, err := remote.TagObject(ref.Hash())
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.1.0\n", tag.Message)
+	})
+
+	t.Run("tag exists", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs([]string{"tag", "create", "v0.1.0", "--work-dir", dir, "--tagger-name", "Rick"})
+		assert.Error(t, c.Execute())
+	})
+
+	t.Run("HEAD is already tagged", func(t *testing.T) {
+		c := NewRootCommand()
+		c.SetOut(io.Discard)
+		c.SetErr(io.Discard)
+		c.SetArgs([]string{"tag", "create", "--work-dir", dir, "--tagger-name", "Rick"})
+		assert.ErrorContains(t, c.Execute(), `there is no commit since the latest tag "v0.1.0"`)
+
+		tags, err := repo.Tags()
+		assert.NoError(t, err)
+		count := 0
+		assert.NoError(t, tags.ForEach(func(*plumbing.Reference) error {
+			count++
+			return nil
+		}))
+		assert.Equal(t, 1, count)
+	})
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/linuxsuren/gogit/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newVersionCmd() (c *cobra.Command) {
+	c = &cobra.Command{
+		Use:   "version",
+		Short: "Semantic version related commands",
+	}
+
+	c.AddCommand(newVersionNextCmd())
+	return
+}
+
+func newVersionNextCmd() (c *cobra.Command) {
+	opt := &versionNextOption{}
+	c = &cobra.Command{
+		Use:     "next",
+		Short:   "Compute the next semantic version from the latest tag and the conventional commits since then",
+		Example: `gogit version next --prerelease rc --build $(git rev-parse --short HEAD)`,
+		RunE: func(c *cobra.Command, args []string) (err error) {
+			var next pkg.Version
+			if _, next, err = pkg.NextVersion(opt.workDir, opt.NextVersionOptions); err == nil {
+				_, err = fmt.Fprintln(c.OutOrStdout(), next.String())
+			}
+			return
+		},
+	}
+
+	opt.addFlags(c)
+	return
+}
+
+func (o *versionNextOption) addFlags(c *cobra.Command) {
+	flags := c.Flags()
+	flags.StringVarP(&o.workDir, "work-dir", "", ".", "The local git repository directory")
+	flags.StringVarP(&o.Prerelease, "prerelease", "", "",
+		"The prerelease identifier, such as rc. The number is increased for the same release version, such as rc.1 to rc.2")
+	flags.StringVarP(&o.Build, "build", "", "", "The build metadata, such as the commit SHA")
+	flags.StringVarP(&o.Prefix, "prefix", "", "v", "The prefix of the version if there is no tag yet")
+}
+
+type versionNextOption struct {
+	pkg.NextVersionOptions
+	workDir string
+}
@@ -0,0 +1,377 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// Changelog grouping modes
+const (
+	ChangelogGroupByType  = "type"
+	ChangelogGroupByLabel = "label"
+)
+
+// changelogTypes are the sections of the conventional commit types in order
+var changelogTypes = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"refactor", "Code Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"chore", "Chores"},
+}
+
+const (
+	changelogBreakingSection = "Breaking Changes"
+	changelogOthersSection   = "Others"
+)
+
+// Changelog is the changes between two revisions
+type Changelog struct {
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	Sections     []ChangelogSection `json:"sections"`
+	Contributors []string           `json:"contributors"`
+}
+
+// ChangelogSection is a group of the changes
+type ChangelogSection struct {
+	Title   string           `json:"title"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// ChangelogEntry is a merged pull request, or a commit without pull request
+type ChangelogEntry struct {
+	// Number is zero if there is no pull request of the commit
+	Number int    `json:"number,omitempty"`
+	Title  string `json:"title"`
+	Link   string `json:"link,omitempty"`
+	Sha    string `json:"sha"`
+	// Author is the login of the pull request author, or the name of the commit author
+	Author string   `json:"author"`
+	Labels []string `json:"labels,omitempty"`
+	ConventionalCommit
+}
+
+// ShortSha returns the abbreviated commit SHA
+func (e ChangelogEntry) ShortSha() string {
+	return shortSha(e.Sha)
+}
+
+// ChangelogOptions is the options for generating the changelog
+type ChangelogOptions struct {
+	From string
+	To   string
+	// GroupBy is one of type and label
+	GroupBy string
+	// Labels are the sections in order when grouping by label, all the labels are used if it's empty
+	Labels []string
+}
+
+// ListCommits returns the commits which are reachable from the to revision but not from the from revision,
+// the newest ones come first. All the ancestors of the to revision are returned if the from revision is empty
+func ListCommits(dir, from, to string) (commits []*object.Commit, err error) {
+	var repo *git.Repository
+	if repo, err = git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true}); err != nil {
+		err = fmt.Errorf("cannot open git repository %q: %v", dir, err)
+		return
+	}
+
+	excluded := make(map[plumbing.Hash]struct{})
+	if from != "" {
+		var fromCommit *object.Commit
+		if fromCommit, err = resolveCommit(repo, from); err != nil {
+			return
+		}
+		if err = walkCommits(repo, fromCommit.Hash, func(commit *object.Commit) {
+			excluded[commit.Hash] = struct{}{}
+		}); err != nil {
+			return
+		}
+	}
+
+	var toCommit *object.Commit
+	if toCommit, err = resolveCommit(repo, to); err != nil {
+		return
+	}
+	err = walkCommits(repo, toCommit.Hash, func(commit *object.Commit) {
+		if _, ok := excluded[commit.Hash]; !ok {
+			commits = append(commits, commit)
+		}
+	})
+	return
+}
+
+// resolveCommit resolves the revision to a commit, the annotated tags are peeled
+func resolveCommit(repo *git.Repository, revision string) (commit *object.Commit, err error) {
+	var hash *plumbing.Hash
+	if hash, err = repo.ResolveRevision(plumbing.Revision(revision)); err != nil {
+		err = fmt.Errorf("cannot resolve revision %q: %v", revision, err)
+		return
+	}
+
+	if commit, err = repo.CommitObject(*hash); err != nil {
+		var tag *object.Tag
+		if tag, err = repo.TagObject(*hash); err == nil {
+			commit, err = tag.Commit()
+		}
+	}
+	return
+}
+
+func walkCommits(repo *git.Repository, from plumbing.Hash, callback func(*object.Commit)) error {
+	return walkCommitsUntil(repo, from, func(commit *object.Commit) bool {
+		callback(commit)
+		return false
+	})
+}
+
+// GenerateChangelog maps the commits to the merged pull requests, and groups them by the conventional commit type
+// or the label. The commits without pull request are kept
+func (s *StatusMaker) GenerateChangelog(ctx context.Context, commits []*object.Commit, opt ChangelogOptions) (changelog *Changelog, err error) {
+	var index map[string]*scm.PullRequest
+	if index, err = s.indexClosedPullRequests(ctx); err != nil {
+		return
+	}
+	owners := pullRequestsOfCommits(commits, index)
+
+	var entries []ChangelogEntry
+	found := make(map[int]struct{})
+	for _, commit := range commits {
+		merged := owners[commit.Hash]
+
+		var entry ChangelogEntry
+		if merged == nil {
+			entry = ChangelogEntry{
+				Title:  strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0]),
+				Sha:    commit.Hash.String(),
+				Author: commit.Author.Name,
+			}
+			entry.ConventionalCommit, _ = ParseConventionalCommit(commit.Message)
+		} else {
+			if _, ok := found[merged.Number]; ok {
+				continue
+			}
+			found[merged.Number] = struct{}{}
+
+			entry = ChangelogEntry{
+				Number: merged.Number,
+				Title:  merged.Title,
+				Link:   merged.Link,
+				Sha:    commit.Hash.String(),
+				Author: merged.Author.Login,
+			}
+			for _, label := range merged.Labels {
+				entry.Labels = append(entry.Labels, label.Name)
+			}
+			entry.ConventionalCommit, _ = ParseConventionalCommit(merged.Title)
+		}
+		if entry.Description == "" {
+			entry.Description = entry.Title
+		}
+		entries = append(entries, entry)
+	}
+
+	changelog = &Changelog{From: opt.From, To: opt.To}
+	if opt.GroupBy == ChangelogGroupByLabel {
+		changelog.Sections = groupByLabel(entries, opt.Labels)
+	} else {
+		changelog.Sections = groupByType(entries)
+	}
+
+	contributors := make(map[string]struct{})
+	for _, entry := range entries {
+		if _, ok := contributors[entry.Author]; !ok && entry.Author != "" {
+			contributors[entry.Author] = struct{}{}
+			changelog.Contributors = append(changelog.Contributors, entry.Author)
+		}
+	}
+	sort.Strings(changelog.Contributors)
+	return
+}
+
+// indexClosedPullRequests lists the closed pull requests once, and indexes them by the merge commit and the head commit
+func (s *StatusMaker) indexClosedPullRequests(ctx context.Context) (index map[string]*scm.PullRequest, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	// the merged ones are listed in the state all of GitLab, instead of closed
+	var prs []*scm.PullRequest
+	if prs, err = listAllPullRequests(ctx, scmClient, s.repo, &scm.PullRequestListOptions{
+		Closed: true,
+		Open:   s.provider == "gitlab",
+	}); err != nil {
+		return
+	}
+
+	index = make(map[string]*scm.PullRequest)
+	for _, pr := range prs {
+		if !pr.Closed && !pr.Merged {
+			continue
+		}
+		for _, sha := range []string{pr.MergeSha, pr.Sha} {
+			// the merged one wins if a commit is in several pull requests
+			if existing, ok := index[sha]; sha != "" && (!ok || (pr.Merged && !existing.Merged)) {
+				index[sha] = pr
+			}
+		}
+	}
+	return
+}
+
+// pullRequestsOfCommits maps the commits to the pull requests by the index. The commits which are merged by the
+// merge commit of a pull request belong to it as well, unless they are in the first parent history
+func pullRequestsOfCommits(commits []*object.Commit, index map[string]*scm.PullRequest) (owners map[plumbing.Hash]*scm.PullRequest) {
+	inRange := make(map[plumbing.Hash]*object.Commit, len(commits))
+	owners = make(map[plumbing.Hash]*scm.PullRequest)
+	for _, commit := range commits {
+		inRange[commit.Hash] = commit
+		if pr, ok := index[commit.Hash.String()]; ok {
+			owners[commit.Hash] = pr
+		}
+	}
+
+	for _, commit := range commits {
+		pr, ok := owners[commit.Hash]
+		if !ok || commit.NumParents() < 2 {
+			continue
+		}
+
+		mainline := ancestorsInRange(inRange, commit.ParentHashes[0])
+		for hash := range ancestorsInRange(inRange, commit.ParentHashes[1]) {
+			if _, ok = mainline[hash]; !ok && owners[hash] == nil {
+				owners[hash] = pr
+			}
+		}
+	}
+	return
+}
+
+// ancestorsInRange returns the commit and its ancestors, the commits out of the range are skipped
+func ancestorsInRange(inRange map[plumbing.Hash]*object.Commit, from plumbing.Hash) (ancestors map[plumbing.Hash]struct{}) {
+	ancestors = make(map[plumbing.Hash]struct{})
+	stack := []plumbing.Hash{from}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		commit, ok := inRange[hash]
+		if _, seen := ancestors[hash]; seen || !ok {
+			continue
+		}
+		ancestors[hash] = struct{}{}
+		stack = append(stack, commit.ParentHashes...)
+	}
+	return
+}
+
+func groupByType(entries []ChangelogEntry) []ChangelogSection {
+	titles := []string{changelogBreakingSection}
+	types := make(map[string]string, len(changelogTypes))
+	for _, item := range changelogTypes {
+		titles = append(titles, item.Title)
+		types[item.Type] = item.Title
+	}
+	titles = append(titles, changelogOthersSection)
+
+	return newSections(titles, entries, func(entry ChangelogEntry) string {
+		if entry.Breaking {
+			return changelogBreakingSection
+		}
+		if title, ok := types[entry.Type]; ok {
+			return title
+		}
+		return changelogOthersSection
+	})
+}
+
+func groupByLabel(entries []ChangelogEntry, labels []string) []ChangelogSection {
+	titles := append([]string{}, labels...)
+	if len(titles) == 0 {
+		known := make(map[string]struct{})
+		for _, entry := range entries {
+			for _, label := range entry.Labels {
+				if _, ok := known[label]; !ok {
+					known[label] = struct{}{}
+					titles = append(titles, label)
+				}
+			}
+		}
+	}
+	titles = append(titles, changelogOthersSection)
+
+	return newSections(titles, entries, func(entry ChangelogEntry) string {
+		for _, title := range titles {
+			for _, label := range entry.Labels {
+				if label == title {
+					return title
+				}
+			}
+		}
+		return changelogOthersSection
+	})
+}
+
+// newSections groups the entries by the titles in order, the empty sections are dropped
+func newSections(titles []string, entries []ChangelogEntry, sectionOf func(ChangelogEntry) string) (sections []ChangelogSection) {
+	grouped := make(map[string][]ChangelogEntry, len(titles))
+	for _, entry := range entries {
+		title := sectionOf(entry)
+		grouped[title] = append(grouped[title], entry)
+	}
+
+	for _, title := range titles {
+		if items, ok := grouped[title]; ok {
+			sections = append(sections, ChangelogSection{Title: title, Entries: items})
+			delete(grouped, title)
+		}
+	}
+	return
+}
+
+// DefaultChangelogTemplate is the default Markdown template of the changelog, the data is Changelog
+const DefaultChangelogTemplate = `## {{.To}}{{if .From}} ({{.From}}...{{.To}}){{end}}
+{{range .Sections}}
+### {{.Title}}
+{{range .Entries}}
+- {{if .Scope}}**{{.Scope}}**: {{end}}{{.Description}} {{if .Number}}([#{{.Number}}]({{.Link}})){{else}}({{.ShortSha}}){{end}}{{if .Author}} by {{.Author}}{{end}}
+{{- end}}
+{{end}}{{if .Contributors}}
+### Contributors
+{{range .Contributors}}
+- {{.}}
+{{- end}}
+{{end}}`
+
+// RenderChangelog renders the changelog with the Go template, the default Markdown template is used if it's empty
+func RenderChangelog(changelog *Changelog, text string) (result string, err error) {
+	var tpl *template.Template
+	if tpl, err = template.New("changelog").Parse(emptyThen(text, DefaultChangelogTemplate)); err != nil {
+		err = fmt.Errorf("invalid changelog template: %v", err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err = tpl.Execute(buf, changelog); err != nil {
+		err = fmt.Errorf("cannot render the changelog: %v", err)
+		return
+	}
+	result = buf.String()
+	return
+}
@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+// commitAll commits the worktree with the message, and returns its hash
+func commitAll(t *testing.T, repo *git.Repository, message string) plumbing.Hash {
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+	return hash
+}
+
+func TestListCommits(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+
+	first := commitAll(t, repo, "init")
+	_, err = repo.CreateTag("v0.0.1", first, &git.CreateTagOptions{
+		Message: "v0.0.1",
+		Tagger:  &object.Signature{Name: "Rick", When: time.Now()},
+	})
+	assert.NoError(t, err)
+	second := commitAll(t, repo, "feat: second")
+	third := commitAll(t, repo, "fix: third")
+
+	commits, err := ListCommits(dir, "v0.0.1", "HEAD")
+	assert.NoError(t, err)
+	if assert.Len(t, commits, 2) {
+		assert.Equal(t, third, commits[0].Hash)
+		assert.Equal(t, second, commits[1].Hash)
+	}
+
+	commits, err = ListCommits(dir, "", "HEAD")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 3)
+
+	_, err = ListCommits(dir, "v9.9.9", "HEAD")
+	assert.Error(t, err)
+	_, err = ListCommits(t.TempDir(), "", "HEAD")
+	assert.Error(t, err)
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	commitAll(t, repo, "direct commit")
+	fixHash := commitAll(t, repo, "fix the bug")
+	featHash := commitAll(t, repo, "add the feature")
+	breakingHash := commitAll(t, repo, "drop the flag")
+
+	base := scm.PullRequestBranch{Repo: scm.Repository{FullName: "linuxsuren/gogit"}}
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{Number: 1, Title: "fix(cmd): the bug", Sha: fixHash.String(), Merged: true, Closed: true,
+		Base: base, Link: "https://github.com/linuxsuren/gogit/pull/1", Author: scm.User{Login: "linuxsuren"},
+		Labels: []*scm.Label{{Name: "bug"}}}
+	data.PullRequests[2] = &scm.PullRequest{Number: 2, Title: "feat: the feature", Sha: featHash.String(), Merged: true, Closed: true,
+		Base: base, Author: scm.User{Login: "alice"}, Labels: []*scm.Label{{Name: "enhancement"}}}
+	data.PullRequests[3] = &scm.PullRequest{Number: 3, Title: "feat!: drop the flag", Sha: breakingHash.String(), Merged: true, Closed: true,
+		Base: base, Author: scm.User{Login: "alice"}}
+
+	commits, err := ListCommits(dir, "", "HEAD")
+	assert.NoError(t, err)
+
+	maker := NewStatusMaker("linuxsuren/gogit", "").WithClient(client)
+	changelog, err := maker.GenerateChangelog(context.Background(), commits, ChangelogOptions{From: "v0.0.1", To: "v0.0.2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Rick", "alice", "linuxsuren"}, changelog.Contributors)
+	if assert.Len(t, changelog.Sections, 4) {
+		assert.Equal(t, "Breaking Changes", changelog.Sections[0].Title)
+		assert.Equal(t, "Features", changelog.Sections[1].Title)
+		assert.Equal(t, "Bug Fixes", changelog.Sections[2].Title)
+		assert.Equal(t, "cmd", changelog.Sections[2].Entries[0].Scope)
+		assert.Equal(t, "Others", changelog.Sections[3].Title)
+		assert.Equal(t, "direct commit", changelog.Sections[3].Entries[0].Description)
+	}
+
+	text, err := RenderChangelog(changelog, "")
+	assert.NoError(t, err)
+	assert.Contains(t, text, "## v0.0.2 (v0.0.1...v0.0.2)\n")
+	assert.Contains(t, text, "\n### Bug Fixes\n\n- **cmd**: the bug ([#1](https://github.com/linuxsuren/gogit/pull/1)) by linuxsuren\n")
+	assert.Contains(t, text, "\n### Contributors\n\n- Rick\n- alice\n- linuxsuren\n")
+
+	changelog, err = maker.GenerateChangelog(context.Background(), commits, ChangelogOptions{
+		GroupBy: ChangelogGroupByLabel,
+		Labels:  []string{"enhancement", "bug"},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, changelog.Sections, 3) {
+		assert.Equal(t, "enhancement", changelog.Sections[0].Title)
+		assert.Equal(t, "bug", changelog.Sections[1].Title)
+		assert.Len(t, changelog.Sections[2].Entries, 2)
+	}
+
+	_, err = RenderChangelog(changelog, "{{.Sections")
+	assert.Error(t, err)
+}
+
+func TestPullRequestsOfCommits(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	commitAll(t, repo, "init")
+	base := commitAll(t, repo, "direct commit")
+	first := commitAll(t, repo, "feat: first")
+	second := commitAll(t, repo, "feat: second")
+	merge, err := worktree.Commit("Merge pull request #1", &git.CommitOptions{
+		Author:  &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+		Parents: []plumbing.Hash{base, second},
+	})
+	assert.NoError(t, err)
+
+	commits, err := ListCommits(dir, "", "HEAD")
+	assert.NoError(t, err)
+
+	pr := &scm.PullRequest{Number: 1, MergeSha: merge.String(), Merged: true}
+	owners := pullRequestsOfCommits(commits, map[string]*scm.PullRequest{merge.String(): pr})
+	assert.Equal(t, map[plumbing.Hash]*scm.PullRequest{merge: pr, first: pr, second: pr}, owners)
+}
@@ -0,0 +1,406 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// CodeOwnersLocations are the paths of CODEOWNERS which are supported by GitHub and GitLab, the first found one is used
+var CodeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// CodeOwners is the parsed CODEOWNERS file, both GitHub and GitLab (with sections) syntax are supported
+type CodeOwners struct {
+	Rules []CodeOwnersRule
+}
+
+// CodeOwnersRule is a pattern with its owners
+type CodeOwnersRule struct {
+	// Section is the lower case name of the GitLab section, it's empty for the rules out of any sections
+	Section string
+	Pattern string
+	// Owners could be @user, @group/team or an email address
+	Owners []string
+
+	regexp *regexp.Regexp
+}
+
+var codeOwnersSection = regexp.MustCompile(`^\^?\[([^\]]+)\](\[\d+\])?(?:\s+(.*))?$`)
+
+// ParseCodeOwners parses the CODEOWNERS file
+func ParseCodeOwners(data []byte) (owners *CodeOwners, err error) {
+	owners = &CodeOwners{}
+	var section string
+	var defaultOwners []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for num := 1; scanner.Scan(); num++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, sectionOwners, ok := parseCodeOwnersSection(line); ok {
+			section = name
+			defaultOwners = sectionOwners
+			continue
+		}
+
+		fields := codeOwnersFields(line)
+		rule := CodeOwnersRule{
+			Section: section,
+			Pattern: strings.ReplaceAll(fields[0], `\#`, "#"),
+			Owners:  fields[1:],
+		}
+		if len(rule.Owners) == 0 {
+			rule.Owners = defaultOwners
+		}
+		if rule.regexp, err = codeOwnersRegexp(rule.Pattern); err != nil {
+			err = fmt.Errorf("invalid pattern %q at line %d: %v", rule.Pattern, num, err)
+			return
+		}
+		owners.Rules = append(owners.Rules, rule)
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseCodeOwnersSection parses the GitLab section header, a line like "[Dd]ocs/ @x" is a rule
+// because the header could only be followed by the owners
+func parseCodeOwnersSection(line string) (name string, owners []string, ok bool) {
+	groups := codeOwnersSection.FindStringSubmatch(line)
+	if groups == nil {
+		return
+	}
+	owners = codeOwnersFields(groups[3])
+	for _, owner := range owners {
+		if !strings.Contains(owner, "@") {
+			return
+		}
+	}
+	name = strings.ToLower(strings.TrimSpace(groups[1]))
+	ok = true
+	return
+}
+
+// codeOwnersFields splits the line by spaces, the inline comment is removed
+func codeOwnersFields(line string) (fields []string) {
+	for _, field := range strings.Fields(line) {
+		if strings.HasPrefix(field, "#") {
+			break
+		}
+		fields = append(fields, field)
+	}
+	return
+}
+
+// codeOwnersRegexp converts the gitignore-style pattern to a regular expression
+func codeOwnersRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	// the files under a matched directory are matched as well, but a wildcard in the last segment only matches one level
+	lastSegment := pattern[strings.LastIndex(pattern, "/")+1:]
+	wildcard := strings.ContainsAny(lastSegment, "*?")
+
+	expr := new(strings.Builder)
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	switch {
+	case dirOnly:
+		expr.WriteString("/.*$")
+	case wildcard:
+		expr.WriteString("$")
+	default:
+		expr.WriteString("(/.*)?$")
+	}
+	return regexp.Compile(expr.String())
+}
+
+// Match returns the matched rules of the file, the last matched rule of each section takes effect
+func (c *CodeOwners) Match(file string) (rules []CodeOwnersRule) {
+	file = strings.TrimPrefix(file, "/")
+	matched := make(map[string]int)
+	var sections []string
+	for i, rule := range c.Rules {
+		if !rule.regexp.MatchString(file) {
+			continue
+		}
+		if _, ok := matched[rule.Section]; !ok {
+			sections = append(sections, rule.Section)
+		}
+		matched[rule.Section] = i
+	}
+
+	for _, section := range sections {
+		if rule := c.Rules[matched[section]]; len(rule.Owners) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return
+}
+
+// codeOwnerLogin returns the login of a user owner, the teams and emails are not supported by the reviewer requests
+func codeOwnerLogin(owner string) (login string, ok bool) {
+	if strings.HasPrefix(owner, "@") && !strings.Contains(owner, "/") {
+		login, ok = strings.TrimPrefix(owner, "@"), true
+	}
+	return
+}
+
+// AssignReviewersOptions is the options for requesting the reviewers from CODEOWNERS
+type AssignReviewersOptions struct {
+	// CodeOwners is the content of CODEOWNERS, it will be loaded from the base branch if it's empty
+	CodeOwners []byte
+	// Max is the maximum number of the new reviewers, zero means no limit
+	Max int
+	// Assignee assigns the owners instead of requesting their reviews
+	Assignee bool
+	DryRun   bool
+}
+
+// AssignReviewers requests the reviews from the owners of the changed files. The owners with fewer open pull
+// requests to review are preferred, and each matched rule gets at least one owner if the limit allows
+func (s *StatusMaker) AssignReviewers(ctx context.Context, opt AssignReviewersOptions) (reviewers []string, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var pr *scm.PullRequest
+	if pr, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err != nil {
+		err = fmt.Errorf("failed to find pull request [%d] from [%s] %v", s.pr, s.repo, err)
+		return
+	}
+
+	data := opt.CodeOwners
+	if len(data) == 0 {
+		if data, err = s.findCodeOwners(ctx, scmClient, pr); err != nil {
+			return
+		}
+	}
+
+	var owners *CodeOwners
+	if owners, err = ParseCodeOwners(data); err != nil {
+		return
+	}
+
+	var changes []*scm.Change
+	if changes, err = listAllChanges(ctx, scmClient, s.repo, s.pr); err != nil {
+		return
+	}
+
+	var ownerSets [][]string
+	for _, change := range changes {
+		for _, rule := range owners.Match(change.Path) {
+			var logins []string
+			for _, owner := range rule.Owners {
+				if login, ok := codeOwnerLogin(owner); ok && login != pr.Author.Login {
+					logins = append(logins, login)
+				}
+			}
+			if len(logins) > 0 {
+				ownerSets = append(ownerSets, logins)
+			}
+		}
+	}
+	if len(ownerSets) == 0 {
+		return
+	}
+
+	var load map[string]int
+	if load, err = s.reviewLoad(ctx, scmClient); err != nil {
+		return
+	}
+
+	existing := make(map[string]struct{})
+	for _, user := range append(append([]scm.User{}, pr.Reviewers...), pr.Assignees...) {
+		existing[user.Login] = struct{}{}
+	}
+	if reviewers = selectReviewers(ownerSets, existing, load, opt.Max); len(reviewers) == 0 || opt.DryRun {
+		return
+	}
+
+	if opt.Assignee {
+		_, err = scmClient.PullRequests.AssignIssue(ctx, s.repo, s.pr, reviewers)
+		err = WrapError(err, "failed to assign %v: %v", reviewers)
+	} else if s.provider == "gitlab" {
+		// go-scm assigns the users instead of requesting their reviews on GitLab
+		err = requestGitLabReviewers(ctx, scmClient, s.repo, s.pr, reviewers)
+		err = WrapError(err, "failed to request the reviews of %v: %v", reviewers)
+	} else {
+		_, err = scmClient.PullRequests.RequestReview(ctx, s.repo, s.pr, reviewers)
+		err = WrapError(err, "failed to request the reviews of %v: %v", reviewers)
+	}
+	return
+}
+
+// requestGitLabReviewers adds the users to the reviewers of the merge request, the existing reviewers are kept
+//
+// See also https://docs.gitlab.com/ee/api/merge_requests.html#update-mr
+func requestGitLabReviewers(ctx context.Context, scmClient *scm.Client, repo string, number int, logins []string) (err error) {
+	mrPath := fmt.Sprintf("api/v4/projects/%s/merge_requests/%d", gitlabProject(repo), number)
+	mr := &struct {
+		Reviewers []struct {
+			ID int `json:"id"`
+		} `json:"reviewers"`
+	}{}
+	if err = requestJSON(ctx, scmClient, http.MethodGet, mrPath, nil, mr); err != nil {
+		return
+	}
+
+	var reviewerIDs []int
+	for _, reviewer := range mr.Reviewers {
+		reviewerIDs = append(reviewerIDs, reviewer.ID)
+	}
+	for _, login := range logins {
+		var users []struct {
+			ID int `json:"id"`
+		}
+		if err = requestJSON(ctx, scmClient, http.MethodGet, "api/v4/users?username="+url.QueryEscape(login), nil, &users); err != nil {
+			return
+		}
+		if len(users) == 0 {
+			err = fmt.Errorf("cannot find the GitLab user %q", login)
+			return
+		}
+		if !slices.Contains(reviewerIDs, users[0].ID) {
+			reviewerIDs = append(reviewerIDs, users[0].ID)
+		}
+	}
+	err = requestJSON(ctx, scmClient, http.MethodPut, mrPath, map[string][]int{"reviewer_ids": reviewerIDs}, nil)
+	return
+}
+
+func (s *StatusMaker) findCodeOwners(ctx context.Context, scmClient *scm.Client, pr *scm.PullRequest) (data []byte, err error) {
+	ref := pr.Target
+	if ref == "" {
+		ref = pr.Base.Ref
+	}
+
+	for _, location := range CodeOwnersLocations {
+		var content *scm.Content
+		var resp *scm.Response
+		if content, resp, err = scmClient.Contents.Find(ctx, s.repo, location, ref); err == nil {
+			data = content.Data
+			return
+		}
+		if resp == nil || resp.Status != http.StatusNotFound {
+			err = fmt.Errorf("failed to find %q from %q: %v", location, ref, err)
+			return
+		}
+	}
+	err = fmt.Errorf("cannot find CODEOWNERS from %q, the locations are %v", ref, CodeOwnersLocations)
+	return
+}
+
+// reviewLoad counts the other open pull requests which the users are reviewing or assigned to
+func (s *StatusMaker) reviewLoad(ctx context.Context, scmClient *scm.Client) (load map[string]int, err error) {
+	var prs []*scm.PullRequest
+	if prs, err = listAllPullRequests(ctx, scmClient, s.repo, &scm.PullRequestListOptions{Open: true}); err != nil {
+		return
+	}
+
+	load = make(map[string]int)
+	for _, pr := range prs {
+		if pr.Number == s.pr || pr.Closed || pr.Merged {
+			continue
+		}
+		for _, login := range PullRequestLogins(pr) {
+			if login != pr.Author.Login {
+				load[login]++
+			}
+		}
+	}
+	return
+}
+
+// selectReviewers covers each owner set with the least loaded owner first, then fills up to the max
+func selectReviewers(ownerSets [][]string, existing map[string]struct{}, load map[string]int, max int) (reviewers []string) {
+	selected := make(map[string]struct{})
+	covered := func(owners []string) bool {
+		for _, owner := range owners {
+			if _, ok := existing[owner]; ok {
+				return true
+			}
+			if _, ok := selected[owner]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	sortByLoad := func(owners []string) {
+		sort.SliceStable(owners, func(i, j int) bool {
+			if load[owners[i]] != load[owners[j]] {
+				return load[owners[i]] < load[owners[j]]
+			}
+			return owners[i] < owners[j]
+		})
+	}
+	full := func() bool {
+		return max > 0 && len(reviewers) >= max
+	}
+	add := func(owner string) {
+		selected[owner] = struct{}{}
+		reviewers = append(reviewers, owner)
+		load[owner]++
+	}
+
+	candidates := make(map[string]struct{})
+	for _, owners := range ownerSets {
+		owners = append([]string{}, owners...)
+		for _, owner := range owners {
+			candidates[owner] = struct{}{}
+		}
+		if full() || covered(owners) {
+			continue
+		}
+		sortByLoad(owners)
+		add(owners[0])
+	}
+
+	var rest []string
+	for owner := range candidates {
+		_, isExisting := existing[owner]
+		if _, isSelected := selected[owner]; !isExisting && !isSelected {
+			rest = append(rest, owner)
+		}
+	}
+	sortByLoad(rest)
+	for _, owner := range rest {
+		if full() {
+			break
+		}
+		add(owner)
+	}
+	return
+}
@@ -0,0 +1,217 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleCodeOwners = `# default owners
+*                 @rick
+*.md              @writer @org/docs   # docs team
+/build/           @ops
+docs/**/api.md    @api
+\#notes           @notes
+
+[Backend][2] @backend-lead
+pkg/
+cmd/              @bob @alice
+
+^[Frontend]
+/web/             @carol
+`
+
+func TestParseCodeOwners(t *testing.T) {
+	owners, err := ParseCodeOwners([]byte(sampleCodeOwners))
+	assert.NoError(t, err)
+	assert.Len(t, owners.Rules, 8)
+	assert.Equal(t, "#notes", owners.Rules[4].Pattern)
+	assert.Equal(t, []string{"@backend-lead"}, owners.Rules[5].Owners)
+	assert.Equal(t, "frontend", owners.Rules[7].Section)
+
+	tests := []struct {
+		file   string
+		expect [][]string
+	}{{
+		file:   "main.go",
+		expect: [][]string{{"@rick"}},
+	}, {
+		file:   "README.md",
+		expect: [][]string{{"@writer", "@org/docs"}},
+	}, {
+		file:   "docs/en/README.md",
+		expect: [][]string{{"@writer", "@org/docs"}},
+	}, {
+		file:   "build/Dockerfile",
+		expect: [][]string{{"@ops"}},
+	}, {
+		file:   "sub/build/Dockerfile",
+		expect: [][]string{{"@rick"}},
+	}, {
+		file:   "docs/api.md",
+		expect: [][]string{{"@api"}},
+	}, {
+		file:   "docs/v1/api.md",
+		expect: [][]string{{"@api"}},
+	}, {
+		file:   "pkg/git.go",
+		expect: [][]string{{"@rick"}, {"@backend-lead"}},
+	}, {
+		file:   "cmd/argoworkflow/main.go",
+		expect: [][]string{{"@rick"}, {"@bob", "@alice"}},
+	}, {
+		file:   "web/index.html",
+		expect: [][]string{{"@rick"}, {"@carol"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var result [][]string
+			for _, rule := range owners.Match(tt.file) {
+				result = append(result, rule.Owners)
+			}
+			assert.Equal(t, tt.expect, result)
+		})
+	}
+}
+
+func TestParseCodeOwnersWildcard(t *testing.T) {
+	owners, err := ParseCodeOwners([]byte(`docs/*      @writer
+[Dd]ocs/    @docs
+[Apps] @apps
+apps/
+`))
+	assert.NoError(t, err)
+	if assert.Len(t, owners.Rules, 3) {
+		assert.Empty(t, owners.Rules[1].Section)
+		assert.Equal(t, "[Dd]ocs/", owners.Rules[1].Pattern)
+		assert.Equal(t, []string{"@docs"}, owners.Rules[1].Owners)
+		assert.Equal(t, "apps", owners.Rules[2].Section)
+		assert.Equal(t, []string{"@apps"}, owners.Rules[2].Owners)
+	}
+
+	tests := []struct {
+		file   string
+		expect [][]string
+	}{{
+		file:   "docs/README.md",
+		expect: [][]string{{"@writer"}},
+	}, {
+		file: "docs/en/README.md",
+	}, {
+		file:   "[Dd]ocs/README.md",
+		expect: [][]string{{"@docs"}},
+	}, {
+		file:   "apps/main.go",
+		expect: [][]string{{"@apps"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var result [][]string
+			for _, rule := range owners.Match(tt.file) {
+				result = append(result, rule.Owners)
+			}
+			assert.Equal(t, tt.expect, result)
+		})
+	}
+}
+
+func TestSelectReviewers(t *testing.T) {
+	ownerSets := [][]string{{"bob", "alice"}, {"rick"}, {"carol", "alice"}}
+
+	load := map[string]int{"bob": 3, "alice": 1, "rick": 0, "carol": 0}
+	assert.Equal(t, []string{"alice", "rick", "carol", "bob"}, selectReviewers(ownerSets, map[string]struct{}{}, load, 0))
+
+	load = map[string]int{"bob": 3, "alice": 1, "rick": 0, "carol": 0}
+	assert.Equal(t, []string{"alice"}, selectReviewers(ownerSets, map[string]struct{}{}, load, 1))
+
+	load = map[string]int{"bob": 0, "alice": 1}
+	assert.Equal(t, []string{"bob", "carol"},
+		selectReviewers(ownerSets, map[string]struct{}{"rick": {}}, load, 2))
+}
+
+func TestAssignReviewers(t *testing.T) {
+	client, data := fake.NewDefault()
+	base := scm.PullRequestBranch{Repo: scm.Repository{FullName: "linuxsuren/gogit"}}
+	data.PullRequests[1] = &scm.PullRequest{
+		Number:    1,
+		Target:    "master",
+		Base:      base,
+		Author:    scm.User{Login: "rick"},
+		Reviewers: []scm.User{{Login: "carol"}},
+	}
+	data.PullRequests[2] = &scm.PullRequest{
+		Number:    2,
+		Base:      base,
+		Author:    scm.User{Login: "rick"},
+		Assignees: []scm.User{{Login: "bob"}},
+	}
+	data.PullRequestChanges[1] = []*scm.Change{{Path: "cmd/root.go"}, {Path: "web/index.html"}, {Path: "main.go"}}
+
+	maker := NewStatusMaker("linuxsuren/gogit", "token").WithPR(1).WithClient(client)
+	reviewers, err := maker.AssignReviewers(context.Background(), AssignReviewersOptions{
+		CodeOwners: []byte(sampleCodeOwners),
+		Max:        1,
+		Assignee:   true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, reviewers)
+	assert.Equal(t, []string{"linuxsuren/gogit#1:alice"}, data.AssigneesAdded)
+
+	// load CODEOWNERS from the base branch
+	data.ContentDir = t.TempDir()
+	dir := filepath.Join(data.ContentDir, "linuxsuren/gogit", "refs", "master", ".gitlab")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @bob @alice"), 0644))
+	reviewers, err = maker.AssignReviewers(context.Background(), AssignReviewersOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, reviewers)
+
+	// the reviews are not supported by the fake provider
+	_, err = maker.AssignReviewers(context.Background(), AssignReviewersOptions{})
+	assert.Error(t, err)
+}
+
+func TestRequestGitLabReviewers(t *testing.T) {
+	var reviewerIDs map[string][]int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/projects/owner/repo/merge_requests/1":
+			_, _ = fmt.Fprint(w, `{"iid": 1, "reviewers": [{"id": 3, "username": "carol"}]}`)
+		case "PUT /api/v4/projects/owner/repo/merge_requests/1":
+			_ = json.NewDecoder(r.Body).Decode(&reviewerIDs)
+			_, _ = fmt.Fprint(w, `{"iid": 1}`)
+		case "GET /api/v4/users":
+			switch r.URL.Query().Get("username") {
+			case "alice":
+				_, _ = fmt.Fprint(w, `[{"id": 1, "username": "alice"}]`)
+			case "carol":
+				_, _ = fmt.Fprint(w, `[{"id": 3, "username": "carol"}]`)
+			default:
+				_, _ = fmt.Fprint(w, `[]`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("gitlab", server.URL, "token")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, requestGitLabReviewers(ctx, client, "owner/repo", 1, []string{"alice", "carol"}))
+	assert.Equal(t, map[string][]int{"reviewer_ids": {3, 1}}, reviewerIDs)
+
+	assert.ErrorContains(t, requestGitLabReviewers(ctx, client, "owner/repo", 1, []string{"unknown"}),
+		`cannot find the GitLab user "unknown"`)
+}
@@ -0,0 +1,455 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// ListComments lists all the comments which have the identity
+func (s *StatusMaker) ListComments(ctx context.Context, identity string) (comments []*scm.Comment, err error) {
+	var service commentService
+	if service, err = s.getCommentService(); err != nil {
+		return
+	}
+
+	var all []*scm.Comment
+	if all, err = service.List(ctx); err == nil {
+		comments = filterComments(all, identity)
+	}
+	return
+}
+
+// DeleteComments deletes all the comments which have the identity
+func (s *StatusMaker) DeleteComments(ctx context.Context, identity string) (count int, err error) {
+	var service commentService
+	if service, err = s.getCommentService(); err != nil {
+		return
+	}
+
+	var comments []*scm.Comment
+	if comments, err = service.List(ctx); err != nil {
+		return
+	}
+
+	for _, comment := range filterComments(comments, identity) {
+		if deleteErr := service.Delete(ctx, comment.ID); deleteErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to delete comment %d: %v", comment.ID, deleteErr))
+		} else {
+			count++
+		}
+	}
+	return
+}
+
+// MinimizeComments hides all the comments which have the identity, it's only supported by GitHub
+//
+// See also https://docs.github.com/en/graphql/reference/mutations#minimizecomment
+func (s *StatusMaker) MinimizeComments(ctx context.Context, identity, reason string) (count int, err error) {
+	if s.provider != "github" {
+		err = fmt.Errorf("minimize comments is not supported by %q", s.provider)
+		return
+	}
+
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var comments []*scm.Comment
+	if comments, err = s.ListComments(ctx, identity); err != nil {
+		return
+	}
+
+	for _, comment := range comments {
+		apiPath := fmt.Sprintf("repos/%s/issues/comments/%d", s.repo, comment.ID)
+		if s.sha != "" {
+			apiPath = fmt.Sprintf("repos/%s/comments/%d", s.repo, comment.ID)
+		}
+		if minimizeErr := minimizeGitHubComment(ctx, scmClient, apiPath, reason); minimizeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to minimize comment %d: %v", comment.ID, minimizeErr))
+		} else {
+			count++
+		}
+	}
+	return
+}
+
+// ResolveComments resolves the discussions of all the comments which have the identity, it's only supported by GitLab.
+// The comments which are not resolvable, such as the ones of the issues and commits, are deleted instead
+//
+// See also https://docs.gitlab.com/ee/api/discussions.html#resolve-a-merge-request-thread
+func (s *StatusMaker) ResolveComments(ctx context.Context, identity string) (count int, err error) {
+	if s.provider != "gitlab" {
+		err = fmt.Errorf("resolve comments is not supported by %q", s.provider)
+		return
+	}
+
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	discussionsPath := s.gitlabDiscussionsPath()
+	var discussions []gitlabDiscussion
+	if discussions, err = listGitLabDiscussions(ctx, scmClient, discussionsPath); err != nil {
+		return
+	}
+
+	for _, discussion := range discussions {
+		for _, note := range discussion.Notes {
+			if !strings.HasSuffix(note.Body, identity) || note.Resolved {
+				continue
+			}
+
+			var requestErr error
+			if note.Resolvable {
+				path := fmt.Sprintf("%s/%s?resolved=true", discussionsPath, discussion.ID)
+				if requestErr = requestJSON(ctx, scmClient, http.MethodPut, path, nil, nil); requestErr != nil {
+					requestErr = fmt.Errorf("failed to resolve comment %d: %v", note.ID, requestErr)
+				}
+			} else {
+				path := fmt.Sprintf("%s/%s/notes/%d", discussionsPath, discussion.ID, note.ID)
+				if requestErr = requestJSON(ctx, scmClient, http.MethodDelete, path, nil, nil); requestErr != nil {
+					requestErr = fmt.Errorf("failed to delete the unresolvable comment %d: %v", note.ID, requestErr)
+				}
+			}
+			if requestErr != nil {
+				err = errors.Join(err, requestErr)
+			} else {
+				count++
+			}
+		}
+	}
+	return
+}
+
+func filterComments(comments []*scm.Comment, endMarker string) (result []*scm.Comment) {
+	for _, comment := range comments {
+		if strings.HasSuffix(comment.Body, endMarker) {
+			result = append(result, comment)
+		}
+	}
+	return
+}
+
+func minimizeGitHubComment(ctx context.Context, scmClient *scm.Client, apiPath, reason string) (err error) {
+	comment := &struct {
+		NodeID string `json:"node_id"`
+	}{}
+	if err = requestJSON(ctx, scmClient, http.MethodGet, apiPath, nil, comment); err != nil {
+		return
+	}
+
+	err = requestGraphQL(ctx, scmClient, `mutation($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) { clientMutationId }
+}`, map[string]any{
+		"id":         comment.NodeID,
+		"classifier": strings.ToUpper(reason),
+	}, nil)
+	return
+}
+
+// gitlabDiscussionsPath returns the discussions API path of the target
+func (s *StatusMaker) gitlabDiscussionsPath() string {
+	project := gitlabProject(s.repo)
+	switch {
+	case s.sha != "":
+		return fmt.Sprintf("api/v4/projects/%s/repository/commits/%s/discussions", project, s.sha)
+	case s.issue > 0:
+		return fmt.Sprintf("api/v4/projects/%s/issues/%d/discussions", project, s.issue)
+	default:
+		return fmt.Sprintf("api/v4/projects/%s/merge_requests/%d/discussions", project, s.pr)
+	}
+}
+
+type gitlabDiscussion struct {
+	ID    string `json:"id"`
+	Notes []struct {
+		ID   int    `json:"id"`
+		Body string `json:"body"`
+		// Resolvable is false for the individual notes, only the merge request discussions could be resolved
+		Resolvable bool `json:"resolvable"`
+		Resolved   bool `json:"resolved"`
+		Author     struct {
+			Username string `json:"username"`
+			Name     string `json:"name"`
+		} `json:"author"`
+	} `json:"notes"`
+}
+
+func listGitLabDiscussions(ctx context.Context, scmClient *scm.Client, discussionsPath string) (discussions []gitlabDiscussion, err error) {
+	for page := 1; page > 0; {
+		var items []gitlabDiscussion
+		path := fmt.Sprintf("%s?page=%d&per_page=100", discussionsPath, page)
+		if err = requestJSON(ctx, scmClient, http.MethodGet, path, nil, &items); err != nil {
+			err = fmt.Errorf("failed to list the discussions: %v", err)
+			return
+		}
+		discussions = append(discussions, items...)
+
+		if len(items) < 100 {
+			break
+		}
+		page++
+	}
+	return
+}
+
+// commentService is the abstraction of the comments against different targets
+type commentService interface {
+	List(ctx context.Context) ([]*scm.Comment, error)
+	Create(ctx context.Context, input *scm.CommentInput) error
+	Edit(ctx context.Context, id int, input *scm.CommentInput) error
+	Delete(ctx context.Context, id int) error
+	String() string
+}
+
+func (s *StatusMaker) getCommentService() (service commentService, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	switch {
+	case s.sha != "":
+		switch s.provider {
+		case "github":
+			service = &githubCommitCommentService{client: scmClient, repo: s.repo, sha: s.sha}
+		case "gitlab":
+			service = &gitlabDiscussionCommentService{client: scmClient, path: s.gitlabDiscussionsPath(),
+				target: fmt.Sprintf("commit %s", s.sha)}
+		default:
+			err = fmt.Errorf("commit comments is not supported by %q", s.provider)
+		}
+	case s.issue > 0:
+		service = &issueCommentService{client: scmClient, repo: s.repo, issue: s.issue}
+	case s.provider == "gitlab":
+		// the notes of a merge request are not resolvable, but the discussions are
+		service = &gitlabDiscussionCommentService{client: scmClient, path: s.gitlabDiscussionsPath(),
+			target: fmt.Sprintf("pull request %d", s.pr)}
+	default:
+		service = &pullRequestCommentService{client: scmClient, repo: s.repo, pr: s.pr}
+	}
+	return
+}
+
+type pullRequestCommentService struct {
+	client *scm.Client
+	repo   string
+	pr     int
+}
+
+func (p *pullRequestCommentService) List(ctx context.Context) (comments []*scm.Comment, err error) {
+	opt := &scm.ListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Comment
+		var resp *scm.Response
+		if items, resp, err = p.client.PullRequests.ListComments(ctx, p.repo, p.pr, opt); err != nil {
+			return
+		}
+		comments = append(comments, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+func (p *pullRequestCommentService) Create(ctx context.Context, input *scm.CommentInput) (err error) {
+	_, _, err = p.client.PullRequests.CreateComment(ctx, p.repo, p.pr, input)
+	return
+}
+
+func (p *pullRequestCommentService) Edit(ctx context.Context, id int, input *scm.CommentInput) (err error) {
+	_, _, err = p.client.PullRequests.EditComment(ctx, p.repo, p.pr, id, input)
+	return
+}
+
+func (p *pullRequestCommentService) Delete(ctx context.Context, id int) (err error) {
+	_, err = p.client.PullRequests.DeleteComment(ctx, p.repo, p.pr, id)
+	return
+}
+
+func (p *pullRequestCommentService) String() string {
+	return fmt.Sprintf("pull request %d", p.pr)
+}
+
+type issueCommentService struct {
+	client *scm.Client
+	repo   string
+	issue  int
+}
+
+func (i *issueCommentService) List(ctx context.Context) (comments []*scm.Comment, err error) {
+	opt := &scm.ListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Comment
+		var resp *scm.Response
+		if items, resp, err = i.client.Issues.ListComments(ctx, i.repo, i.issue, opt); err != nil {
+			return
+		}
+		comments = append(comments, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+func (i *issueCommentService) Create(ctx context.Context, input *scm.CommentInput) (err error) {
+	_, _, err = i.client.Issues.CreateComment(ctx, i.repo, i.issue, input)
+	return
+}
+
+func (i *issueCommentService) Edit(ctx context.Context, id int, input *scm.CommentInput) (err error) {
+	_, _, err = i.client.Issues.EditComment(ctx, i.repo, i.issue, id, input)
+	return
+}
+
+func (i *issueCommentService) Delete(ctx context.Context, id int) (err error) {
+	_, err = i.client.Issues.DeleteComment(ctx, i.repo, i.issue, id)
+	return
+}
+
+func (i *issueCommentService) String() string {
+	return fmt.Sprintf("issue %d", i.issue)
+}
+
+// githubCommitCommentService is not covered by go-scm
+//
+// See also https://docs.github.com/en/rest/commits/comments
+type githubCommitCommentService struct {
+	client *scm.Client
+	repo   string
+	sha    string
+}
+
+type githubCommitComment struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (g *githubCommitCommentService) List(ctx context.Context) (comments []*scm.Comment, err error) {
+	for page := 1; page > 0; page++ {
+		var items []githubCommitComment
+		path := fmt.Sprintf("repos/%s/commits/%s/comments?page=%d&per_page=100", g.repo, g.sha, page)
+		if err = requestJSON(ctx, g.client, http.MethodGet, path, nil, &items); err != nil {
+			return
+		}
+
+		for _, item := range items {
+			comments = append(comments, &scm.Comment{
+				ID:     item.ID,
+				Body:   item.Body,
+				Author: scm.User{Login: item.User.Login},
+				Link:   item.HTMLURL,
+			})
+		}
+		if len(items) < 100 {
+			break
+		}
+	}
+	return
+}
+
+func (g *githubCommitCommentService) Create(ctx context.Context, input *scm.CommentInput) error {
+	return requestJSON(ctx, g.client, http.MethodPost, fmt.Sprintf("repos/%s/commits/%s/comments", g.repo, g.sha),
+		map[string]string{"body": input.Body}, nil)
+}
+
+func (g *githubCommitCommentService) Edit(ctx context.Context, id int, input *scm.CommentInput) error {
+	return requestJSON(ctx, g.client, http.MethodPatch, fmt.Sprintf("repos/%s/comments/%d", g.repo, id),
+		map[string]string{"body": input.Body}, nil)
+}
+
+func (g *githubCommitCommentService) Delete(ctx context.Context, id int) error {
+	return requestJSON(ctx, g.client, http.MethodDelete, fmt.Sprintf("repos/%s/comments/%d", g.repo, id), nil, nil)
+}
+
+func (g *githubCommitCommentService) String() string {
+	return fmt.Sprintf("commit %s", g.sha)
+}
+
+// gitlabDiscussionCommentService is based on the discussions, because the commit comments cannot be updated
+// or deleted, and only the discussions of a merge request could be resolved on GitLab
+//
+// See also https://docs.gitlab.com/ee/api/discussions.html
+type gitlabDiscussionCommentService struct {
+	client *scm.Client
+	path   string
+	target string
+	// notes is the map from note ID to the discussion ID
+	notes map[int]string
+}
+
+func (g *gitlabDiscussionCommentService) List(ctx context.Context) (comments []*scm.Comment, err error) {
+	var discussions []gitlabDiscussion
+	if discussions, err = listGitLabDiscussions(ctx, g.client, g.path); err != nil {
+		return
+	}
+
+	g.notes = make(map[int]string)
+	for _, discussion := range discussions {
+		for _, note := range discussion.Notes {
+			g.notes[note.ID] = discussion.ID
+			comments = append(comments, &scm.Comment{
+				ID:     note.ID,
+				Body:   note.Body,
+				Author: scm.User{Login: note.Author.Username, Name: note.Author.Name},
+			})
+		}
+	}
+	return
+}
+
+func (g *gitlabDiscussionCommentService) Create(ctx context.Context, input *scm.CommentInput) error {
+	return requestJSON(ctx, g.client, http.MethodPost, g.path, map[string]string{"body": input.Body}, nil)
+}
+
+func (g *gitlabDiscussionCommentService) Edit(ctx context.Context, id int, input *scm.CommentInput) (err error) {
+	var path string
+	if path, err = g.notePath(ctx, id); err == nil {
+		err = requestJSON(ctx, g.client, http.MethodPut, path, map[string]string{"body": input.Body}, nil)
+	}
+	return
+}
+
+func (g *gitlabDiscussionCommentService) Delete(ctx context.Context, id int) (err error) {
+	var path string
+	if path, err = g.notePath(ctx, id); err == nil {
+		err = requestJSON(ctx, g.client, http.MethodDelete, path, nil, nil)
+	}
+	return
+}
+
+func (g *gitlabDiscussionCommentService) notePath(ctx context.Context, id int) (path string, err error) {
+	if g.notes == nil {
+		if _, err = g.List(ctx); err != nil {
+			return
+		}
+	}
+
+	if discussion, ok := g.notes[id]; ok {
+		path = fmt.Sprintf("%s/%s/notes/%d", g.path, discussion, id)
+	} else {
+		err = fmt.Errorf("cannot find the discussion of note %d", id)
+	}
+	return
+}
+
+func (g *gitlabDiscussionCommentService) String() string {
+	return g.target
+}
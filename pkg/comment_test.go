@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAndDeleteComments(t *testing.T) {
+	client, data := fake.NewDefault()
+	data.PullRequestComments[1] = []*scm.Comment{
+		{ID: 1, Body: "hello\n\n" + CommentEndMarker},
+		{ID: 2, Body: "other"},
+		{ID: 3, Body: "world\n\n" + CommentEndMarker},
+	}
+
+	ctx := context.Background()
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithClient(client)
+
+	comments, err := maker.ListComments(ctx, CommentEndMarker)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+
+	count, err := maker.DeleteComments(ctx, CommentEndMarker)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, data.PullRequestComments[1], 1)
+	assert.Equal(t, 2, data.PullRequestComments[1][0].ID)
+}
+
+func TestHideCommentsWithUnsupportedProvider(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewStatusMaker("owner/repo", "").WithProvider("gitlab").MinimizeComments(ctx, CommentEndMarker, "outdated")
+	assert.Error(t, err)
+
+	_, err = NewStatusMaker("owner/repo", "").WithProvider("github").ResolveComments(ctx, CommentEndMarker)
+	assert.Error(t, err)
+}
+
+func TestResolveComments(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/owner/repo/merge_requests/1/discussions":
+			// the discussion created by gogit, an individual note, a resolved discussion and the others
+			_, _ = fmt.Fprintf(w, `[{
+  "id": "abc", "individual_note": false,
+  "notes": [{"id": 10, "type": "DiscussionNote", "body": "hello\n\n%[1]s", "author": {"id": 1, "username": "bot"},
+    "system": false, "noteable_type": "MergeRequest", "resolvable": true, "resolved": false}]
+}, {
+  "id": "def", "individual_note": true,
+  "notes": [{"id": 11, "type": null, "body": "old\n\n%[1]s", "author": {"id": 1, "username": "bot"},
+    "system": false, "noteable_type": "MergeRequest", "resolvable": false}]
+}, {
+  "id": "ghi", "individual_note": false,
+  "notes": [{"id": 12, "type": "DiscussionNote", "body": "done\n\n%[1]s", "author": {"id": 1, "username": "bot"},
+    "system": false, "noteable_type": "MergeRequest", "resolvable": true, "resolved": true}]
+}, {
+  "id": "jkl", "individual_note": true,
+  "notes": [{"id": 13, "type": null, "body": "other", "author": {"id": 2, "username": "rick"},
+    "system": false, "noteable_type": "MergeRequest", "resolvable": false}]
+}]`, CommentEndMarker)
+		case r.Method == http.MethodPut || r.Method == http.MethodDelete:
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			_, _ = fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("gitlab", server.URL, "token")
+	assert.NoError(t, err)
+
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithProvider("gitlab").WithClient(client)
+	count, err := maker.ResolveComments(context.Background(), CommentEndMarker)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{
+		"PUT /api/v4/projects/owner/repo/merge_requests/1/discussions/abc",
+		"DELETE /api/v4/projects/owner/repo/merge_requests/1/discussions/def/notes/11",
+	}, requests)
+}
+
+func TestCreateCommentOnGitLabMergeRequest(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet:
+			_, _ = fmt.Fprint(w, `[{"id": "abc", "individual_note": true, "notes": [{"id": 11, "body": "other", "resolvable": false}]}]`)
+		default:
+			_, _ = fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("gitlab", server.URL, "token")
+	assert.NoError(t, err)
+
+	// the comment is created as a discussion, so it could be resolved
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithProvider("gitlab").WithClient(client)
+	assert.NoError(t, maker.CreateComment(context.Background(), "build passed", CommentEndMarker))
+	assert.Equal(t, []string{
+		"GET /api/v4/projects/owner/repo/merge_requests/1/discussions",
+		"POST /api/v4/projects/owner/repo/merge_requests/1/discussions",
+	}, requests)
+}
+
+func TestCreateCommentOnIssue(t *testing.T) {
+	client, data := fake.NewDefault()
+
+	maker := NewStatusMaker("owner/repo", "").WithIssue(2).WithClient(client)
+	err := maker.CreateComment(context.Background(), "nightly build passed", CommentEndMarker)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owner/repo#2:nightly build passed\n\n" + CommentEndMarker}, data.IssueCommentsAdded)
+	assert.Empty(t, data.PullRequestComments)
+}
+
+func TestCreateCommentOnCommit(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/commits/sha/comments":
+			_, _ = fmt.Fprintf(w, `[{"id": 1, "body": "old\n\n%s"}, {"id": 2, "body": "other"}, {"id": 3, "body": "dup\n\n%s"}]`,
+				CommentEndMarker, CommentEndMarker)
+		default:
+			_, _ = fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+
+	maker := NewStatusMaker("owner/repo", "").WithSha("sha").WithProvider("github").WithClient(client)
+	err = maker.CreateComment(context.Background(), "build passed", CommentEndMarker)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /api/v3/repos/owner/repo/commits/sha/comments",
+		"PATCH /api/v3/repos/owner/repo/comments/1",
+		"DELETE /api/v3/repos/owner/repo/comments/3",
+	}, requests)
+
+	_, err = NewStatusMaker("owner/repo", "").WithSha("sha").WithProvider("gitea").WithClient(client).
+		ListComments(context.Background(), CommentEndMarker)
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ConventionalCommit is the parsed message of a conventional commit, see also https://www.conventionalcommits.org
+type ConventionalCommit struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope,omitempty"`
+	Breaking    bool   `json:"breaking,omitempty"`
+	Description string `json:"description"`
+}
+
+var conventionalCommitHeader = regexp.MustCompile(`^([a-zA-Z]+)(\(([^()]*)\))?(!)?: (.+)$`)
+
+// ParseConventionalCommit parses the message of a commit or the title of a pull request, the type is lower case
+func ParseConventionalCommit(message string) (commit ConventionalCommit, ok bool) {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	groups := conventionalCommitHeader.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if groups == nil {
+		return
+	}
+
+	ok = true
+	commit = ConventionalCommit{
+		Type:        strings.ToLower(groups[1]),
+		Scope:       groups[3],
+		Breaking:    groups[4] == "!",
+		Description: strings.TrimSpace(groups[5]),
+	}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			commit.Breaking = true
+		}
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		expect  ConventionalCommit
+		ok      bool
+	}{{
+		name:    "type only",
+		message: "feat: add changelog",
+		expect:  ConventionalCommit{Type: "feat", Description: "add changelog"},
+		ok:      true,
+	}, {
+		name:    "scope and breaking",
+		message: "Fix(cmd)!: remove the flag",
+		expect:  ConventionalCommit{Type: "fix", Scope: "cmd", Breaking: true, Description: "remove the flag"},
+		ok:      true,
+	}, {
+		name:    "breaking footer",
+		message: "refactor: rename\n\nBREAKING CHANGE: the API is renamed",
+		expect:  ConventionalCommit{Type: "refactor", Breaking: true, Description: "rename"},
+		ok:      true,
+	}, {
+		name:    "not conventional",
+		message: "Update README.md",
+	}, {
+		name:    "no description",
+		message: "feat: ",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit, ok := ParseConventionalCommit(tt.message)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expect, commit)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Version formats, the others are treated as Go templates of Description
+const (
+	VersionFormatRef      = "ref"
+	VersionFormatDescribe = "describe"
+	VersionFormatShortSha = "short-sha"
+	VersionFormatSha      = "sha"
+	VersionFormatSemver   = "semver"
+)
+
+// Description describes HEAD by the nearest tag like `git describe --tags --dirty`
+type Description struct {
+	// Ref is the checked out branch, tag or pr-N
+	Ref string
+	// Tag is the nearest tag, it's empty if there is no tag
+	Tag string
+	// Distance is the number of the commits since the nearest tag
+	Distance int
+	Sha      string
+	// Dirty is true if there are uncommitted changes, the untracked files are ignored
+	Dirty     bool
+	Timestamp time.Time
+}
+
+// ShortSha returns the abbreviated commit SHA
+func (d Description) ShortSha() string {
+	return shortSha(d.Sha)
+}
+
+// Describe returns the version like v1.4.2-13-gabc1234-dirty, it's the short SHA if there is no tag
+func (d Description) Describe() (version string) {
+	switch {
+	case d.Tag == "":
+		version = d.ShortSha()
+	case d.Distance == 0:
+		version = d.Tag
+	default:
+		version = fmt.Sprintf("%s-%d-g%s", d.Tag, d.Distance, d.ShortSha())
+	}
+	if d.Dirty {
+		version += "-dirty"
+	}
+	return
+}
+
+// Semver returns the semantic version derived from the nearest tag. It's the tag if HEAD is tagged and clean,
+// otherwise it's a development prerelease of the next patch version, such as v1.4.3-dev.13+gabc1234.dirty
+func (d Description) Semver() string {
+	version, err := ParseVersion(d.Tag)
+	if err != nil {
+		version = Version{Prefix: "v"}
+	}
+	if err == nil && d.Distance == 0 && !d.Dirty {
+		return version.String()
+	}
+
+	next := version.Bump(BumpPatch)
+	next.Prerelease = fmt.Sprintf("dev.%d", d.Distance)
+	next.Build = "g" + d.ShortSha()
+	if d.Dirty {
+		next.Build += ".dirty"
+	}
+	return next.String()
+}
+
+// FormatVersion formats the description, the format is one of ref, describe, short-sha, sha, semver, or a Go template
+func FormatVersion(format string, desc Description) (version string, err error) {
+	switch format {
+	case VersionFormatRef, "":
+		version = desc.Ref
+	case VersionFormatDescribe:
+		version = desc.Describe()
+	case VersionFormatShortSha:
+		version = desc.ShortSha()
+	case VersionFormatSha:
+		version = desc.Sha
+	case VersionFormatSemver:
+		version = desc.Semver()
+	default:
+		var tpl *template.Template
+		if tpl, err = template.New("version").Parse(format); err != nil {
+			err = fmt.Errorf("invalid version format: %v", err)
+			return
+		}
+
+		buf := new(bytes.Buffer)
+		if err = tpl.Execute(buf, desc); err == nil {
+			version = strings.TrimSpace(buf.String())
+		}
+	}
+	return
+}
+
+// DescribeHead describes HEAD of the git repository by the nearest tag
+func DescribeHead(repo *git.Repository) (desc Description, err error) {
+	return NewDescriber(repo).Describe()
+}
+
+// describeCandidates is the number of the latest tags which are compared, it's the default of `git describe --candidates`
+const describeCandidates = 10
+
+// Describer describes HEAD like `git describe --tags`. It caches the parents of the walked commits,
+// so describing again after deepening a shallow clone only loads the new commits
+type Describer struct {
+	repo  *git.Repository
+	nodes map[plumbing.Hash]commitNode
+}
+
+type commitNode struct {
+	parents []plumbing.Hash
+	when    time.Time
+}
+
+// NewDescriber creates the describer of the git repository
+func NewDescriber(repo *git.Repository) *Describer {
+	return &Describer{repo: repo, nodes: make(map[plumbing.Hash]commitNode)}
+}
+
+// Describe describes HEAD by the tag with the fewest commits since it. Like `git describe`, the latest tagged
+// ancestors are the candidates, and the distance is the number of the commits which are not reachable from the tag
+func (d *Describer) Describe() (desc Description, err error) {
+	desc.Timestamp = time.Now()
+
+	var head *plumbing.Reference
+	if head, err = d.repo.Head(); err != nil {
+		return
+	}
+	desc.Sha = head.Hash().String()
+
+	var tags map[plumbing.Hash][]string
+	if tags, err = commitTags(d.repo); err != nil {
+		return
+	}
+
+	var shallow map[plumbing.Hash]struct{}
+	if shallow, err = shallowCommits(d.repo); err != nil {
+		return
+	}
+
+	var ancestors map[plumbing.Hash]struct{}
+	if ancestors, err = d.ancestors(head.Hash(), shallow); err != nil {
+		return
+	}
+
+	var candidates []plumbing.Hash
+	for hash := range ancestors {
+		if _, ok := tags[hash]; ok {
+			candidates = append(candidates, hash)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		left, right := d.nodes[candidates[i]].when, d.nodes[candidates[j]].when
+		if left.Equal(right) {
+			return candidates[i].String() < candidates[j].String()
+		}
+		return left.After(right)
+	})
+	if len(candidates) > describeCandidates {
+		candidates = candidates[:describeCandidates]
+	}
+
+	// all the commits are counted if there is no tag
+	desc.Distance = len(ancestors)
+	for _, candidate := range candidates {
+		var reachable map[plumbing.Hash]struct{}
+		if reachable, err = d.ancestors(candidate, shallow); err != nil {
+			return
+		}
+		if distance := len(ancestors) - len(reachable); desc.Tag == "" || distance < desc.Distance {
+			desc.Tag = preferredTag(tags[candidate])
+			desc.Distance = distance
+		}
+	}
+
+	desc.Dirty, err = isDirty(d.repo)
+	return
+}
+
+// ancestors returns the commit and all its ancestors, the parents of the shallow commits are skipped
+func (d *Describer) ancestors(from plumbing.Hash, shallow map[plumbing.Hash]struct{}) (
+	seen map[plumbing.Hash]struct{}, err error) {
+	return walkAncestors(from, shallow, func(hash plumbing.Hash) (parents []plumbing.Hash, stop bool, err error) {
+		node, ok := d.nodes[hash]
+		if !ok {
+			var commit *object.Commit
+			if commit, err = d.repo.CommitObject(hash); err != nil {
+				return
+			}
+			node = commitNode{parents: commit.ParentHashes, when: commit.Committer.When}
+			d.nodes[hash] = node
+		}
+		parents = node.parents
+		return
+	})
+}
+
+// shallowCommits returns the shallow commits of the repository, their parents are missing in a shallow clone
+func shallowCommits(repo *git.Repository) (shallow map[plumbing.Hash]struct{}, err error) {
+	var shallows []plumbing.Hash
+	if shallows, err = repo.Storer.Shallow(); err != nil {
+		return
+	}
+	shallow = make(map[plumbing.Hash]struct{}, len(shallows))
+	for _, hash := range shallows {
+		shallow[hash] = struct{}{}
+	}
+	return
+}
+
+// walkAncestors visits the commit and its ancestors in pre-order, the first parent is visited first.
+// The visit returns the parents of the commit, or stops the walk. The parents of the shallow commits are skipped
+func walkAncestors(from plumbing.Hash, shallow map[plumbing.Hash]struct{},
+	visit func(plumbing.Hash) ([]plumbing.Hash, bool, error)) (seen map[plumbing.Hash]struct{}, err error) {
+	seen = make(map[plumbing.Hash]struct{})
+	stack := []plumbing.Hash{from}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+
+		var parents []plumbing.Hash
+		var stop bool
+		if parents, stop, err = visit(hash); err != nil || stop {
+			return
+		}
+		if _, ok := shallow[hash]; ok {
+			continue
+		}
+		for i := len(parents) - 1; i >= 0; i-- {
+			stack = append(stack, parents[i])
+		}
+	}
+	return
+}
+
+// commitTags maps the commits to their tag names, the annotated tags are peeled
+func commitTags(repo *git.Repository) (tags map[plumbing.Hash][]string, err error) {
+	var iter storer.ReferenceIter
+	if iter, err = repo.Tags(); err != nil {
+		return
+	}
+
+	tags = make(map[plumbing.Hash][]string)
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, tagErr := repo.TagObject(hash); tagErr == nil {
+			if commit, commitErr := tag.Commit(); commitErr == nil {
+				hash = commit.Hash
+			}
+		}
+		tags[hash] = append(tags[hash], ref.Name().Short())
+		return nil
+	})
+	return
+}
+
+// preferredTag returns the highest semantic version, or the last one in the alphabetical order
+func preferredTag(names []string) (preferred string) {
+	sort.Strings(names)
+	var version *Version
+	for _, name := range names {
+		if current, err := ParseVersion(name); err == nil && (version == nil || current.Compare(*version) > 0) {
+			version = &current
+			preferred = name
+		}
+	}
+	if preferred == "" {
+		preferred = names[len(names)-1]
+	}
+	return
+}
+
+// walkCommitsUntil walks the history in pre-order until the callback returns true.
+// The parents of the shallow commits are skipped, they are missing in a shallow clone
+func walkCommitsUntil(repo *git.Repository, from plumbing.Hash, callback func(*object.Commit) bool) (err error) {
+	var shallow map[plumbing.Hash]struct{}
+	if shallow, err = shallowCommits(repo); err != nil {
+		return
+	}
+	_, err = walkAncestors(from, shallow, func(hash plumbing.Hash) (parents []plumbing.Hash, stop bool, err error) {
+		var commit *object.Commit
+		if commit, err = repo.CommitObject(hash); err == nil {
+			parents, stop = commit.ParentHashes, callback(commit)
+		}
+		return
+	})
+	return
+}
+
+// isDirty returns true if there are modified files, the untracked files are ignored
+func isDirty(repo *git.Repository) (dirty bool, err error) {
+	var worktree *git.Worktree
+	if worktree, err = repo.Worktree(); err != nil {
+		return
+	}
+
+	var status git.Status
+	if status, err = worktree.Status(); err == nil {
+		for _, file := range status {
+			if file.Worktree == git.Untracked {
+				continue
+			}
+			if file.Worktree != git.Unmodified || file.Staging != git.Unmodified {
+				dirty = true
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeHead(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	readme := filepath.Join(dir, "README.md")
+	assert.NoError(t, os.WriteFile(readme, []byte("gogit"), 0644))
+	_, err = worktree.Add("README.md")
+	assert.NoError(t, err)
+	first := commitAll(t, repo, "init")
+
+	desc, err := DescribeHead(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, first.String(), desc.Sha)
+	assert.Equal(t, first.String()[:7], desc.Describe())
+	assert.Equal(t, "v0.0.1-dev.1+g"+first.String()[:7], desc.Semver())
+
+	_, err = repo.CreateTag("v1.4.2", first, &git.CreateTagOptions{
+		Message: "v1.4.2",
+		Tagger:  &object.Signature{Name: "Rick", When: time.Now()},
+	})
+	assert.NoError(t, err)
+	_, err = repo.CreateTag("stable", first, nil)
+	assert.NoError(t, err)
+
+	desc, err = DescribeHead(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.2", desc.Describe())
+	assert.Equal(t, "v1.4.2", desc.Semver())
+
+	commitAll(t, repo, "fix: one")
+	head := commitAll(t, repo, "fix: two")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("ignored"), 0644))
+
+	desc, err = DescribeHead(repo)
+	assert.NoError(t, err)
+	assert.False(t, desc.Dirty)
+	assert.Equal(t, "v1.4.2-2-g"+head.String()[:7], desc.Describe())
+
+	assert.NoError(t, os.WriteFile(readme, []byte("changed"), 0644))
+	desc, err = DescribeHead(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.2-2-g"+head.String()[:7]+"-dirty", desc.Describe())
+	assert.Equal(t, "v1.4.3-dev.2+g"+head.String()[:7]+".dirty", desc.Semver())
+}
+
+func TestDescribeMergedTag(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	first := commitAll(t, repo, "init")
+	_, err = repo.CreateTag("v1.0.0", first, nil)
+	assert.NoError(t, err)
+	commitAll(t, repo, "fix: one")
+	base := commitAll(t, repo, "fix: two")
+
+	// the nearer tag is on the merged branch, the first parent leads to the farther one
+	branch := commitAll(t, repo, "feat: branch")
+	_, err = repo.CreateTag("v1.1.0", branch, nil)
+	assert.NoError(t, err)
+	merge, err := worktree.Commit("Merge branch", &git.CommitOptions{
+		Author:  &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+		Parents: []plumbing.Hash{base, branch},
+	})
+	assert.NoError(t, err)
+
+	describer := NewDescriber(repo)
+	desc, err := describer.Describe()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0", desc.Tag)
+	assert.Equal(t, 1, desc.Distance)
+	assert.Equal(t, "v1.1.0-1-g"+merge.String()[:7], desc.Describe())
+
+	// the cached history is reused
+	head := commitAll(t, repo, "fix: three")
+	desc, err = describer.Describe()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0-2-g"+head.String()[:7], desc.Describe())
+}
+
+func TestFormatVersion(t *testing.T) {
+	desc := Description{
+		Ref:       "master",
+		Tag:       "v1.4.2",
+		Distance:  13,
+		Sha:       "abc1234567890",
+		Timestamp: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	tests := []struct {
+		format string
+		expect string
+	}{
+		{"", "master"},
+		{VersionFormatRef, "master"},
+		{VersionFormatDescribe, "v1.4.2-13-gabc1234"},
+		{VersionFormatShortSha, "abc1234"},
+		{VersionFormatSha, "abc1234567890"},
+		{VersionFormatSemver, "v1.4.3-dev.13+gabc1234"},
+		{`{{.Ref}}-{{.ShortSha}}-{{.Timestamp.Format "20060102"}}`, "master-abc1234-20230102"},
+	}
+	for _, tt := range tests {
+		version, err := FormatVersion(tt.format, desc)
+		assert.NoError(t, err, tt.format)
+		assert.Equal(t, tt.expect, version, tt.format)
+	}
+
+	_, err := FormatVersion("{{.Ref", desc)
+	assert.Error(t, err)
+	_, err = FormatVersion("{{.Fake}}", desc)
+	assert.Error(t, err)
+}
@@ -0,0 +1,296 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// DigestItem is an open pull request which needs attention
+type DigestItem struct {
+	Repo        string           `json:"repo"`
+	PullRequest *scm.PullRequest `json:"pullRequest"`
+	// Stale is true if there is no review after the stale duration
+	Stale bool `json:"stale"`
+	// FailedStatuses are the labels of the failed statuses
+	FailedStatuses []string `json:"failedStatuses,omitempty"`
+	// Hours is the age of the pull request
+	Hours int `json:"hours"`
+}
+
+// Recipients returns the reviewers and assignees, the author is included if there are failed statuses
+func (i DigestItem) Recipients() (logins []string) {
+	users := append(append([]scm.User{}, i.PullRequest.Reviewers...), i.PullRequest.Assignees...)
+	if len(i.FailedStatuses) > 0 {
+		users = append(users, i.PullRequest.Author)
+	}
+
+	found := make(map[string]struct{}, len(users))
+	for _, user := range users {
+		if _, ok := found[user.Login]; ok || user.Login == "" {
+			continue
+		}
+		found[user.Login] = struct{}{}
+		logins = append(logins, user.Login)
+	}
+	return
+}
+
+// RepoDigest is the summary of the open pull requests of a repository
+type RepoDigest struct {
+	Repo string `json:"repo"`
+	// Open is the number of all the open pull requests
+	Open  int          `json:"open"`
+	Items []DigestItem `json:"items"`
+}
+
+// UserDigest is the personalized digest of a user
+type UserDigest struct {
+	Login string       `json:"login"`
+	Items []DigestItem `json:"items"`
+}
+
+// CollectDigest gathers the open pull requests of the repositories, which have no review after the stale duration
+// or have failed statuses. The repositories without an owner belong to the owner of the maker
+func (s *StatusMaker) CollectDigest(ctx context.Context, repos []string, staleAfter time.Duration, now time.Time) (digests []RepoDigest, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	for _, repo := range repos {
+		if !strings.Contains(repo, "/") {
+			repo = strings.SplitN(s.repo, "/", 2)[0] + "/" + repo
+		}
+		maker := *s
+		maker.repo = repo
+
+		var digest RepoDigest
+		if digest, err = maker.collectRepoDigest(ctx, scmClient, staleAfter, now); err != nil {
+			return
+		}
+		digests = append(digests, digest)
+	}
+	return
+}
+
+func (s *StatusMaker) collectRepoDigest(ctx context.Context, scmClient *scm.Client, staleAfter time.Duration, now time.Time) (digest RepoDigest, err error) {
+	digest.Repo = s.repo
+
+	var prs []*scm.PullRequest
+	if prs, err = listAllPullRequests(ctx, scmClient, s.repo, &scm.PullRequestListOptions{Open: true}); err != nil {
+		return
+	}
+
+	for _, pr := range prs {
+		if pr.Closed || pr.Merged {
+			continue
+		}
+		digest.Open++
+		if pr.Draft {
+			continue
+		}
+
+		item := DigestItem{Repo: s.repo, PullRequest: pr, Hours: int(now.Sub(pr.Created).Hours())}
+		if now.Sub(pr.Created) > staleAfter {
+			var reviewed bool
+			if reviewed, err = s.reviewed(ctx, scmClient, pr); err != nil {
+				return
+			}
+			item.Stale = !reviewed
+		}
+		if item.FailedStatuses, err = s.failedStatuses(ctx, scmClient, pr.Sha); err != nil {
+			return
+		}
+
+		if item.Stale || len(item.FailedStatuses) > 0 {
+			digest.Items = append(digest.Items, item)
+		}
+	}
+	return
+}
+
+// reviewed returns true if anyone except the author reviewed the pull request
+func (s *StatusMaker) reviewed(ctx context.Context, scmClient *scm.Client, pr *scm.PullRequest) (ok bool, err error) {
+	if s.provider == "gitlab" {
+		var approvers []string
+		approvers, err = listGitLabApprovers(ctx, scmClient, s.repo, pr.Number)
+		ok = len(approvers) > 0
+		return
+	}
+
+	var reviews []*scm.Review
+	if reviews, err = listAllReviews(ctx, scmClient, s.repo, pr.Number); err == nil {
+		for _, review := range reviews {
+			if review.Author.Login != pr.Author.Login {
+				ok = true
+				break
+			}
+		}
+	}
+	return
+}
+
+// failedStatuses returns the labels whose latest status is failed
+func (s *StatusMaker) failedStatuses(ctx context.Context, scmClient *scm.Client, sha string) (labels []string, err error) {
+	var statuses []*scm.Status
+	if statuses, _, err = scmClient.Repositories.ListStatus(ctx, s.repo, sha, &scm.ListOptions{
+		Page: 1,
+		Size: 100, // assume this list has not too many items
+	}); err != nil {
+		err = fmt.Errorf("failed to list the statuses of %q: %v", sha, err)
+		return
+	}
+
+	found := make(map[string]struct{}, len(statuses))
+	for _, status := range statuses {
+		if _, ok := found[status.Label]; ok {
+			continue
+		}
+		found[status.Label] = struct{}{}
+		if isFailedState(status.State) {
+			labels = append(labels, status.Label)
+		}
+	}
+	return
+}
+
+// UserDigests groups the items by the recipients, the users are sorted by login
+func UserDigests(digests []RepoDigest) (users []UserDigest) {
+	index := make(map[string]int)
+	for _, digest := range digests {
+		for _, item := range digest.Items {
+			for _, login := range item.Recipients() {
+				i, ok := index[login]
+				if !ok {
+					i = len(users)
+					index[login] = i
+					users = append(users, UserDigest{Login: login})
+				}
+				users[i].Items = append(users[i].Items, item)
+			}
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Login < users[j].Login
+	})
+	return
+}
+
+// DefaultUserDigestTemplate is the default template of the personalized digest, the data is UserDigest
+const DefaultUserDigestTemplate = `{{len .Items}} pull request(s) need your attention:
+{{range .Items}}- {{.Repo}}#{{.PullRequest.Number}} {{.PullRequest.Title}}{{if .Stale}}, no review for {{.Hours}}h{{end}}{{range .FailedStatuses}}, {{.}} failed{{end}}
+  {{.PullRequest.Link}}
+{{end}}`
+
+// DefaultRepoDigestTemplate is the default template of the repository summary, the data is RepoDigest
+const DefaultRepoDigestTemplate = `{{.Repo}} has {{.Open}} open pull request(s), {{len .Items}} of them need attention:
+{{range .Items}}- #{{.PullRequest.Number}} {{.PullRequest.Title}} by {{.PullRequest.Author.Login}}{{if .Stale}}, no review for {{.Hours}}h{{end}}{{range .FailedStatuses}}, {{.}} failed{{end}}
+{{end}}`
+
+// DigestSender sends the digests through the notification path
+type DigestSender struct {
+	// Resolver resolves the notifiers of each user
+	Resolver *NotifyResolver
+	// Team are the notifiers which receive the summaries of the repositories
+	Team map[string]Notifier
+
+	user *template.Template
+	repo *template.Template
+}
+
+// NewDigestSender creates a digest sender, the default templates are used if they are empty
+func NewDigestSender(resolver *NotifyResolver, team map[string]Notifier, userTemplate, repoTemplate string) (sender *DigestSender, err error) {
+	sender = &DigestSender{Resolver: resolver, Team: team}
+	if sender.user, err = template.New("user").Parse(emptyThen(userTemplate, DefaultUserDigestTemplate)); err != nil {
+		err = fmt.Errorf("invalid user digest template: %v", err)
+		return
+	}
+	if sender.repo, err = template.New("repo").Parse(emptyThen(repoTemplate, DefaultRepoDigestTemplate)); err != nil {
+		err = fmt.Errorf("invalid repo digest template: %v", err)
+	}
+	return
+}
+
+// Send sends the personalized digests to the users, and the summaries to the team notifiers, the failures are joined
+func (d *DigestSender) Send(ctx context.Context, digests []RepoDigest, now time.Time, w io.Writer) (err error) {
+	for _, user := range UserDigests(digests) {
+		err = errors.Join(err, d.sendUserDigest(ctx, user, now, w))
+	}
+
+	if len(d.Team) == 0 {
+		return
+	}
+	for _, digest := range digests {
+		if len(digest.Items) == 0 {
+			continue
+		}
+
+		text, renderErr := renderDigest(d.repo, digest)
+		if renderErr != nil {
+			err = errors.Join(err, renderErr)
+			return
+		}
+		err = errors.Join(err, SendNotifications(ctx, d.Team, Message{
+			Title: fmt.Sprintf("Open pull requests of %s", digest.Repo),
+			Text:  text,
+		}, w))
+	}
+	return
+}
+
+func (d *DigestSender) sendUserDigest(ctx context.Context, user UserDigest, now time.Time, w io.Writer) (err error) {
+	// skip the repositories which are muted by the user
+	var items []DigestItem
+	for _, item := range user.Items {
+		if directoryUser := d.Resolver.Directory.Find(d.Resolver.Provider, user.Login); directoryUser != nil {
+			if directoryUser.Muted(item.Repo, now) {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return
+	}
+	user.Items = items
+
+	// the group channels are not used, they receive the summaries instead
+	resolver := &NotifyResolver{Provider: d.Resolver.Provider, Repo: items[0].Repo, Directory: d.Resolver.Directory}
+	if config := d.Resolver.Config; config != nil {
+		resolver.Config = &NotifyConfig{Channels: config.Channels, Users: config.Users}
+	}
+
+	var notifiers map[string]Notifier
+	if _, notifiers, err = resolver.Resolve([]string{user.Login}, now); err != nil || len(notifiers) == 0 {
+		return
+	}
+
+	var text string
+	if text, err = renderDigest(d.user, user); err == nil {
+		err = SendNotifications(ctx, notifiers, Message{
+			Title:      fmt.Sprintf("Pull requests digest of %s", user.Login),
+			Text:       text,
+			Recipients: []string{user.Login},
+		}, w)
+	}
+	return
+}
+
+func renderDigest(tpl *template.Template, data any) (text string, err error) {
+	buf := new(bytes.Buffer)
+	if err = tpl.Execute(buf, data); err != nil {
+		err = fmt.Errorf("cannot render the digest: %v", err)
+		return
+	}
+	text = buf.String()
+	return
+}
@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectDigest(t *testing.T) {
+	now := time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC)
+	base := scm.PullRequestBranch{Repo: scm.Repository{FullName: "linuxsuren/gogit"}}
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{
+		Number:    1,
+		Title:     "stale",
+		Base:      base,
+		Author:    scm.User{Login: "linuxsuren"},
+		Reviewers: []scm.User{{Login: "bob"}},
+		Created:   now.Add(-50 * time.Hour),
+	}
+	data.PullRequests[2] = &scm.PullRequest{
+		Number:    2,
+		Title:     "reviewed but failed",
+		Base:      base,
+		Sha:       "sha",
+		Author:    scm.User{Login: "linuxsuren"},
+		Assignees: []scm.User{{Login: "alice"}},
+		Created:   now.Add(-50 * time.Hour),
+	}
+	data.PullRequests[3] = &scm.PullRequest{
+		Number:  3,
+		Title:   "fresh",
+		Base:    base,
+		Author:  scm.User{Login: "alice"},
+		Created: now.Add(-time.Hour),
+	}
+	data.PullRequests[4] = &scm.PullRequest{
+		Number:  4,
+		Title:   "draft",
+		Base:    base,
+		Draft:   true,
+		Created: now.Add(-50 * time.Hour),
+	}
+	data.Reviews[2] = []*scm.Review{{Author: scm.User{Login: "alice"}}}
+	data.Statuses["sha"] = []*scm.Status{
+		{Label: "build", State: scm.StateFailure},
+		{Label: "test", State: scm.StateSuccess},
+		{Label: "build", State: scm.StateSuccess},
+	}
+
+	maker := NewStatusMaker("linuxsuren/gogit", "").WithClient(client)
+	digests, err := maker.CollectDigest(context.Background(), []string{"gogit"}, 24*time.Hour, now)
+	assert.NoError(t, err)
+	if assert.Len(t, digests, 1) {
+		digest := digests[0]
+		assert.Equal(t, "linuxsuren/gogit", digest.Repo)
+		assert.Equal(t, 4, digest.Open)
+		if assert.Len(t, digest.Items, 2) {
+			assert.True(t, digest.Items[0].Stale)
+			assert.Equal(t, 50, digest.Items[0].Hours)
+			assert.False(t, digest.Items[1].Stale)
+			assert.Equal(t, []string{"build"}, digest.Items[1].FailedStatuses)
+			assert.Equal(t, []string{"alice", "linuxsuren"}, digest.Items[1].Recipients())
+		}
+	}
+
+	users := UserDigests(digests)
+	if assert.Len(t, users, 3) {
+		assert.Equal(t, "alice", users[0].Login)
+		assert.Equal(t, "bob", users[1].Login)
+		assert.Equal(t, 1, users[1].Items[0].PullRequest.Number)
+	}
+}
+
+func TestDigestSender(t *testing.T) {
+	digests := []RepoDigest{{
+		Repo: "linuxsuren/gogit",
+		Open: 2,
+		Items: []DigestItem{{
+			Repo: "linuxsuren/gogit",
+			PullRequest: &scm.PullRequest{
+				Number:    1,
+				Title:     "fix",
+				Author:    scm.User{Login: "linuxsuren"},
+				Reviewers: []scm.User{{Login: "bob"}, {Login: "alice"}},
+			},
+			Stale: true,
+			Hours: 30,
+		}},
+	}, {
+		Repo: "linuxsuren/api-testing",
+	}}
+
+	var received []Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		received = append(received, msg)
+	}))
+	defer server.Close()
+
+	directory, err := ParseUserDirectory([]byte(sampleUserDirectory))
+	assert.NoError(t, err)
+	team := &fakeNotifier{}
+	sender, err := NewDigestSender(&NotifyResolver{Provider: "github", Directory: directory, Config: &NotifyConfig{
+		Channels: []NotifyChannel{{Name: "hook", Kind: NotifierWebhook, Webhook: server.URL}},
+		Users:    map[string][]string{"alice": {"hook"}, "bob": {"hook"}},
+	}}, map[string]Notifier{"team": team}, "", "")
+	assert.NoError(t, err)
+
+	// bob muted the repository, alice is not in the quiet hours
+	err = sender.Send(context.Background(), digests, time.Date(2023, 1, 1, 4, 0, 0, 0, time.UTC), new(bytes.Buffer))
+	assert.NoError(t, err)
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "Pull requests digest of alice", received[0].Title)
+		assert.Equal(t, []string{"alice"}, received[0].Recipients)
+	}
+	if assert.Len(t, team.messages, 1) {
+		assert.Equal(t, "Open pull requests of linuxsuren/gogit", team.messages[0].Title)
+		assert.Equal(t, "linuxsuren/gogit has 2 open pull request(s), 1 of them need attention:\n"+
+			"- #1 fix by linuxsuren, no review for 30h\n", team.messages[0].Text)
+	}
+
+	text, err := renderDigest(sender.user, UserDigests(digests)[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "1 pull request(s) need your attention:\n- linuxsuren/gogit#1 fix, no review for 30h\n  \n", text)
+
+	_, err = NewDigestSender(&NotifyResolver{}, nil, "{{.Items", "")
+	assert.Error(t, err)
+	_, err = NewDigestSender(&NotifyResolver{}, nil, "", "{{.Items")
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dingdingWebhook = "https://oapi.dingtalk.com/robot/send?access_token="
+
+// DingDing message types
+const (
+	DingDingText       = "text"
+	DingDingMarkdown   = "markdown"
+	DingDingActionCard = "actionCard"
+	DingDingLink       = "link"
+)
+
+// DingDingMessage is the payload of the DingDing robot
+//
+// See also https://open.dingtalk.com/document/orgapp/custom-robot-access
+type DingDingMessage struct {
+	MsgType    string                  `json:"msgtype"`
+	Text       *DingDingTextContent    `json:"text,omitempty"`
+	Markdown   *DingDingMarkdownText   `json:"markdown,omitempty"`
+	ActionCard *DingDingActionCardText `json:"actionCard,omitempty"`
+	Link       *DingDingLinkText       `json:"link,omitempty"`
+	At         *DingDingAt             `json:"at,omitempty"`
+}
+
+// DingDingTextContent is the content of the text message
+type DingDingTextContent struct {
+	Content string `json:"content"`
+}
+
+// DingDingMarkdownText is the content of the markdown message
+type DingDingMarkdownText struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// DingDingActionCardText is the content of the actionCard message with a single button
+type DingDingActionCardText struct {
+	Title       string `json:"title"`
+	Text        string `json:"text"`
+	SingleTitle string `json:"singleTitle"`
+	SingleURL   string `json:"singleURL"`
+}
+
+// DingDingLinkText is the content of the link message
+type DingDingLinkText struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	MessageURL string `json:"messageUrl"`
+	PicURL     string `json:"picUrl,omitempty"`
+}
+
+// DingDingAt mentions the users by mobile number or user ID
+type DingDingAt struct {
+	AtMobiles []string `json:"atMobiles,omitempty"`
+	AtUserIds []string `json:"atUserIds,omitempty"`
+	IsAtAll   bool     `json:"isAtAll,omitempty"`
+}
+
+// DingDingResponse is the response of the DingDing robot
+type DingDingResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// DingDingClient sends messages to a DingDing robot, the requests will be signed if the secret is not empty
+type DingDingClient struct {
+	Webhook string
+	Secret  string
+
+	now func() time.Time
+}
+
+// NewDingDingClient creates a DingDing client with the access token and the optional signing secret
+func NewDingDingClient(token, secret string) *DingDingClient {
+	return &DingDingClient{Webhook: dingdingWebhook + token, Secret: secret}
+}
+
+// Send sends the message to the robot
+func (c *DingDingClient) Send(ctx context.Context, msg DingDingMessage) (err error) {
+	var api string
+	if api, err = c.signedURL(); err != nil {
+		return
+	}
+
+	resp := &DingDingResponse{}
+	if err = postJSON(ctx, api, msg, resp); err == nil && resp.ErrCode != 0 {
+		err = fmt.Errorf("receive error response from dingding: %d %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return
+}
+
+// signedURL appends the timestamp and sign to the webhook
+//
+// See also https://open.dingtalk.com/document/robots/customize-robot-security-settings
+func (c *DingDingClient) signedURL() (api string, err error) {
+	api = c.Webhook
+	if c.Secret == "" {
+		return
+	}
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	timestamp := strconv.FormatInt(now().UnixMilli(), 10)
+
+	var webhook *url.URL
+	if webhook, err = url.Parse(c.Webhook); err != nil {
+		err = fmt.Errorf("invalid dingding webhook: %v", err)
+		return
+	}
+	query := webhook.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", DingDingSign(timestamp, c.Secret))
+	webhook.RawQuery = query.Encode()
+	api = webhook.String()
+	return
+}
+
+// DingDingSign computes the sign of the timestamp with the secret
+func DingDingSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DingDingNotifier sends the message to a DingDing robot
+type DingDingNotifier struct {
+	Client *DingDingClient
+	// MsgType is one of text, markdown, actionCard and link, the default is text
+	MsgType string
+	// Mobiles and UserIDs map the git logins to the DingDing users to mention
+	Mobiles map[string]string
+	UserIDs map[string]string
+}
+
+// Notify sends the message
+func (n *DingDingNotifier) Notify(ctx context.Context, msg Message) (err error) {
+	var payload DingDingMessage
+	if payload, err = n.newMessage(msg); err == nil {
+		err = n.Client.Send(ctx, payload)
+	}
+	return
+}
+
+func (n *DingDingNotifier) newMessage(msg Message) (payload DingDingMessage, err error) {
+	at := &DingDingAt{}
+	for _, login := range msg.Recipients {
+		if mobile, ok := n.Mobiles[login]; ok {
+			at.AtMobiles = append(at.AtMobiles, mobile)
+		}
+		if userID, ok := n.UserIDs[login]; ok {
+			at.AtUserIds = append(at.AtUserIds, userID)
+		}
+	}
+	sort.Strings(at.AtMobiles)
+	sort.Strings(at.AtUserIds)
+
+	// the users will be highlighted only when they are mentioned in the text
+	var mentions []string
+	for _, mobile := range at.AtMobiles {
+		mentions = append(mentions, "@"+mobile)
+	}
+	for _, userID := range at.AtUserIds {
+		mentions = append(mentions, "@"+userID)
+	}
+	text := msg.Text
+	if len(mentions) > 0 {
+		text = strings.TrimRight(text, "\n") + "\n\n" + strings.Join(mentions, " ")
+		payload.At = at
+	}
+
+	title := msg.Title
+	if title == "" {
+		title = msg.Text
+	}
+
+	payload.MsgType = n.MsgType
+	switch n.MsgType {
+	case "", DingDingText:
+		payload.MsgType = DingDingText
+		payload.Text = &DingDingTextContent{Content: text}
+	case DingDingMarkdown:
+		payload.Markdown = &DingDingMarkdownText{Title: title, Text: text}
+	case DingDingActionCard:
+		payload.ActionCard = &DingDingActionCardText{Title: title, Text: text, SingleTitle: "View", SingleURL: msg.Link}
+	case DingDingLink:
+		payload.Link = &DingDingLinkText{Title: title, Text: msg.Text, MessageURL: msg.Link}
+	default:
+		err = fmt.Errorf("unsupported dingding message type %q", n.MsgType)
+	}
+	return
+}
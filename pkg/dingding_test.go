@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDingDingSign(t *testing.T) {
+	assert.Equal(t, "lkcPI1uoxBY1gUnCnnPH1Kkru0Hqjo7rFpA3haIVhEQ=", DingDingSign("1700000000000", "SEC123"))
+}
+
+func TestDingDingClient(t *testing.T) {
+	var query url.Values
+	var payload DingDingMessage
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		data, _ := io.ReadAll(r.Body)
+		payload = DingDingMessage{}
+		_ = json.Unmarshal(data, &payload)
+		_, _ = fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+
+	client := &DingDingClient{
+		Webhook: server.URL + "/robot/send?access_token=token",
+		Secret:  "SEC123",
+		now: func() time.Time {
+			return time.UnixMilli(1700000000000)
+		},
+	}
+
+	response = `{"errcode": 0, "errmsg": "ok"}`
+	msg := DingDingMessage{MsgType: DingDingText, Text: &DingDingTextContent{Content: "say \"hi\"\nbye"}}
+	assert.NoError(t, client.Send(context.Background(), msg))
+	assert.Equal(t, "token", query.Get("access_token"))
+	assert.Equal(t, "1700000000000", query.Get("timestamp"))
+	assert.Equal(t, "lkcPI1uoxBY1gUnCnnPH1Kkru0Hqjo7rFpA3haIVhEQ=", query.Get("sign"))
+	assert.Equal(t, msg, payload)
+
+	response = `{"errcode": 310000, "errmsg": "sign not match"}`
+	err := client.Send(context.Background(), msg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "sign not match")
+
+	client.Secret = ""
+	response = `{"errcode": 0}`
+	assert.NoError(t, client.Send(context.Background(), msg))
+	assert.Empty(t, query.Get("sign"))
+}
+
+func TestDingDingNotifierMessage(t *testing.T) {
+	msg := Message{
+		Title:      "feat: support dingding",
+		Text:       "workflow done\n",
+		Link:       "https://github.com/linuxsuren/gogit/pull/1",
+		Recipients: []string{"rick", "bob", "alice"},
+	}
+	mobiles := map[string]string{"rick": "13800000000"}
+	userIDs := map[string]string{"bob": "user-bob"}
+	at := &DingDingAt{AtMobiles: []string{"13800000000"}, AtUserIds: []string{"user-bob"}}
+
+	tests := []struct {
+		name     string
+		notifier *DingDingNotifier
+		expect   DingDingMessage
+		hasErr   bool
+	}{{
+		name:     "text without mentions",
+		notifier: &DingDingNotifier{},
+		expect: DingDingMessage{
+			MsgType: DingDingText,
+			Text:    &DingDingTextContent{Content: "workflow done\n"},
+		},
+	}, {
+		name:     "text with mentions",
+		notifier: &DingDingNotifier{MsgType: DingDingText, Mobiles: mobiles, UserIDs: userIDs},
+		expect: DingDingMessage{
+			MsgType: DingDingText,
+			Text:    &DingDingTextContent{Content: "workflow done\n\n@13800000000 @user-bob"},
+			At:      at,
+		},
+	}, {
+		name:     "markdown",
+		notifier: &DingDingNotifier{MsgType: DingDingMarkdown, Mobiles: mobiles},
+		expect: DingDingMessage{
+			MsgType:  DingDingMarkdown,
+			Markdown: &DingDingMarkdownText{Title: msg.Title, Text: "workflow done\n\n@13800000000"},
+			At:       &DingDingAt{AtMobiles: []string{"13800000000"}},
+		},
+	}, {
+		name:     "actionCard",
+		notifier: &DingDingNotifier{MsgType: DingDingActionCard},
+		expect: DingDingMessage{
+			MsgType: DingDingActionCard,
+			ActionCard: &DingDingActionCardText{Title: msg.Title, Text: msg.Text,
+				SingleTitle: "View", SingleURL: msg.Link},
+		},
+	}, {
+		name:     "link",
+		notifier: &DingDingNotifier{MsgType: DingDingLink},
+		expect: DingDingMessage{
+			MsgType: DingDingLink,
+			Link:    &DingDingLinkText{Title: msg.Title, Text: msg.Text, MessageURL: msg.Link},
+		},
+	}, {
+		name:     "unsupported type",
+		notifier: &DingDingNotifier{MsgType: "feedCard"},
+		hasErr:   true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := tt.notifier.newMessage(msg)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	htmltemplate "html/template"
+)
+
+// SMTP security modes
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+	SMTPNone     = "none"
+)
+
+// SMTPConfig is the config of the email notifier
+type SMTPConfig struct {
+	Host     string `yaml:"host" json:"host"`
+	Port     int    `yaml:"port" json:"port"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+	From     string `yaml:"from" json:"from"`
+	// Security is one of starttls, tls and none, the default is starttls
+	Security   string `yaml:"security" json:"security"`
+	SkipVerify bool   `yaml:"skipVerify" json:"skipVerify"`
+	// AllowInsecureAuth sends the password over an unencrypted connection to a remote server, it's only for
+	// the security none. Otherwise, the password is only sent over TLS, or to localhost
+	AllowInsecureAuth bool `yaml:"allowInsecureAuth" json:"allowInsecureAuth"`
+
+	// Subject, Text and HTML are the Go templates of the Message, the HTML part is optional
+	Subject string `yaml:"subject" json:"subject"`
+	Text    string `yaml:"text" json:"text"`
+	HTML    string `yaml:"html" json:"html"`
+	// To are the addresses which always receive the emails
+	To []string `yaml:"to" json:"to"`
+	// Addresses maps the git logins to the emails, the emails of the pull request users are used as fallback
+	Addresses map[string]string `yaml:"addresses" json:"addresses"`
+}
+
+const (
+	defaultEmailSubject = "{{.Title}}"
+	defaultEmailText    = "{{.Text}}\n\n{{.Link}}"
+)
+
+// EmailNotifier sends the message over SMTP, the recipients are the emails of the involved users
+type EmailNotifier struct {
+	Config SMTPConfig
+
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// NewEmailNotifier creates an email notifier, the templates will be parsed
+func NewEmailNotifier(config SMTPConfig) (notifier *EmailNotifier, err error) {
+	switch config.Security {
+	case "":
+		config.Security = SMTPStartTLS
+	case SMTPStartTLS, SMTPTLS, SMTPNone:
+	default:
+		err = fmt.Errorf("unsupported SMTP security %q", config.Security)
+		return
+	}
+	if config.Host == "" || config.From == "" {
+		err = errors.New("the host and from address of SMTP are required")
+		return
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.Security == SMTPTLS {
+			config.Port = 465
+		}
+	}
+
+	notifier = &EmailNotifier{Config: config}
+	if notifier.subject, err = template.New("subject").Parse(emptyThen(config.Subject, defaultEmailSubject)); err != nil {
+		err = fmt.Errorf("invalid email subject template: %v", err)
+		return
+	}
+	if notifier.text, err = template.New("text").Parse(emptyThen(config.Text, defaultEmailText)); err != nil {
+		err = fmt.Errorf("invalid email text template: %v", err)
+		return
+	}
+	if config.HTML != "" {
+		if notifier.html, err = htmltemplate.New("html").Parse(config.HTML); err != nil {
+			err = fmt.Errorf("invalid email html template: %v", err)
+		}
+	}
+	return
+}
+
+// Notify sends the email
+func (n *EmailNotifier) Notify(ctx context.Context, msg Message) (err error) {
+	to := n.recipients(msg)
+	if len(to) == 0 {
+		return
+	}
+
+	var data []byte
+	if data, err = n.newMail(msg, to); err != nil {
+		return
+	}
+	err = n.send(ctx, to, data)
+	return
+}
+
+func (n *EmailNotifier) recipients(msg Message) (to []string) {
+	emails := make(map[string]string)
+	if msg.PullRequest != nil {
+		for _, user := range pullRequestUsers(msg.PullRequest) {
+			if user.Email != "" {
+				emails[user.Login] = user.Email
+			}
+		}
+	}
+	for login, email := range n.Config.Addresses {
+		emails[login] = email
+	}
+
+	found := make(map[string]struct{})
+	for _, address := range n.Config.To {
+		found[address] = struct{}{}
+	}
+	for _, login := range msg.Recipients {
+		if email, ok := emails[login]; ok {
+			found[email] = struct{}{}
+		}
+	}
+
+	for address := range found {
+		to = append(to, address)
+	}
+	sort.Strings(to)
+	return
+}
+
+// newMail builds the MIME message with the plain text part, and the optional HTML part
+func (n *EmailNotifier) newMail(msg Message, to []string) (data []byte, err error) {
+	var subject, text, html bytes.Buffer
+	if err = n.subject.Execute(&subject, msg); err != nil {
+		err = fmt.Errorf("cannot render the email subject: %v", err)
+		return
+	}
+	if err = n.text.Execute(&text, msg); err != nil {
+		err = fmt.Errorf("cannot render the email text: %v", err)
+		return
+	}
+	if n.html != nil {
+		if err = n.html.Execute(&html, msg); err != nil {
+			err = fmt.Errorf("cannot render the email html: %v", err)
+			return
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "From: %s\r\n", n.Config.From)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(to, ", "))
+	// the line breaks, such as the ones in the pull request title, cannot inject any header
+	fmt.Fprintf(buf, "Subject: %s\r\n", mimeEncodeHeader(headerLineBreaks.Replace(strings.TrimSpace(subject.String()))))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if n.html == nil {
+		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		err = writeQuotedPrintable(buf, text.Bytes())
+	} else {
+		writer := multipart.NewWriter(buf)
+		fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+		for _, part := range []struct {
+			contentType string
+			body        []byte
+		}{{"text/plain; charset=UTF-8", text.Bytes()}, {"text/html; charset=UTF-8", html.Bytes()}} {
+			var partWriter io.Writer
+			if partWriter, err = writer.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			}); err != nil {
+				return
+			}
+			qp := quotedprintable.NewWriter(partWriter)
+			if _, err = qp.Write(part.body); err != nil {
+				return
+			}
+			if err = qp.Close(); err != nil {
+				return
+			}
+		}
+		err = writer.Close()
+	}
+	data = buf.Bytes()
+	return
+}
+
+func (n *EmailNotifier) send(ctx context.Context, to []string, data []byte) (err error) {
+	address := net.JoinHostPort(n.Config.Host, strconv.Itoa(n.Config.Port))
+	tlsConfig := &tls.Config{ServerName: n.Config.Host, InsecureSkipVerify: n.Config.SkipVerify}
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	if n.Config.Security == SMTPTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		err = fmt.Errorf("cannot connect to the SMTP server %q: %v", address, err)
+		return
+	}
+
+	var client *smtp.Client
+	if client, err = smtp.NewClient(conn, n.Config.Host); err != nil {
+		_ = conn.Close()
+		return
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if n.Config.Security == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			err = fmt.Errorf("the SMTP server %q does not support STARTTLS", address)
+			return
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return
+		}
+	}
+
+	if n.Config.Username != "" {
+		auth := smtp.PlainAuth("", n.Config.Username, n.Config.Password, n.Config.Host)
+		if n.Config.AllowInsecureAuth && n.Config.Security == SMTPNone {
+			auth = &insecurePlainAuth{username: n.Config.Username, password: n.Config.Password}
+		}
+		if err = client.Auth(auth); err != nil {
+			err = fmt.Errorf("SMTP authentication failed: %v", err)
+			return
+		}
+	}
+
+	if err = client.Mail(n.Config.From); err != nil {
+		return
+	}
+	for _, address := range to {
+		if err = client.Rcpt(address); err != nil {
+			return
+		}
+	}
+
+	var writer io.WriteCloser
+	if writer, err = client.Data(); err != nil {
+		return
+	}
+	if _, err = writer.Write(data); err != nil {
+		return
+	}
+	if err = writer.Close(); err != nil {
+		return
+	}
+	err = client.Quit()
+	return
+}
+
+// insecurePlainAuth is the PLAIN authentication without the restriction of smtp.PlainAuth, which only allows
+// TLS connections or localhost. It's used only if SMTPConfig.AllowInsecureAuth is set
+type insecurePlainAuth struct {
+	username, password string
+}
+
+func (a *insecurePlainAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "PLAIN", []byte("\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a *insecurePlainAuth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, errors.New("unexpected server challenge")
+	}
+	return nil, nil
+}
+
+var headerLineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+func mimeEncodeHeader(text string) string {
+	for _, r := range text {
+		if r > 127 {
+			return mime.QEncoding.Encode("UTF-8", text)
+		}
+	}
+	return text
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, data []byte) (err error) {
+	writer := quotedprintable.NewWriter(buf)
+	if _, err = writer.Write(data); err == nil {
+		err = writer.Close()
+	}
+	return
+}
+
+func emptyThen(first, second string) string {
+	if first == "" {
+		return second
+	}
+	return first
+}
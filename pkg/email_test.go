@@ -0,0 +1,263 @@
+package pkg
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpSink is a local SMTP server which records the received mails
+type smtpSink struct {
+	listener net.Listener
+	auth     string
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPSink(t *testing.T, extensions ...string) (sink *smtpSink) {
+	return newSMTPSinkAt(t, "127.0.0.1", extensions...)
+}
+
+func newSMTPSinkAt(t *testing.T, host string, extensions ...string) (sink *smtpSink) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	assert.NoError(t, err)
+	sink = &smtpSink{listener: listener, done: make(chan struct{})}
+	go sink.serve(extensions)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	return
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve(extensions []string) {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO":
+			_ = text.PrintfLine("250-localhost")
+			for _, extension := range extensions {
+				_ = text.PrintfLine("250-%s", extension)
+			}
+			_ = text.PrintfLine("250 8BITMIME")
+		case "AUTH":
+			if fields := strings.Fields(line); len(fields) == 3 {
+				auth, _ := base64.StdEncoding.DecodeString(fields[2])
+				s.auth = string(auth)
+			}
+			_ = text.PrintfLine("235 authenticated")
+		case "MAIL":
+			s.from = strings.Trim(strings.Fields(strings.TrimPrefix(line, "MAIL FROM:"))[0], "<>")
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			s.to = append(s.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 go ahead")
+			data, _ := text.ReadDotBytes()
+			s.data = string(data)
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestNewEmailNotifier(t *testing.T) {
+	notifier, err := NewEmailNotifier(SMTPConfig{Host: "smtp.example.com", From: "gogit@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, SMTPStartTLS, notifier.Config.Security)
+	assert.Equal(t, 587, notifier.Config.Port)
+
+	notifier, err = NewEmailNotifier(SMTPConfig{Host: "smtp.example.com", From: "gogit@example.com", Security: SMTPTLS})
+	assert.NoError(t, err)
+	assert.Equal(t, 465, notifier.Config.Port)
+
+	for _, config := range []SMTPConfig{
+		{From: "gogit@example.com"},
+		{Host: "smtp.example.com", From: "gogit@example.com", Security: "ssl"},
+		{Host: "smtp.example.com", From: "gogit@example.com", Subject: "{{.Title"},
+		{Host: "smtp.example.com", From: "gogit@example.com", HTML: "{{.Text"},
+	} {
+		_, err = NewEmailNotifier(config)
+		assert.Error(t, err)
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number: 1,
+		Title:  "fix the bug",
+		Author: scm.User{Login: "linuxsuren", Email: "rick@example.com"},
+		Reviewers: []scm.User{
+			{Login: "alice", Email: "alice@example.com"},
+			{Login: "bob"},
+		},
+	}
+	msg := Message{
+		Title:       pr.Title,
+		Text:        "workflow done",
+		Link:        "https://github.com/linuxsuren/gogit/pull/1",
+		Recipients:  []string{"linuxsuren", "alice", "bob"},
+		PullRequest: pr,
+	}
+
+	t.Run("plain text with auth", func(t *testing.T) {
+		sink := newSMTPSink(t, "AUTH PLAIN")
+		notifier, err := NewEmailNotifier(SMTPConfig{
+			Host:      "127.0.0.1",
+			Port:      sink.port(),
+			Security:  SMTPNone,
+			Username:  "gogit",
+			Password:  "password",
+			From:      "gogit@example.com",
+			To:        []string{"team@example.com"},
+			Addresses: map[string]string{"bob": "bob@example.com"},
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, notifier.Notify(context.Background(), msg))
+		<-sink.done
+		assert.Equal(t, "\x00gogit\x00password", sink.auth)
+		assert.Equal(t, "gogit@example.com", sink.from)
+		assert.Equal(t, []string{"alice@example.com", "bob@example.com", "rick@example.com", "team@example.com"}, sink.to)
+		assert.Contains(t, sink.data, "Subject: fix the bug\n")
+		assert.Contains(t, sink.data, "Content-Type: text/plain; charset=UTF-8\n")
+		assert.Contains(t, sink.data, "workflow done\n\nhttps://github.com/linuxsuren/gogit/pull/1")
+	})
+
+	t.Run("no auth over an unencrypted connection to a remote server", func(t *testing.T) {
+		config := SMTPConfig{
+			Host:     "127.0.0.2",
+			Security: SMTPNone,
+			Username: "gogit",
+			Password: "password",
+			From:     "gogit@example.com",
+		}
+		sink := newSMTPSinkAt(t, config.Host, "AUTH PLAIN")
+		config.Port = sink.port()
+		notifier, err := NewEmailNotifier(config)
+		assert.NoError(t, err)
+		assert.ErrorContains(t, notifier.Notify(context.Background(), msg), "unencrypted connection")
+		<-sink.done
+		assert.Empty(t, sink.auth)
+
+		sink = newSMTPSinkAt(t, config.Host, "AUTH PLAIN")
+		config.Port = sink.port()
+		config.AllowInsecureAuth = true
+		notifier, err = NewEmailNotifier(config)
+		assert.NoError(t, err)
+		assert.NoError(t, notifier.Notify(context.Background(), msg))
+		<-sink.done
+		assert.Equal(t, "\x00gogit\x00password", sink.auth)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		sink := newSMTPSink(t)
+		notifier, err := NewEmailNotifier(SMTPConfig{
+			Host:     "127.0.0.1",
+			Port:     sink.port(),
+			Security: SMTPNone,
+			From:     "gogit@example.com",
+			Subject:  "[#{{.PullRequest.Number}}] {{.Title}}",
+			HTML:     `<a href="{{.Link}}">{{.PullRequest.Title}}</a>`,
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, notifier.Notify(context.Background(), msg))
+		<-sink.done
+		assert.Equal(t, []string{"alice@example.com", "rick@example.com"}, sink.to)
+		assert.Contains(t, sink.data, "Subject: [#1] fix the bug\n")
+		assert.Contains(t, sink.data, "Content-Type: multipart/alternative; boundary=")
+		assert.Contains(t, sink.data, "Content-Type: text/html; charset=UTF-8")
+		assert.Contains(t, sink.data, `<a href=3D"https://github.com/linuxsuren/gogit/pull/1">fix the bug</a>`)
+	})
+
+	t.Run("STARTTLS is not supported", func(t *testing.T) {
+		sink := newSMTPSink(t)
+		notifier, err := NewEmailNotifier(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "gogit@example.com"})
+		assert.NoError(t, err)
+		assert.ErrorContains(t, notifier.Notify(context.Background(), msg), "does not support STARTTLS")
+	})
+
+	t.Run("no recipients", func(t *testing.T) {
+		notifier, err := NewEmailNotifier(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "gogit@example.com"})
+		assert.NoError(t, err)
+		assert.NoError(t, notifier.Notify(context.Background(), Message{Title: "title", Recipients: []string{"unknown"}}))
+	})
+}
+
+func TestEmailChannel(t *testing.T) {
+	directory, err := ParseUserDirectory([]byte(`
+users:
+  - name: Bob
+    logins:
+      github: bob
+    email: bob@example.com`))
+	assert.NoError(t, err)
+	config, err := ParseNotifyConfig([]byte(`
+channels:
+  - name: contractors
+    kind: email
+    smtp:
+      host: smtp.example.com
+      port: 2525
+      from: gogit@example.com
+groups:
+  contractors:
+    members: [bob]
+    channels: [contractors]`))
+	assert.NoError(t, err)
+
+	_, notifiers, err := (&NotifyResolver{Provider: "github", Config: config, Directory: directory}).
+		Resolve([]string{"bob"}, time.Now())
+	assert.NoError(t, err)
+	if email, ok := notifiers["contractors"].(*EmailNotifier); assert.True(t, ok) {
+		assert.Equal(t, map[string]string{"bob": "bob@example.com"}, email.Config.Addresses)
+		assert.Equal(t, 2525, email.Config.Port)
+	}
+
+	_, err = ParseNotifyConfig([]byte(`{"channels": [{"name": "a", "kind": "email"}]}`))
+	assert.Error(t, err)
+}
+
+func TestEmailSubjectInjection(t *testing.T) {
+	notifier, err := NewEmailNotifier(SMTPConfig{Host: "smtp.example.com", From: "gogit@example.com"})
+	assert.NoError(t, err)
+
+	data, err := notifier.newMail(Message{Title: "fix\r\nBcc: evil@example.com\nX-Spam: yes"}, []string{"team@example.com"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "Subject: fix  Bcc: evil@example.com X-Spam: yes\r\n")
+	assert.NotContains(t, string(data), "\r\nBcc:")
+	assert.NotContains(t, string(data), "\nX-Spam:")
+}
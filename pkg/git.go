@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -17,7 +18,7 @@ func CreateStatus(ctx context.Context, repoInfo RepoInformation) (err error) {
 	return
 }
 
-// CreateComment creates a comment against the pull request
+// CreateComment creates a comment against the pull request, issue or commit
 //
 // It will update the comment there is a comment has the same ender
 func CreateComment(ctx context.Context, repoInfo RepoInformation, message, identity string) (err error) {
@@ -37,6 +38,7 @@ func NewMaker(ctx context.Context, repoInfo RepoInformation) (maker *StatusMaker
 	repo := repoInfo.GetRepoPath()
 	maker = NewStatusMaker(repo, repoInfo.Token)
 	maker.WithTarget(repoInfo.Target).WithPR(repoInfo.PrNumber).
+		WithIssue(repoInfo.IssueNumber).WithSha(repoInfo.Sha).
 		WithServer(repoInfo.Server).
 		WithProvider(repoInfo.Provider).
 		WithUsername(repoInfo.Username).
@@ -50,9 +52,12 @@ type StatusMaker struct {
 	server   string
 	repo     string
 	pr       int
+	issue    int
+	sha      string
 	token    string
 	username string
 	target   string
+	client   *scm.Client
 
 	// expirationCheck checks if the current status is expiration that compared to the previous one
 	expirationCheck expirationCheckFunc
@@ -113,23 +118,47 @@ func (s *StatusMaker) WithPR(pr int) *StatusMaker {
 	return s
 }
 
+// WithIssue sets the issue number, the comments will be created against the issue instead of the pull request
+func (s *StatusMaker) WithIssue(issue int) *StatusMaker {
+	s.issue = issue
+	return s
+}
+
+// WithSha sets the commit sha, the comments will be created against the commit instead of the pull request
+func (s *StatusMaker) WithSha(sha string) *StatusMaker {
+	s.sha = sha
+	return s
+}
+
+// WithClient sets the git provider client, it will be created from the provider if it's nil
+func (s *StatusMaker) WithClient(client *scm.Client) *StatusMaker {
+	s.client = client
+	return s
+}
+
+func (s *StatusMaker) getClient() (scmClient *scm.Client, err error) {
+	if s.client != nil {
+		scmClient = s.client
+		return
+	}
+	scmClient, err = factory.NewClient(s.provider, s.server, s.token, func(c *scm.Client) {
+		c.Username = s.username
+	})
+	return
+}
+
 // CommentEndMarker is the identify for matching existing comment
 const CommentEndMarker = "Comment from [gogit](https://github.com/linuxsuren/gogit)."
 
-// CreateComment creates a comment
+// CreateComment creates a comment against the target, it could be a pull request, an issue or a commit
 func (s *StatusMaker) CreateComment(ctx context.Context, message, endMarker string) (err error) {
-	var scmClient *scm.Client
-	if scmClient, err = factory.NewClient(s.provider, s.server, s.token, func(c *scm.Client) {
-		c.Username = s.username
-	}); err != nil {
+	var service commentService
+	if service, err = s.getCommentService(); err != nil {
 		return
 	}
 
 	var comments []*scm.Comment
-	if comments, _, err = scmClient.PullRequests.ListComments(ctx, s.repo, s.pr, &scm.ListOptions{
-		Page: 1,
-		Size: 100,
-	}); err != nil {
+	if comments, err = service.List(ctx); err != nil {
 		if err = IgnoreError(err, "Not Found"); err != nil {
 			err = fmt.Errorf("cannot any comments %v", err)
 			return
@@ -143,15 +172,17 @@ func (s *StatusMaker) CreateComment(ctx context.Context, message, endMarker stri
 
 	if len(commentIDs) == 0 {
 		// not found existing comment, create a new one
-		_, _, err = scmClient.PullRequests.CreateComment(ctx, s.repo, s.pr, commentInput)
-		err = WrapError(err, "failed to create comment, repo is %q, pr is %d: %v", s.repo, s.pr)
+		err = service.Create(ctx, commentInput)
+		err = WrapError(err, "failed to create comment, repo is %q, target is %s: %v", s.repo, service)
 	} else {
-		_, _, err = scmClient.PullRequests.EditComment(ctx, s.repo, s.pr, commentIDs[0], commentInput)
+		err = service.Edit(ctx, commentIDs[0], commentInput)
 		err = WrapError(err, "failed to edit comment: %v")
 
 		// remove the duplicated comments
 		for i := 1; i < len(commentIDs); i++ {
-			_, _ = scmClient.PullRequests.DeleteComment(ctx, s.repo, s.pr, commentIDs[i])
+			if deleteErr := service.Delete(ctx, commentIDs[i]); deleteErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to delete the duplicated comment %d: %v", commentIDs[i], deleteErr))
+			}
 		}
 	}
 	return
@@ -170,9 +201,7 @@ func getCommentIDs(comments []*scm.Comment, endMarker string) (commentIDs []int)
 // CreateStatus creates a generic status
 func (s *StatusMaker) CreateStatus(ctx context.Context, status scm.State, label, desc string) (err error) {
 	var scmClient *scm.Client
-	if scmClient, err = factory.NewClient(s.provider, s.server, s.token, func(c *scm.Client) {
-		c.Username = s.username
-	}); err != nil {
+	if scmClient, err = s.getClient(); err != nil {
 		return
 	}
 
@@ -202,9 +231,7 @@ func (s *StatusMaker) CreateStatus(ctx context.Context, status scm.State, label,
 // ListStatus list the status
 func (s *StatusMaker) ListStatus(ctx context.Context, label, desc string) (err error) {
 	var scmClient *scm.Client
-	if scmClient, err = factory.NewClient(s.provider, s.server, s.token, func(c *scm.Client) {
-		c.Username = s.username
-	}); err != nil {
+	if scmClient, err = s.getClient(); err != nil {
 		return
 	}
 
@@ -0,0 +1,464 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// MergeConflictError represents the files which cannot be merged automatically
+type MergeConflictError struct {
+	// Commit is the commit which conflicts with the base
+	Commit string
+	Paths  []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("cannot apply commit %s automatically, conflicts in:\n  - %s",
+		shortSha(e.Commit), strings.Join(e.Paths, "\n  - "))
+}
+
+// MergeCommits merges head into base like `git merge --no-ff`, base is returned if head is already merged
+func MergeCommits(repo *git.Repository, base, head *object.Commit, message string, signature object.Signature) (merged *object.Commit, err error) {
+	var mergeBase *object.Commit
+	if mergeBase, err = findMergeBase(base, head); err != nil {
+		return
+	}
+	if mergeBase.Hash == head.Hash {
+		merged = base
+		return
+	}
+
+	var tree plumbing.Hash
+	if tree, err = mergeCommitTrees(repo, mergeBase, base, head); err != nil {
+		return
+	}
+	merged, err = writeCommit(repo, &object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     tree,
+		ParentHashes: []plumbing.Hash{base.Hash, head.Hash},
+	})
+	return
+}
+
+// RebaseCommits replays the commits of head which are not in base onto base like `git rebase`. The commits, including
+// the ones merged into head, are replayed in the topological order. The merge commits, and the commits which are
+// already applied, are skipped. The authors are kept
+func RebaseCommits(repo *git.Repository, base, head *object.Commit, committer object.Signature) (rebased *object.Commit, err error) {
+	if _, err = findMergeBase(base, head); err != nil {
+		return
+	}
+
+	excluded := make(map[plumbing.Hash]struct{})
+	if err = walkCommits(repo, base.Hash, func(commit *object.Commit) {
+		excluded[commit.Hash] = struct{}{}
+	}); err != nil {
+		return
+	}
+
+	var commits []*object.Commit
+	if commits, err = topoOrderCommits(repo, head, excluded); err != nil {
+		return
+	}
+
+	rebased = base
+	for _, commit := range commits {
+		if commit.NumParents() > 1 {
+			continue
+		}
+
+		parent := &object.Commit{TreeHash: emptyTree}
+		if commit.NumParents() > 0 {
+			if parent, err = commit.Parent(0); err != nil {
+				return
+			}
+		}
+
+		var tree plumbing.Hash
+		if tree, err = mergeCommitTrees(repo, parent, rebased, commit); err != nil {
+			return
+		}
+		if tree == rebased.TreeHash {
+			continue
+		}
+
+		// keep the time of the original commit, then rebasing the same commits gets the same result
+		committer.When = commit.Committer.When
+		if rebased, err = writeCommit(repo, &object.Commit{
+			Author:       commit.Author,
+			Committer:    committer,
+			Message:      commit.Message,
+			TreeHash:     tree,
+			ParentHashes: []plumbing.Hash{rebased.Hash},
+		}); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// topoOrderCommits returns the commits reachable from head but not excluded, the parents are before their children
+// and the first parent history is visited first
+func topoOrderCommits(repo *git.Repository, head *object.Commit, excluded map[plumbing.Hash]struct{}) (commits []*object.Commit, err error) {
+	type frame struct {
+		commit   *object.Commit
+		expanded bool
+	}
+	seen := make(map[plumbing.Hash]struct{})
+	stack := []frame{{commit: head}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.expanded {
+			commits = append(commits, top.commit)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		commit := top.commit
+		_, isSeen := seen[commit.Hash]
+		if _, isExcluded := excluded[commit.Hash]; isSeen || isExcluded {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		seen[commit.Hash] = struct{}{}
+		top.expanded = true
+
+		for i := commit.NumParents() - 1; i >= 0; i-- {
+			hash := commit.ParentHashes[i]
+			if _, ok := excluded[hash]; ok {
+				continue
+			}
+
+			var parent *object.Commit
+			if parent, err = repo.CommitObject(hash); err != nil {
+				return
+			}
+			stack = append(stack, frame{commit: parent})
+		}
+	}
+	return
+}
+
+// emptyTree is the hash of the tree without any entry, it's not required to exist in the storage
+var emptyTree = plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904")
+
+func findMergeBase(base, head *object.Commit) (mergeBase *object.Commit, err error) {
+	var bases []*object.Commit
+	if bases, err = base.MergeBase(head); err != nil {
+		err = fmt.Errorf("cannot find the merge base of %s and %s, the history might be too shallow: %v",
+			shortSha(base.Hash.String()), shortSha(head.Hash.String()), err)
+		return
+	}
+	if len(bases) == 0 {
+		err = fmt.Errorf("%s and %s have no common ancestor", shortSha(base.Hash.String()), shortSha(head.Hash.String()))
+		return
+	}
+	mergeBase = bases[0]
+	return
+}
+
+// treeFile is a blob or a submodule in the tree
+type treeFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// mergeCommitTrees merges the changes from base to theirs into ours, the conflicts are returned as MergeConflictError
+func mergeCommitTrees(repo *git.Repository, base, ours, theirs *object.Commit) (tree plumbing.Hash, err error) {
+	var baseFiles, ourFiles, theirFiles map[string]treeFile
+	if baseFiles, err = treeFiles(repo, base.TreeHash); err != nil {
+		return
+	}
+	if ourFiles, err = treeFiles(repo, ours.TreeHash); err != nil {
+		return
+	}
+	if theirFiles, err = treeFiles(repo, theirs.TreeHash); err != nil {
+		return
+	}
+
+	paths := make(map[string]struct{})
+	for _, files := range []map[string]treeFile{baseFiles, ourFiles, theirFiles} {
+		for name := range files {
+			paths[name] = struct{}{}
+		}
+	}
+
+	merged := make(map[string]treeFile)
+	var conflicts []string
+	for name := range paths {
+		baseFile, inBase := baseFiles[name]
+		ourFile, inOurs := ourFiles[name]
+		theirFile, inTheirs := theirFiles[name]
+
+		var file treeFile
+		var exists, ok bool
+		switch {
+		case inOurs == inTheirs && ourFile == theirFile:
+			file, exists, ok = ourFile, inOurs, true
+		case inBase == inOurs && baseFile == ourFile:
+			file, exists, ok = theirFile, inTheirs, true
+		case inBase == inTheirs && baseFile == theirFile:
+			file, exists, ok = ourFile, inOurs, true
+		case inBase && inOurs && inTheirs && ourFile.mode == theirFile.mode && ourFile.mode.IsFile():
+			// both sides modified the file
+			file.mode, exists = ourFile.mode, true
+			if file.hash, ok, err = mergeBlobs(repo, baseFile.hash, ourFile.hash, theirFile.hash); err != nil {
+				return
+			}
+		}
+
+		if !ok {
+			conflicts = append(conflicts, name)
+		} else if exists {
+			merged[name] = file
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		err = &MergeConflictError{Commit: theirs.Hash.String(), Paths: conflicts}
+		return
+	}
+	if tree, err = writeTree(repo, merged); err != nil {
+		if conflict, ok := err.(*MergeConflictError); ok {
+			conflict.Commit = theirs.Hash.String()
+		}
+	}
+	return
+}
+
+// treeFiles returns the files of the tree by the paths
+func treeFiles(repo *git.Repository, hash plumbing.Hash) (files map[string]treeFile, err error) {
+	files = make(map[string]treeFile)
+	if hash == emptyTree {
+		return
+	}
+
+	var tree *object.Tree
+	if tree, err = repo.TreeObject(hash); err != nil {
+		return
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		var name string
+		var entry object.TreeEntry
+		if name, entry, err = walker.Next(); err == io.EOF {
+			err = nil
+			return
+		} else if err != nil {
+			return
+		}
+		if entry.Mode != filemode.Dir {
+			files[name] = treeFile{hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+}
+
+// mergeBlobs merges the text files line by line, it's not ok if the changes overlap or any file is binary
+func mergeBlobs(repo *git.Repository, base, ours, theirs plumbing.Hash) (merged plumbing.Hash, ok bool, err error) {
+	contents := make([]string, 3)
+	for i, hash := range []plumbing.Hash{base, ours, theirs} {
+		var blob *object.Blob
+		if blob, err = repo.BlobObject(hash); err != nil {
+			return
+		}
+
+		var reader io.ReadCloser
+		if reader, err = blob.Reader(); err != nil {
+			return
+		}
+		data, readErr := io.ReadAll(reader)
+		_ = reader.Close()
+		if err = readErr; err != nil {
+			return
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			return
+		}
+		contents[i] = string(data)
+	}
+
+	var text string
+	if text, ok = mergeLines(contents[0], contents[1], contents[2]); ok {
+		merged, err = writeBlob(repo, []byte(text))
+	}
+	return
+}
+
+// lineHunk replaces the lines [start, end) of the base
+type lineHunk struct {
+	start, end int
+	lines      []string
+}
+
+// mergeLines merges the changes of both sides like diff3, it's not ok if the changes overlap or are adjacent
+func mergeLines(base, ours, theirs string) (merged string, ok bool) {
+	baseLines := splitLines(base)
+	ourHunks, theirHunks := lineHunks(base, ours), lineHunks(base, theirs)
+
+	var hunks []lineHunk
+	for i, j := 0, 0; i < len(ourHunks) || j < len(theirHunks); {
+		switch {
+		case j == len(theirHunks) || (i < len(ourHunks) && ourHunks[i].end < theirHunks[j].start):
+			hunks = append(hunks, ourHunks[i])
+			i++
+		case i == len(ourHunks) || theirHunks[j].end < ourHunks[i].start:
+			hunks = append(hunks, theirHunks[j])
+			j++
+		case ourHunks[i].start == theirHunks[j].start && ourHunks[i].end == theirHunks[j].end &&
+			strings.Join(ourHunks[i].lines, "") == strings.Join(theirHunks[j].lines, ""):
+			// the same change on both sides
+			hunks = append(hunks, ourHunks[i])
+			i++
+			j++
+		default:
+			return
+		}
+	}
+
+	buf := new(strings.Builder)
+	position := 0
+	for _, hunk := range hunks {
+		buf.WriteString(strings.Join(baseLines[position:hunk.start], ""))
+		buf.WriteString(strings.Join(hunk.lines, ""))
+		position = hunk.end
+	}
+	buf.WriteString(strings.Join(baseLines[position:], ""))
+	merged, ok = buf.String(), true
+	return
+}
+
+// lineHunks returns the changes from src to dst by the lines of src
+func lineHunks(src, dst string) (hunks []lineHunk) {
+	position := 0
+	var current *lineHunk
+	for _, item := range diff.Do(src, dst) {
+		lines := splitLines(item.Text)
+		if item.Type == diffmatchpatch.DiffEqual {
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+			position += len(lines)
+			continue
+		}
+
+		if current == nil {
+			current = &lineHunk{start: position, end: position}
+		}
+		if item.Type == diffmatchpatch.DiffDelete {
+			position += len(lines)
+			current.end = position
+		} else {
+			current.lines = append(current.lines, lines...)
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+	return
+}
+
+// splitLines splits the text into the lines with the line breaks
+func splitLines(text string) (lines []string) {
+	for text != "" {
+		index := strings.IndexByte(text, '\n')
+		if index < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:index+1])
+		text = text[index+1:]
+	}
+	return
+}
+
+func writeBlob(repo *git.Repository, data []byte) (hash plumbing.Hash, err error) {
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+
+	var writer io.WriteCloser
+	if writer, err = obj.Writer(); err != nil {
+		return
+	}
+	if _, err = writer.Write(data); err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		hash, err = repo.Storer.SetEncodedObject(obj)
+	}
+	return
+}
+
+// writeTree writes the nested trees of the files, and returns the root tree
+func writeTree(repo *git.Repository, files map[string]treeFile) (hash plumbing.Hash, err error) {
+	children := make(map[string]map[string]treeFile)
+	tree := &object.Tree{}
+	for name, file := range files {
+		if dir, rest, nested := strings.Cut(name, "/"); nested {
+			if children[dir] == nil {
+				children[dir] = make(map[string]treeFile)
+			}
+			children[dir][rest] = file
+		} else {
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: file.mode, Hash: file.hash})
+		}
+	}
+
+	for dir, dirFiles := range children {
+		if _, ok := files[dir]; ok {
+			err = &MergeConflictError{Paths: []string{dir}}
+			return
+		}
+
+		var dirHash plumbing.Hash
+		if dirHash, err = writeTree(repo, dirFiles); err != nil {
+			return
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: dirHash})
+	}
+
+	// git sorts the entries as if the directories end with a slash
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return treeEntryKey(tree.Entries[i]) < treeEntryKey(tree.Entries[j])
+	})
+
+	obj := repo.Storer.NewEncodedObject()
+	if err = tree.Encode(obj); err == nil {
+		hash, err = repo.Storer.SetEncodedObject(obj)
+	}
+	return
+}
+
+func treeEntryKey(entry object.TreeEntry) string {
+	if entry.Mode == filemode.Dir {
+		return entry.Name + "/"
+	}
+	return entry.Name
+}
+
+func writeCommit(repo *git.Repository, commit *object.Commit) (written *object.Commit, err error) {
+	obj := repo.Storer.NewEncodedObject()
+	if err = commit.Encode(obj); err != nil {
+		return
+	}
+
+	var hash plumbing.Hash
+	if hash, err = repo.Storer.SetEncodedObject(obj); err == nil {
+		written, err = repo.CommitObject(hash)
+	}
+	return
+}
@@ -0,0 +1,172 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeLines(t *testing.T) {
+	const base = "1\n2\n3\n4\n5\n"
+	tests := []struct {
+		name   string
+		ours   string
+		theirs string
+		expect string
+		ok     bool
+	}{{
+		name:   "changes in different lines",
+		ours:   "one\n2\n3\n4\n5\n",
+		theirs: "1\n2\n3\n4\nfive\n6\n",
+		expect: "one\n2\n3\n4\nfive\n6\n",
+		ok:     true,
+	}, {
+		name:   "deletion and insertion",
+		ours:   "1\n3\n4\n5\n",
+		theirs: "1\n2\n3\n4\n4.5\n5\n",
+		expect: "1\n3\n4\n4.5\n5\n",
+		ok:     true,
+	}, {
+		name:   "the same change",
+		ours:   "1\n2\nthree\n4\n5\n",
+		theirs: "1\n2\nthree\n4\n5\n",
+		expect: "1\n2\nthree\n4\n5\n",
+		ok:     true,
+	}, {
+		name:   "overlapping changes",
+		ours:   "1\n2\nthree\n4\n5\n",
+		theirs: "1\n2\nTHREE\n4\n5\n",
+	}, {
+		name:   "adjacent changes",
+		ours:   "1\n2\nthree\n4\n5\n",
+		theirs: "1\n2\n3\nfour\n5\n",
+	}, {
+		name:   "insertions at the end",
+		ours:   base + "6\n",
+		theirs: base + "six\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, ok := mergeLines(base, tt.ours, tt.theirs)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expect, merged)
+			}
+		})
+	}
+}
+
+func TestMergeAndRebaseCommits(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	commitFiles := func(message string, files map[string]string) *object.Commit {
+		for name, content := range files {
+			assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			_, err := worktree.Add(name)
+			assert.NoError(t, err)
+		}
+		commit, err := repo.CommitObject(commitAll(t, repo, message))
+		assert.NoError(t, err)
+		return commit
+	}
+
+	initial := commitFiles("init", map[string]string{"a.txt": "1\n2\n3\n4\n5\n"})
+	base := commitFiles("fix: base", map[string]string{"a.txt": "one\n2\n3\n4\n5\n"})
+
+	assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{
+		Hash: initial.Hash, Branch: plumbing.NewBranchReferenceName("feature"), Create: true,
+	}))
+	commitFiles("feat: first", map[string]string{"a.txt": "1\n2\n3\n4\nfive\n"})
+	head := commitFiles("feat: second", map[string]string{"docs/b.txt": "b\n"})
+	signature := object.Signature{Name: "gogit", Email: "gogit@example.com", When: time.Now()}
+
+	t.Run("merge", func(t *testing.T) {
+		merged, err := MergeCommits(repo, base, head, "Merge feature", signature)
+		assert.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{base.Hash, head.Hash}, merged.ParentHashes)
+		assertFile(t, merged, "a.txt", "one\n2\n3\n4\nfive\n")
+		assertFile(t, merged, "docs/b.txt", "b\n")
+
+		// already merged
+		again, err := MergeCommits(repo, merged, head, "Merge feature", signature)
+		assert.NoError(t, err)
+		assert.Equal(t, merged.Hash, again.Hash)
+	})
+
+	t.Run("rebase", func(t *testing.T) {
+		rebased, err := RebaseCommits(repo, base, head, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, "feat: second", rebased.Message)
+		assertFile(t, rebased, "a.txt", "one\n2\n3\n4\nfive\n")
+		assertFile(t, rebased, "docs/b.txt", "b\n")
+
+		first, err := rebased.Parent(0)
+		assert.NoError(t, err)
+		assert.Equal(t, "feat: first", first.Message)
+		assert.Equal(t, []plumbing.Hash{base.Hash}, first.ParentHashes)
+		assert.Equal(t, "Rick", first.Author.Name)
+		assert.Equal(t, "gogit", first.Committer.Name)
+	})
+
+	t.Run("rebase the commits merged into head", func(t *testing.T) {
+		assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{
+			Hash: initial.Hash, Branch: plumbing.NewBranchReferenceName("topic"), Create: true,
+		}))
+		topic := commitFiles("feat: topic", map[string]string{"c.txt": "c\n"})
+		assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature")}))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c\n"), 0644))
+		_, err := worktree.Add("c.txt")
+		assert.NoError(t, err)
+		mergeHash, err := worktree.Commit("Merge topic", &git.CommitOptions{
+			Author:  &object.Signature{Name: "Rick", Email: "rick@example.com", When: time.Now()},
+			Parents: []plumbing.Hash{head.Hash, topic.Hash},
+		})
+		assert.NoError(t, err)
+		merge, err := repo.CommitObject(mergeHash)
+		assert.NoError(t, err)
+
+		rebased, err := RebaseCommits(repo, base, merge, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, "feat: topic", rebased.Message)
+		assertFile(t, rebased, "a.txt", "one\n2\n3\n4\nfive\n")
+		assertFile(t, rebased, "c.txt", "c\n")
+
+		var messages []string
+		for commit := rebased; commit.Hash != base.Hash; {
+			assert.Len(t, commit.ParentHashes, 1)
+			messages = append(messages, commit.Message)
+			commit, err = commit.Parent(0)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []string{"feat: topic", "feat: second", "feat: first"}, messages)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		conflicting := commitFiles("fix: conflict", map[string]string{"a.txt": "ONE\n2\n3\n4\nfive\n"})
+		_, err := MergeCommits(repo, base, conflicting, "Merge feature", signature)
+		assert.Equal(t, &MergeConflictError{Commit: conflicting.Hash.String(), Paths: []string{"a.txt"}}, err)
+
+		_, err = RebaseCommits(repo, base, conflicting, signature)
+		assert.Equal(t, &MergeConflictError{Commit: conflicting.Hash.String(), Paths: []string{"a.txt"}}, err)
+	})
+}
+
+func assertFile(t *testing.T, commit *object.Commit, name, expect string) {
+	file, err := commit.File(name)
+	if assert.NoError(t, err) {
+		content, err := file.Contents()
+		assert.NoError(t, err)
+		assert.Equal(t, expect, content)
+	}
+}
@@ -0,0 +1,286 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"gopkg.in/yaml.v3"
+)
+
+// Label is the declared label of a repository
+type Label struct {
+	Name        string `yaml:"name" json:"name"`
+	Color       string `yaml:"color" json:"color"`
+	Description string `yaml:"description" json:"description"`
+}
+
+// ParseLabels parses the declared labels from YAML or JSON
+func ParseLabels(data []byte) (labels []Label, err error) {
+	if err = yaml.Unmarshal(data, &labels); err != nil {
+		err = fmt.Errorf("cannot parse the labels: %v", err)
+		return
+	}
+
+	names := make(map[string]struct{}, len(labels))
+	for i := range labels {
+		label := &labels[i]
+		if label.Name == "" {
+			err = fmt.Errorf("the name of label %d is empty", i)
+			return
+		}
+		if _, ok := names[label.Name]; ok {
+			err = fmt.Errorf("the label %q is duplicated", label.Name)
+			return
+		}
+		names[label.Name] = struct{}{}
+		label.Color = normalizeColor(label.Color)
+	}
+	return
+}
+
+func normalizeColor(color string) string {
+	return strings.ToLower(strings.TrimPrefix(color, "#"))
+}
+
+// AddLabels adds the labels to the pull request or issue
+func (s *StatusMaker) AddLabels(ctx context.Context, labels ...string) (err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	for _, label := range labels {
+		var addErr error
+		if s.issue > 0 {
+			_, addErr = scmClient.Issues.AddLabel(ctx, s.repo, s.issue, label)
+		} else {
+			_, addErr = scmClient.PullRequests.AddLabel(ctx, s.repo, s.pr, label)
+		}
+		if addErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add label %q: %v", label, addErr))
+		}
+	}
+	return
+}
+
+// RemoveLabels removes the labels from the pull request or issue
+func (s *StatusMaker) RemoveLabels(ctx context.Context, labels ...string) (err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	for _, label := range labels {
+		var removeErr error
+		if s.issue > 0 {
+			_, removeErr = scmClient.Issues.DeleteLabel(ctx, s.repo, s.issue, label)
+		} else {
+			_, removeErr = scmClient.PullRequests.DeleteLabel(ctx, s.repo, s.pr, label)
+		}
+		if removeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to remove label %q: %v", label, removeErr))
+		}
+	}
+	return
+}
+
+// ListLabels lists the labels of the pull request or issue
+func (s *StatusMaker) ListLabels(ctx context.Context) (labels []*scm.Label, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	opt := &scm.ListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Label
+		var resp *scm.Response
+		if s.issue > 0 {
+			items, resp, err = scmClient.Issues.ListLabels(ctx, s.repo, s.issue, opt)
+		} else {
+			items, resp, err = scmClient.PullRequests.ListLabels(ctx, s.repo, s.pr, opt)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to list the labels: %v", err)
+			return
+		}
+		labels = append(labels, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+// LabelChanges represents the differences between the existing labels and the declared labels
+type LabelChanges struct {
+	Create []Label
+	Update []Label
+	Delete []Label
+}
+
+// IsEmpty returns true if there is nothing to change
+func (l LabelChanges) IsEmpty() bool {
+	return len(l.Create) == 0 && len(l.Update) == 0 && len(l.Delete) == 0
+}
+
+// DiffLabels compares the existing labels and the declared labels. The labels which are not declared
+// will be deleted only when prune is true
+func DiffLabels(existing []*scm.Label, declared []Label, prune bool) (changes LabelChanges) {
+	existingMap := make(map[string]*scm.Label, len(existing))
+	for _, label := range existing {
+		existingMap[label.Name] = label
+	}
+
+	declaredMap := make(map[string]struct{}, len(declared))
+	for _, label := range declared {
+		declaredMap[label.Name] = struct{}{}
+
+		if current, ok := existingMap[label.Name]; !ok {
+			changes.Create = append(changes.Create, label)
+		} else if (label.Color != "" && normalizeColor(current.Color) != label.Color) ||
+			current.Description != label.Description {
+			changes.Update = append(changes.Update, label)
+		}
+	}
+
+	if prune {
+		for _, label := range existing {
+			if _, ok := declaredMap[label.Name]; !ok {
+				changes.Delete = append(changes.Delete, Label{
+					Name:        label.Name,
+					Color:       normalizeColor(label.Color),
+					Description: label.Description,
+				})
+			}
+		}
+	}
+	return
+}
+
+// SyncLabels makes the labels of the repository match the declared labels
+func (s *StatusMaker) SyncLabels(ctx context.Context, declared []Label, prune, dryRun bool) (changes LabelChanges, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var service repoLabelService
+	switch s.provider {
+	case "github":
+		service = &githubLabelService{client: scmClient, repo: s.repo}
+	case "gitlab":
+		service = &gitlabLabelService{client: scmClient, repo: s.repo}
+	default:
+		err = fmt.Errorf("sync labels is not supported by %q", s.provider)
+		return
+	}
+
+	var existing []*scm.Label
+	opt := &scm.ListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Label
+		var resp *scm.Response
+		if items, resp, err = scmClient.Repositories.ListLabels(ctx, s.repo, opt); err != nil {
+			err = fmt.Errorf("failed to list the labels of %q: %v", s.repo, err)
+			return
+		}
+		existing = append(existing, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+
+	changes = DiffLabels(existing, declared, prune)
+	if dryRun {
+		return
+	}
+
+	ids := make(map[string]int64, len(existing))
+	for _, label := range existing {
+		ids[label.Name] = label.ID
+	}
+
+	for _, label := range changes.Create {
+		if createErr := service.Create(ctx, label); createErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to create label %q: %v", label.Name, createErr))
+		}
+	}
+	for _, label := range changes.Update {
+		if updateErr := service.Update(ctx, ids[label.Name], label); updateErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to update label %q: %v", label.Name, updateErr))
+		}
+	}
+	for _, label := range changes.Delete {
+		if deleteErr := service.Delete(ctx, ids[label.Name], label); deleteErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to delete label %q: %v", label.Name, deleteErr))
+		}
+	}
+	return
+}
+
+// repoLabelService manages the labels of a repository, it's not covered by go-scm
+type repoLabelService interface {
+	Create(ctx context.Context, label Label) error
+	Update(ctx context.Context, id int64, label Label) error
+	Delete(ctx context.Context, id int64, label Label) error
+}
+
+// githubLabelService see also https://docs.github.com/en/rest/issues/labels
+type githubLabelService struct {
+	client *scm.Client
+	repo   string
+}
+
+func (g *githubLabelService) Create(ctx context.Context, label Label) error {
+	return requestJSON(ctx, g.client, http.MethodPost, fmt.Sprintf("repos/%s/labels", g.repo), label, nil)
+}
+
+func (g *githubLabelService) Update(ctx context.Context, _ int64, label Label) error {
+	return requestJSON(ctx, g.client, http.MethodPatch,
+		fmt.Sprintf("repos/%s/labels/%s", g.repo, url.PathEscape(label.Name)), label, nil)
+}
+
+func (g *githubLabelService) Delete(ctx context.Context, _ int64, label Label) error {
+	return requestJSON(ctx, g.client, http.MethodDelete,
+		fmt.Sprintf("repos/%s/labels/%s", g.repo, url.PathEscape(label.Name)), nil, nil)
+}
+
+// gitlabLabelService see also https://docs.gitlab.com/ee/api/labels.html
+type gitlabLabelService struct {
+	client *scm.Client
+	repo   string
+}
+
+func (g *gitlabLabelService) Create(ctx context.Context, label Label) error {
+	return requestJSON(ctx, g.client, http.MethodPost, g.path(""), gitlabLabel(label), nil)
+}
+
+func (g *gitlabLabelService) Update(ctx context.Context, id int64, label Label) error {
+	return requestJSON(ctx, g.client, http.MethodPut, g.path(fmt.Sprintf("/%d", id)), gitlabLabel(label), nil)
+}
+
+func (g *gitlabLabelService) Delete(ctx context.Context, id int64, _ Label) error {
+	return requestJSON(ctx, g.client, http.MethodDelete, g.path(fmt.Sprintf("/%d", id)), nil, nil)
+}
+
+func (g *gitlabLabelService) path(suffix string) string {
+	return fmt.Sprintf("api/v4/projects/%s/labels%s", gitlabProject(g.repo), suffix)
+}
+
+// gitlabLabel converts the label to GitLab style, the color must start with #
+func gitlabLabel(label Label) Label {
+	if label.Color != "" {
+		label.Color = "#" + label.Color
+	}
+	return label
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expect    []Label
+		expectErr bool
+	}{{
+		name: "yaml",
+		data: `- name: size/XL
+  color: "#FF0000"
+  description: Huge
+- name: ci/failed`,
+		expect: []Label{{Name: "size/XL", Color: "ff0000", Description: "Huge"}, {Name: "ci/failed"}},
+	}, {
+		name:   "json",
+		data:   `[{"name": "bug", "color": "d73a4a"}]`,
+		expect: []Label{{Name: "bug", Color: "d73a4a"}},
+	}, {
+		name:      "empty name",
+		data:      `[{"color": "d73a4a"}]`,
+		expectErr: true,
+	}, {
+		name:      "duplicated",
+		data:      `[{"name": "bug"}, {"name": "bug"}]`,
+		expectErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := ParseLabels([]byte(tt.data))
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, labels)
+			}
+		})
+	}
+}
+
+func TestDiffLabels(t *testing.T) {
+	existing := []*scm.Label{
+		{Name: "bug", Color: "D73A4A"},
+		{Name: "size/XL", Color: "ff0000", Description: "old"},
+		{Name: "wontfix", Color: "ffffff"},
+	}
+	declared := []Label{
+		{Name: "bug", Color: "d73a4a"},
+		{Name: "size/XL", Color: "ff0000", Description: "new"},
+		{Name: "ci/failed", Color: "000000"},
+	}
+
+	changes := DiffLabels(existing, declared, false)
+	assert.Equal(t, []Label{{Name: "ci/failed", Color: "000000"}}, changes.Create)
+	assert.Equal(t, []Label{{Name: "size/XL", Color: "ff0000", Description: "new"}}, changes.Update)
+	assert.Empty(t, changes.Delete)
+
+	changes = DiffLabels(existing, declared, true)
+	assert.Equal(t, []Label{{Name: "wontfix", Color: "ffffff"}}, changes.Delete)
+	assert.False(t, changes.IsEmpty())
+	assert.True(t, DiffLabels(nil, nil, true).IsEmpty())
+}
+
+func TestAddAndRemoveLabels(t *testing.T) {
+	client, data := fake.NewDefault()
+	ctx := context.Background()
+
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithClient(client)
+	assert.NoError(t, maker.AddLabels(ctx, "ci/failed"))
+	assert.NoError(t, maker.RemoveLabels(ctx, "needs-rebase"))
+	assert.Equal(t, []string{"owner/repo#1:ci/failed"}, data.PullRequestLabelsAdded)
+	assert.Equal(t, []string{"owner/repo#1:needs-rebase"}, data.PullRequestLabelsRemoved)
+
+	labels, err := maker.ListLabels(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 1) {
+		assert.Equal(t, "ci/failed", labels[0].Name)
+	}
+
+	maker = NewStatusMaker("owner/repo", "").WithIssue(2).WithClient(client)
+	assert.NoError(t, maker.AddLabels(ctx, "nightly"))
+	assert.Equal(t, []string{"owner/repo#2:nightly"}, data.IssueLabelsAdded)
+}
+
+func TestSyncLabels(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"id": 1, "name": "bug", "color": "d73a4a"},
+				{"id": 2, "name": "wontfix", "color": "ffffff"},
+			})
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	declared := []Label{{Name: "bug", Color: "000000"}, {Name: "ci/failed", Color: "ff0000"}}
+	maker := NewStatusMaker("owner/repo", "").WithProvider("github").WithClient(client)
+
+	changes, err := maker.SyncLabels(ctx, declared, true, true)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Delete, 1)
+	assert.Empty(t, requests)
+
+	_, err = maker.SyncLabels(ctx, declared, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"POST /api/v3/repos/owner/repo/labels",
+		"PATCH /api/v3/repos/owner/repo/labels/bug",
+		"DELETE /api/v3/repos/owner/repo/labels/wontfix",
+	}, requests)
+
+	_, err = NewStatusMaker("owner/repo", "").WithProvider("gitea").WithClient(client).SyncLabels(ctx, declared, false, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"gopkg.in/yaml.v3"
+)
+
+// CommitLintCommentMarker is the identity of the sticky comment about the commit lint violations
+const CommitLintCommentMarker = "Commit lint from [gogit](https://github.com/linuxsuren/gogit)."
+
+// CommitLintRules is the rule set of the conventional commits
+type CommitLintRules struct {
+	// Types are the allowed types, such as feat and fix
+	Types []string `yaml:"types" json:"types"`
+	// Scopes are the allowed scopes, any scope is allowed if it's empty
+	Scopes        []string `yaml:"scopes" json:"scopes"`
+	ScopeRequired bool     `yaml:"scopeRequired" json:"scopeRequired"`
+	// MaxLength is the max length of the header, zero means no limit
+	MaxLength int `yaml:"maxLength" json:"maxLength"`
+	// BreakingFooter requires a BREAKING CHANGE footer when the header is marked as breaking by "!"
+	BreakingFooter bool `yaml:"breakingFooter" json:"breakingFooter"`
+	// SignOff requires a Signed-off-by footer in each commit message, it's not applied to the title
+	SignOff bool `yaml:"signOff" json:"signOff"`
+	// Ignore are the regular expressions of the messages which are skipped, such as the merge commits
+	Ignore []string `yaml:"ignore" json:"ignore"`
+}
+
+// DefaultCommitLintRules returns the rules of https://www.conventionalcommits.org
+func DefaultCommitLintRules() CommitLintRules {
+	return CommitLintRules{
+		Types:     []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"},
+		MaxLength: 72,
+		Ignore:    []string{"^Merge "},
+	}
+}
+
+// ParseCommitLintRules parses the rules from YAML or JSON, the missing fields are the default ones
+func ParseCommitLintRules(data []byte) (rules CommitLintRules, err error) {
+	rules = DefaultCommitLintRules()
+	if err = yaml.Unmarshal(data, &rules); err != nil {
+		err = fmt.Errorf("cannot parse the commit lint rules: %v", err)
+		return
+	}
+
+	for _, pattern := range rules.Ignore {
+		if _, err = regexp.Compile(pattern); err != nil {
+			err = fmt.Errorf("invalid ignore pattern %q: %v", pattern, err)
+			return
+		}
+	}
+	return
+}
+
+// Lint returns the violations of the commit message, or the pull request title if the title is true
+func (r CommitLintRules) Lint(message string, title bool) (violations []string) {
+	message = strings.TrimSpace(message)
+	for _, pattern := range r.Ignore {
+		if ok, _ := regexp.MatchString(pattern, message); ok {
+			return
+		}
+	}
+
+	lines := strings.Split(message, "\n")
+	header := strings.TrimSpace(lines[0])
+	if r.MaxLength > 0 && len(header) > r.MaxLength {
+		violations = append(violations, fmt.Sprintf("the header is longer than %d characters", r.MaxLength))
+	}
+
+	groups := conventionalCommitHeader.FindStringSubmatch(header)
+	if groups == nil {
+		violations = append(violations, "the header should be in the format of 'type(scope): description'")
+		return
+	}
+
+	if commitType := strings.ToLower(groups[1]); len(r.Types) > 0 && !slices.Contains(r.Types, commitType) {
+		violations = append(violations, fmt.Sprintf("the type %q is not one of %v", commitType, r.Types))
+	}
+	if scope := groups[3]; scope == "" && r.ScopeRequired {
+		violations = append(violations, "the scope is required")
+	} else if scope != "" && len(r.Scopes) > 0 && !slices.Contains(r.Scopes, scope) {
+		violations = append(violations, fmt.Sprintf("the scope %q is not one of %v", scope, r.Scopes))
+	}
+
+	var breakingFooter, signedOff bool
+	for _, line := range lines[1:] {
+		switch {
+		case strings.HasPrefix(line, "BREAKING CHANGE:"), strings.HasPrefix(line, "BREAKING-CHANGE:"):
+			breakingFooter = true
+		case strings.HasPrefix(strings.ToUpper(line), "BREAKING CHANGE:"), strings.HasPrefix(strings.ToUpper(line), "BREAKING-CHANGE:"):
+			violations = append(violations, "the BREAKING CHANGE footer should be upper case")
+		case strings.HasPrefix(line, "Signed-off-by:"):
+			signedOff = true
+		}
+	}
+	if r.BreakingFooter && groups[4] == "!" && !breakingFooter && !title {
+		violations = append(violations, "the breaking change should be described in a BREAKING CHANGE footer")
+	}
+	if r.SignOff && !signedOff && !title {
+		violations = append(violations, "the Signed-off-by footer is missing")
+	}
+	return
+}
+
+// CommitLintViolation is a pull request title or a commit which violates the rules
+type CommitLintViolation struct {
+	// Sha is empty for the pull request title
+	Sha     string   `json:"sha,omitempty" yaml:"sha,omitempty"`
+	Header  string   `json:"header" yaml:"header"`
+	Reasons []string `json:"reasons" yaml:"reasons"`
+}
+
+// LintCommits validates the title and all the commit messages of the pull request
+func (s *StatusMaker) LintCommits(ctx context.Context, rules CommitLintRules) (violations []CommitLintViolation, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var pr *scm.PullRequest
+	if pr, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err != nil {
+		err = fmt.Errorf("failed to find pull request %d: %v", s.pr, err)
+		return
+	}
+	if reasons := rules.Lint(pr.Title, true); len(reasons) > 0 {
+		violations = append(violations, CommitLintViolation{Header: pr.Title, Reasons: reasons})
+	}
+
+	var commits []*scm.Commit
+	if commits, err = s.listPullRequestCommits(ctx, scmClient); err != nil {
+		return
+	}
+	for _, commit := range commits {
+		if reasons := rules.Lint(commit.Message, false); len(reasons) > 0 {
+			violations = append(violations, CommitLintViolation{
+				Sha:     commit.Sha,
+				Header:  strings.TrimSpace(strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]),
+				Reasons: reasons,
+			})
+		}
+	}
+	return
+}
+
+// listPullRequestCommits lists the commits of the pull request, it's not covered by go-scm
+func (s *StatusMaker) listPullRequestCommits(ctx context.Context, scmClient *scm.Client) (commits []*scm.Commit, err error) {
+	const size = 100
+	for page := 1; ; page++ {
+		var path string
+		switch s.provider {
+		case "github":
+			// see also https://docs.github.com/en/rest/pulls/pulls#list-commits-on-a-pull-request
+			path = fmt.Sprintf("repos/%s/pulls/%d/commits?per_page=%d&page=%d", s.repo, s.pr, size, page)
+		case "gitea":
+			path = fmt.Sprintf("api/v1/repos/%s/pulls/%d/commits?limit=%d&page=%d", s.repo, s.pr, size, page)
+		case "gitlab":
+			// see also https://docs.gitlab.com/ee/api/merge_requests.html#get-single-merge-request-commits
+			path = fmt.Sprintf("api/v4/projects/%s/merge_requests/%d/commits?per_page=%d&page=%d",
+				gitlabProject(s.repo), s.pr, size, page)
+		default:
+			err = fmt.Errorf("listing the commits of pull request is not supported by provider %q", s.provider)
+			return
+		}
+
+		var out []struct {
+			Sha string `json:"sha"`
+			// ID, Message and CommittedDate are for GitLab
+			ID            string    `json:"id"`
+			Message       string    `json:"message"`
+			CommittedDate time.Time `json:"committed_date"`
+			Commit        struct {
+				Message   string `json:"message"`
+				Committer struct {
+					Date time.Time `json:"date"`
+				} `json:"committer"`
+			} `json:"commit"`
+		}
+		if err = requestJSON(ctx, scmClient, http.MethodGet, path, nil, &out); err != nil {
+			err = fmt.Errorf("failed to list the commits of pull request %d: %v", s.pr, err)
+			return
+		}
+		for _, item := range out {
+			date := item.Commit.Committer.Date
+			if date.IsZero() {
+				date = item.CommittedDate
+			}
+			commits = append(commits, &scm.Commit{
+				Sha:       emptyThen(item.Sha, item.ID),
+				Message:   emptyThen(item.Commit.Message, item.Message),
+				Committer: scm.Signature{Date: date},
+			})
+		}
+
+		if len(out) < size {
+			break
+		}
+	}
+	return
+}
+
+// CommitLintReport returns the Markdown report of the violations
+func CommitLintReport(violations []CommitLintViolation) string {
+	buf := new(strings.Builder)
+	buf.WriteString("Please fix the following commit lint violations:\n")
+	for _, violation := range violations {
+		subject := "the title"
+		if violation.Sha != "" {
+			subject = "commit " + shortSha(violation.Sha)
+		}
+		buf.WriteString(fmt.Sprintf("\n**%s**: `%s`\n", subject, violation.Header))
+		for _, reason := range violation.Reasons {
+			buf.WriteString(fmt.Sprintf("- %s\n", reason))
+		}
+	}
+	return buf.String()
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitLintRules(t *testing.T) {
+	rules := DefaultCommitLintRules()
+	rules.Scopes = []string{"cmd", "pkg"}
+	rules.BreakingFooter = true
+	rules.SignOff = true
+
+	tests := []struct {
+		name    string
+		message string
+		title   bool
+		expect  []string
+	}{{
+		name:    "valid commit",
+		message: "feat(cmd): add lint command\n\nSigned-off-by: Rick <rick@example.com>",
+	}, {
+		name:    "valid title",
+		message: "fix: the bug",
+		title:   true,
+	}, {
+		name:    "merge commit is ignored",
+		message: "Merge branch 'master' into feat",
+	}, {
+		name:    "not conventional",
+		message: "Update README.md",
+		title:   true,
+		expect:  []string{"the header should be in the format of 'type(scope): description'"},
+	}, {
+		name:    "unknown type and scope",
+		message: "feature(docs): add lint",
+		title:   true,
+		expect: []string{
+			`the type "feature" is not one of [feat fix docs style refactor perf test build ci chore revert]`,
+			`the scope "docs" is not one of [cmd pkg]`,
+		},
+	}, {
+		name:    "too long",
+		message: "fix: this header is too long to be accepted by the default max length of the rules",
+		title:   true,
+		expect:  []string{"the header is longer than 72 characters"},
+	}, {
+		name:    "breaking without footer or sign-off",
+		message: "feat!: remove the flag\n\nbreaking change: the flag is removed",
+		expect: []string{
+			"the BREAKING CHANGE footer should be upper case",
+			"the breaking change should be described in a BREAKING CHANGE footer",
+			"the Signed-off-by footer is missing",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, rules.Lint(tt.message, tt.title))
+		})
+	}
+
+	parsed, err := ParseCommitLintRules([]byte("scopeRequired: true\ntypes: [feat]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"feat"}, parsed.Types)
+	assert.Equal(t, 72, parsed.MaxLength)
+	assert.Equal(t, []string{"the scope is required"}, parsed.Lint("feat: add", true))
+
+	_, err = ParseCommitLintRules([]byte("ignore: ['(']"))
+	assert.Error(t, err)
+	_, err = ParseCommitLintRules([]byte("types: feat: fix"))
+	assert.Error(t, err)
+}
+
+func TestLintCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/pulls/1":
+			_ = json.NewEncoder(w).Encode(map[string]any{"number": 1, "title": "Add lint command"})
+		case "/api/v3/repos/owner/repo/pulls/1/commits":
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"sha": "1234567890", "commit": map[string]any{"message": "feat: add lint command"}},
+				{"sha": "abcdefghij", "commit": map[string]any{"message": "wip\n\nmore details"}},
+			})
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+
+	maker := NewStatusMaker("owner/repo", "").WithProvider("github").WithPR(1).WithClient(client)
+	violations, err := maker.LintCommits(context.Background(), DefaultCommitLintRules())
+	assert.NoError(t, err)
+	if assert.Len(t, violations, 2) {
+		assert.Empty(t, violations[0].Sha)
+		assert.Equal(t, "abcdefghij", violations[1].Sha)
+		assert.Equal(t, "wip", violations[1].Header)
+	}
+	assert.Equal(t, `Please fix the following commit lint violations:
+
+**the title**: `+"`Add lint command`"+`
+- the header should be in the format of 'type(scope): description'
+
+**commit abcdefg**: `+"`wip`"+`
+- the header should be in the format of 'type(scope): description'
+`, CommitLintReport(violations))
+
+	_, err = maker.WithProvider("bitbucket").LintCommits(context.Background(), DefaultCommitLintRules())
+	assert.Error(t, err)
+}
@@ -0,0 +1,227 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// MergeOptions is the options for merging a pull request
+type MergeOptions struct {
+	// Method is one of merge, squash and rebase
+	Method string
+	// RequireStatus is the labels of the status which must be successful
+	RequireStatus []string
+	// MinApprovals is the minimum number of approvals, the author is not counted
+	MinApprovals int
+	DeleteBranch bool
+}
+
+// MergeMethods are the supported merge methods
+var MergeMethods = []string{"merge", "squash", "rebase"}
+
+// MergeGateError represents the failed gates of merging a pull request
+type MergeGateError struct {
+	Failures []string
+}
+
+func (e *MergeGateError) Error() string {
+	return fmt.Sprintf("the pull request cannot be merged:\n  - %s", strings.Join(e.Failures, "\n  - "))
+}
+
+// MergeResult is the result of merging a pull request
+type MergeResult struct {
+	// DeletedBranch is the head branch which is deleted after merged
+	DeletedBranch string
+	// Fork is the repository of the head branch if it's not the base repository, its branch is never deleted
+	Fork string
+}
+
+// MergePullRequest merges the pull request once all the gates are passed
+func (s *StatusMaker) MergePullRequest(ctx context.Context, opt MergeOptions) (result MergeResult, err error) {
+	if opt.Method == "rebase" && s.provider == "gitlab" {
+		// go-scm only passes squash to GitLab, the merge method of a GitLab project is a project setting
+		err = fmt.Errorf("merge method rebase is not supported on GitLab, please use merge or squash, " +
+			"or set the merge method of the project to fast-forward merge")
+		return
+	}
+
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var pr *scm.PullRequest
+	if pr, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err != nil {
+		err = fmt.Errorf("failed to find pull request [%d] from [%s] %v", s.pr, s.repo, err)
+		return
+	}
+
+	var failures []string
+	if failures, err = s.CheckMergeGates(ctx, scmClient, pr, opt); err != nil {
+		return
+	}
+	if len(failures) > 0 {
+		err = &MergeGateError{Failures: failures}
+		return
+	}
+
+	if _, err = scmClient.PullRequests.Merge(ctx, s.repo, s.pr, &scm.PullRequestMergeOptions{
+		SHA:                pr.Sha,
+		MergeMethod:        opt.Method,
+		DeleteSourceBranch: opt.DeleteBranch,
+	}); err != nil {
+		err = fmt.Errorf("failed to merge pull request %d: %v", s.pr, err)
+		return
+	}
+
+	// GitLab deletes the source branch as part of the merge
+	if !opt.DeleteBranch || s.provider == "gitlab" {
+		return
+	}
+	// the branch with the same name in the base repository is not the head branch of a fork
+	if result.Fork = forkOf(s.repo, pr); result.Fork != "" {
+		return
+	}
+
+	branch := pr.Source
+	if branch == "" {
+		branch = pr.Head.Ref
+	}
+	if _, err = scmClient.Git.DeleteRef(ctx, s.repo, "heads/"+branch); err != nil {
+		err = fmt.Errorf("merged, but failed to delete branch %q: %v", branch, err)
+		return
+	}
+	result.DeletedBranch = branch
+	return
+}
+
+// forkOf returns the repository of the head branch if it's not the given repository
+func forkOf(repo string, pr *scm.PullRequest) (fork string) {
+	headRepo := pr.Head.Repo.FullName
+	if headRepo == "" {
+		headRepo = pr.Fork
+	}
+	if headRepo != "" && !strings.EqualFold(headRepo, repo) {
+		fork = headRepo
+	}
+	return
+}
+
+// CheckMergeGates returns the reasons why the pull request cannot be merged
+func (s *StatusMaker) CheckMergeGates(ctx context.Context, scmClient *scm.Client, pr *scm.PullRequest, opt MergeOptions) (failures []string, err error) {
+	if pr.Merged {
+		failures = append(failures, "it is already merged")
+		return
+	}
+	if pr.Closed {
+		failures = append(failures, "it is closed")
+		return
+	}
+	if pr.Draft {
+		failures = append(failures, "it is a draft")
+	}
+	if pr.MergeableState == scm.MergeableStateConflicting {
+		failures = append(failures, "it has conflicts with the base branch")
+	}
+
+	for _, label := range opt.RequireStatus {
+		var status *scm.Status
+		if status, err = s.FindPreviousStatus(ctx, scmClient, pr.Sha, label); err != nil {
+			return
+		}
+
+		if status == nil {
+			failures = append(failures, fmt.Sprintf("status %q is missing", label))
+		} else if status.State != scm.StateSuccess {
+			failures = append(failures, fmt.Sprintf("status %q is %s", label, status.State.String()))
+		}
+	}
+
+	if opt.MinApprovals > 0 {
+		var approvers []string
+		if approvers, err = s.listApprovers(ctx, scmClient, pr); err != nil {
+			return
+		}
+		if len(approvers) < opt.MinApprovals {
+			failures = append(failures, fmt.Sprintf("it has %d approval(s), requires %d", len(approvers), opt.MinApprovals))
+		}
+	}
+	return
+}
+
+// listApprovers returns the users who approved the pull request, only the latest review of each user counts
+func (s *StatusMaker) listApprovers(ctx context.Context, scmClient *scm.Client, pr *scm.PullRequest) (approvers []string, err error) {
+	if s.provider == "gitlab" {
+		approvers, err = listGitLabApprovers(ctx, scmClient, s.repo, pr.Number)
+		return
+	}
+
+	var reviews []*scm.Review
+	if reviews, err = listAllReviews(ctx, scmClient, s.repo, pr.Number); err != nil {
+		return
+	}
+
+	latest := make(map[string]string)
+	var logins []string
+	for _, review := range reviews {
+		login := review.Author.Login
+		if login == pr.Author.Login || review.State == scm.ReviewStateCommented || review.State == scm.ReviewStatePending {
+			continue
+		}
+		if _, ok := latest[login]; !ok {
+			logins = append(logins, login)
+		}
+		latest[login] = review.State
+	}
+
+	for _, login := range logins {
+		if latest[login] == scm.ReviewStateApproved {
+			approvers = append(approvers, login)
+		}
+	}
+	return
+}
+
+func listAllReviews(ctx context.Context, scmClient *scm.Client, repo string, number int) (reviews []*scm.Review, err error) {
+	opt := &scm.ListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Review
+		var resp *scm.Response
+		if items, resp, err = scmClient.Reviews.List(ctx, repo, number, opt); err != nil {
+			err = fmt.Errorf("failed to list the reviews: %v", err)
+			return
+		}
+		reviews = append(reviews, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+// listGitLabApprovers see also https://docs.gitlab.com/ee/api/merge_request_approvals.html
+func listGitLabApprovers(ctx context.Context, scmClient *scm.Client, repo string, number int) (approvers []string, err error) {
+	out := &struct {
+		ApprovedBy []struct {
+			User struct {
+				Username string `json:"username"`
+			} `json:"user"`
+		} `json:"approved_by"`
+	}{}
+	if err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("api/v4/projects/%s/merge_requests/%d/approvals",
+		gitlabProject(repo), number), nil, out); err != nil {
+		err = fmt.Errorf("failed to get the approvals: %v", err)
+		return
+	}
+
+	for _, item := range out.ApprovedBy {
+		approvers = append(approvers, item.User.Username)
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePullRequest(t *testing.T) {
+	opt := MergeOptions{
+		Method:        "squash",
+		RequireStatus: []string{"build", "test"},
+		MinApprovals:  2,
+		DeleteBranch:  true,
+	}
+
+	tests := []struct {
+		name     string
+		pr       *scm.PullRequest
+		statuses []*scm.Status
+		reviews  []*scm.Review
+		expect   []string
+	}{{
+		name: "all gates passed",
+		pr:   &scm.PullRequest{Number: 1, Sha: "sha", Source: "feature", Author: scm.User{Login: "author"}},
+		statuses: []*scm.Status{
+			{Label: "build", State: scm.StateSuccess},
+			{Label: "test", State: scm.StateSuccess},
+		},
+		reviews: []*scm.Review{
+			{Author: scm.User{Login: "a"}, State: scm.ReviewStateChangesRequested},
+			{Author: scm.User{Login: "a"}, State: scm.ReviewStateApproved},
+			{Author: scm.User{Login: "b"}, State: scm.ReviewStateApproved},
+			{Author: scm.User{Login: "b"}, State: scm.ReviewStateCommented},
+		},
+	}, {
+		name: "status failed and approvals are not enough",
+		pr:   &scm.PullRequest{Number: 1, Sha: "sha", Source: "feature", Author: scm.User{Login: "author"}},
+		statuses: []*scm.Status{
+			{Label: "build", State: scm.StateFailure},
+		},
+		reviews: []*scm.Review{
+			{Author: scm.User{Login: "author"}, State: scm.ReviewStateApproved},
+			{Author: scm.User{Login: "a"}, State: scm.ReviewStateApproved},
+			{Author: scm.User{Login: "b"}, State: scm.ReviewStateApproved},
+			{Author: scm.User{Login: "b"}, State: scm.ReviewStateChangesRequested},
+		},
+		expect: []string{`status "build" is failure`, `status "test" is missing`, "it has 1 approval(s), requires 2"},
+	}, {
+		name:   "already merged",
+		pr:     &scm.PullRequest{Number: 1, Merged: true},
+		expect: []string{"it is already merged"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, data := fake.NewDefault()
+			data.PullRequests[1] = tt.pr
+			data.Statuses["sha"] = tt.statuses
+			data.Reviews[1] = tt.reviews
+
+			result, err := NewStatusMaker("owner/repo", "").WithPR(1).WithClient(client).
+				MergePullRequest(context.Background(), opt)
+			if tt.expect == nil {
+				assert.NoError(t, err)
+				assert.True(t, data.PullRequests[1].Merged)
+				assert.Equal(t, MergeResult{DeletedBranch: "feature"}, result)
+				assert.Equal(t, []fake.DeletedRef{{Org: "owner", Repo: "repo", Ref: "heads/feature"}}, data.RefsDeleted)
+			} else {
+				gateErr, ok := err.(*MergeGateError)
+				if assert.True(t, ok, err) {
+					assert.Equal(t, tt.expect, gateErr.Failures)
+				}
+				assert.Empty(t, data.RefsDeleted)
+			}
+		})
+	}
+}
+
+func TestListGitLabApprovers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v4/projects/owner/repo/merge_requests/1/approvals" {
+			_, _ = fmt.Fprint(w, `{"approved_by": [{"user": {"username": "a"}}, {"user": {"username": "b"}}]}`)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("gitlab", server.URL, "token")
+	assert.NoError(t, err)
+
+	maker := NewStatusMaker("owner/repo", "").WithProvider("gitlab")
+	approvers, err := maker.listApprovers(context.Background(), client, &scm.PullRequest{Number: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, approvers)
+}
+
+func TestMergeGitLabRebase(t *testing.T) {
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{Number: 1, Sha: "sha"}
+
+	_, err := NewStatusMaker("owner/repo", "").WithPR(1).WithProvider("gitlab").WithClient(client).
+		MergePullRequest(context.Background(), MergeOptions{Method: "rebase"})
+	assert.ErrorContains(t, err, "not supported on GitLab")
+	assert.False(t, data.PullRequests[1].Merged)
+}
+
+func TestMergeForkPullRequest(t *testing.T) {
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{Number: 1, Sha: "sha", Source: "main",
+		Head: scm.PullRequestBranch{Ref: "main", Repo: scm.Repository{FullName: "someone/repo"}}}
+
+	result, err := NewStatusMaker("owner/repo", "").WithPR(1).WithClient(client).
+		MergePullRequest(context.Background(), MergeOptions{Method: "merge", DeleteBranch: true})
+	assert.NoError(t, err)
+	assert.True(t, data.PullRequests[1].Merged)
+	assert.Equal(t, MergeResult{Fork: "someone/repo"}, result)
+	assert.Empty(t, data.RefsDeleted)
+}
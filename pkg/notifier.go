@@ -0,0 +1,386 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"gopkg.in/yaml.v3"
+)
+
+// Message is the notification which will be sent to the channels
+type Message struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	Link  string `json:"link"`
+	// Recipients are the git logins of the involved users, the notifier could mention them
+	Recipients []string `json:"recipients,omitempty"`
+
+	// PullRequest is the optional source of the message, it's available in the webhook body template
+	PullRequest *scm.PullRequest `json:"pullRequest,omitempty"`
+	// Transition is the optional status change which triggers the message
+	Transition *StatusTransition `json:"transition,omitempty"`
+}
+
+// Notifier sends the message to a chat tool or a webhook
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Notifier kinds
+const (
+	NotifierDingDing = "dingding"
+	NotifierSlack    = "slack"
+	NotifierTeams    = "teams"
+	NotifierFeishu   = "feishu"
+	NotifierWeCom    = "wecom"
+	NotifierWebhook  = "webhook"
+	NotifierEmail    = "email"
+)
+
+// NotifyChannel is the config of a notifier
+type NotifyChannel struct {
+	Name string `yaml:"name" json:"name"`
+	// Kind is one of dingding, slack, teams, feishu, wecom, webhook and email
+	Kind    string `yaml:"kind" json:"kind"`
+	Webhook string `yaml:"webhook" json:"webhook"`
+	// Token is the access token of DingDing, or the key of WeCom. It's used when the webhook is empty
+	Token string `yaml:"token" json:"token"`
+
+	// Secret, MsgType, Mobiles and UserIDs are for DingDing, see also DingDingNotifier
+	Secret  string            `yaml:"secret" json:"secret"`
+	MsgType string            `yaml:"msgType" json:"msgType"`
+	Mobiles map[string]string `yaml:"mobiles" json:"mobiles"`
+	UserIDs map[string]string `yaml:"userIds" json:"userIds"`
+
+	// Method, Headers and Body are for the generic webhook, Body is a Go template of the Message
+	Method  string            `yaml:"method" json:"method"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	Body    string            `yaml:"body" json:"body"`
+
+	// SMTP is for the email, see also EmailNotifier
+	SMTP *SMTPConfig `yaml:"smtp" json:"smtp"`
+}
+
+// NotifyGroup notifies the channels when any of its members is involved
+type NotifyGroup struct {
+	Members  []string `yaml:"members" json:"members"`
+	Channels []string `yaml:"channels" json:"channels"`
+}
+
+// NotifyConfig selects the notify channels per user or per group
+type NotifyConfig struct {
+	Channels []NotifyChannel `yaml:"channels" json:"channels"`
+	// Users maps the git login to the channel names
+	Users  map[string][]string    `yaml:"users" json:"users"`
+	Groups map[string]NotifyGroup `yaml:"groups" json:"groups"`
+}
+
+// ParseNotifyConfig parses the notify config from YAML or JSON
+func ParseNotifyConfig(data []byte) (config *NotifyConfig, err error) {
+	config = &NotifyConfig{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		err = fmt.Errorf("cannot parse the notify config: %v", err)
+		return
+	}
+
+	names := make(map[string]struct{}, len(config.Channels))
+	for _, channel := range config.Channels {
+		if _, ok := names[channel.Name]; ok {
+			err = fmt.Errorf("the channel %q is duplicated", channel.Name)
+			return
+		}
+		names[channel.Name] = struct{}{}
+
+		if _, err = channel.Notifier(); err != nil {
+			return
+		}
+	}
+
+	for login, channels := range config.Users {
+		if err = checkChannelNames(names, channels); err != nil {
+			err = fmt.Errorf("invalid channels of user %q: %v", login, err)
+			return
+		}
+	}
+	for name, group := range config.Groups {
+		if err = checkChannelNames(names, group.Channels); err != nil {
+			err = fmt.Errorf("invalid channels of group %q: %v", name, err)
+			return
+		}
+	}
+	return
+}
+
+func checkChannelNames(names map[string]struct{}, channels []string) (err error) {
+	for _, channel := range channels {
+		if _, ok := names[channel]; !ok {
+			err = fmt.Errorf("channel %q is not found", channel)
+			return
+		}
+	}
+	return
+}
+
+// Resolve returns the channels of the users and the groups which they belong to, without duplicated ones
+func (c *NotifyConfig) Resolve(logins ...string) (channels []NotifyChannel) {
+	involved := make(map[string]struct{}, len(logins))
+	for _, login := range logins {
+		involved[login] = struct{}{}
+	}
+
+	var names []string
+	for _, login := range logins {
+		names = append(names, c.Users[login]...)
+	}
+	for _, group := range c.Groups {
+		for _, member := range group.Members {
+			if _, ok := involved[member]; ok {
+				names = append(names, group.Channels...)
+				break
+			}
+		}
+	}
+
+	selected := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		selected[name] = struct{}{}
+	}
+	for _, channel := range c.Channels {
+		if _, ok := selected[channel.Name]; ok {
+			channels = append(channels, channel)
+		}
+	}
+	return
+}
+
+// Notifier creates the notifier of the channel
+func (c NotifyChannel) Notifier() (notifier Notifier, err error) {
+	webhook := c.Webhook
+	switch c.Kind {
+	case NotifierDingDing:
+		if webhook == "" && c.Token != "" {
+			webhook = dingdingWebhook + c.Token
+		}
+		switch c.MsgType {
+		case "", DingDingText, DingDingMarkdown, DingDingActionCard, DingDingLink:
+		default:
+			err = fmt.Errorf("unsupported dingding message type %q of channel %q", c.MsgType, c.Name)
+			return
+		}
+		notifier = &DingDingNotifier{
+			Client:  &DingDingClient{Webhook: webhook, Secret: c.Secret},
+			MsgType: c.MsgType,
+			Mobiles: c.Mobiles,
+			UserIDs: c.UserIDs,
+		}
+	case NotifierWeCom:
+		if webhook == "" && c.Token != "" {
+			webhook = wecomWebhook + c.Token
+		}
+		notifier = &WeComNotifier{Webhook: webhook}
+	case NotifierSlack:
+		notifier = &SlackNotifier{Webhook: webhook}
+	case NotifierTeams:
+		notifier = &TeamsNotifier{Webhook: webhook}
+	case NotifierFeishu:
+		notifier = &FeishuNotifier{Webhook: webhook}
+	case NotifierWebhook:
+		notifier, err = NewWebhookNotifier(webhook, c.Method, c.Headers, c.Body)
+	case NotifierEmail:
+		if c.SMTP == nil {
+			err = fmt.Errorf("the smtp of channel %q is empty", c.Name)
+		} else if notifier, err = NewEmailNotifier(*c.SMTP); err != nil {
+			err = fmt.Errorf("invalid smtp of channel %q: %v", c.Name, err)
+		}
+		return
+	default:
+		err = fmt.Errorf("unsupported notifier kind %q of channel %q", c.Kind, c.Name)
+		return
+	}
+
+	if err == nil && webhook == "" {
+		err = fmt.Errorf("the webhook of channel %q is empty", c.Name)
+	}
+	return
+}
+
+// WebhookNotifier sends the message to a generic webhook
+type WebhookNotifier struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	body    *template.Template
+}
+
+// NewWebhookNotifier creates a generic webhook notifier, the message will be sent as JSON if the body template is empty
+func NewWebhookNotifier(url, method string, headers map[string]string, body string) (notifier *WebhookNotifier, err error) {
+	if method == "" {
+		method = http.MethodPost
+	}
+	notifier = &WebhookNotifier{URL: url, Method: method, Headers: headers}
+	if body != "" {
+		if notifier.body, err = template.New("body").Parse(body); err != nil {
+			err = fmt.Errorf("invalid webhook body template: %v", err)
+		}
+	}
+	return
+}
+
+// Notify sends the message
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) (err error) {
+	var body []byte
+	if n.body == nil {
+		if body, err = json.Marshal(msg); err != nil {
+			return
+		}
+	} else {
+		buf := new(bytes.Buffer)
+		if err = n.body.Execute(buf, msg); err != nil {
+			err = fmt.Errorf("cannot render the webhook body: %v", err)
+			return
+		}
+		body = buf.Bytes()
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for key, val := range n.Headers {
+		headers[key] = val
+	}
+	_, err = sendRequest(ctx, n.Method, n.URL, headers, body)
+	return
+}
+
+// postJSON sends the payload to the webhook, and parses the response into out if it's not nil
+func postJSON(ctx context.Context, url string, payload, out any) (err error) {
+	var data []byte
+	if data, err = json.Marshal(payload); err != nil {
+		return
+	}
+
+	var body []byte
+	if body, err = sendRequest(ctx, http.MethodPost, url, map[string]string{
+		"Content-Type": "application/json",
+	}, data); err == nil && out != nil {
+		if err = json.Unmarshal(body, out); err != nil {
+			err = fmt.Errorf("cannot parse the response %q: %v", string(body), err)
+		}
+	}
+	return
+}
+
+func sendRequest(ctx context.Context, method, url string, headers map[string]string, data []byte) (body []byte, err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data)); err != nil {
+		return
+	}
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+
+	var resp *http.Response
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if body, err = io.ReadAll(resp.Body); err == nil && resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("received code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return
+}
+
+// SendNotifications sends the message through all the notifiers concurrently, the failures are joined
+func SendNotifications(ctx context.Context, notifiers map[string]Notifier, msg Message, w io.Writer) (err error) {
+	names := make([]string, 0, len(notifiers))
+	for name := range notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := make([]error, len(names))
+	var wait sync.WaitGroup
+	for i, name := range names {
+		wait.Add(1)
+		go func(i int, name string) {
+			defer wait.Done()
+			if notifyErr := notifiers[name].Notify(ctx, msg); notifyErr != nil {
+				errs[i] = fmt.Errorf("send message to %q failed: %v", name, notifyErr)
+			}
+		}(i, name)
+	}
+	wait.Wait()
+
+	for i, name := range names {
+		if errs[i] == nil {
+			fmt.Fprintf(w, "send message to %q successfully\n", name)
+		}
+	}
+	err = errors.Join(errs...)
+	return
+}
+
+// NotifyPullRequest notifies the author, reviewers and assignees of the pull request
+func (s *StatusMaker) NotifyPullRequest(ctx context.Context, resolver *NotifyResolver, text string, w io.Writer) (err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var pr *scm.PullRequest
+	if pr, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err != nil {
+		err = fmt.Errorf("failed to find pull request [%d] from [%s] %v", s.pr, s.repo, err)
+		return
+	}
+
+	var recipients []string
+	var notifiers map[string]Notifier
+	if recipients, notifiers, err = resolver.Resolve(PullRequestLogins(pr), time.Now()); err != nil {
+		return
+	}
+
+	link := s.target
+	if link == "" {
+		link = pr.Link
+	}
+	err = SendNotifications(ctx, notifiers, Message{
+		Title:       pr.Title,
+		Text:        text,
+		Link:        link,
+		Recipients:  recipients,
+		PullRequest: pr,
+	}, w)
+	return
+}
+
+// PullRequestLogins returns the logins of the author, reviewers and assignees without duplicated ones
+func PullRequestLogins(pr *scm.PullRequest) (logins []string) {
+	users := pullRequestUsers(pr)
+	found := make(map[string]struct{}, len(users))
+	for _, user := range users {
+		if _, ok := found[user.Login]; ok || user.Login == "" {
+			continue
+		}
+		found[user.Login] = struct{}{}
+		logins = append(logins, user.Login)
+	}
+	return
+}
+
+func pullRequestUsers(pr *scm.PullRequest) (users []scm.User) {
+	users = append([]scm.User{pr.Author}, pr.Reviewers...)
+	users = append(users, pr.Assignees...)
+	return
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+)
+
+const wecomWebhook = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key="
+
+// SlackNotifier sends the message to a Slack incoming webhook
+//
+// See also https://api.slack.com/messaging/webhooks
+type SlackNotifier struct {
+	Webhook string
+}
+
+// Notify sends the message
+func (n *SlackNotifier) Notify(ctx context.Context, msg Message) error {
+	return postJSON(ctx, n.Webhook, map[string]string{"text": msg.Text}, nil)
+}
+
+// TeamsNotifier sends the message to a Microsoft Teams incoming webhook
+//
+// See also https://learn.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using
+type TeamsNotifier struct {
+	Webhook string
+}
+
+// Notify sends the message
+func (n *TeamsNotifier) Notify(ctx context.Context, msg Message) error {
+	payload := map[string]any{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  msg.Title,
+		"title":    msg.Title,
+		"text":     msg.Text,
+	}
+	if msg.Link != "" {
+		payload["potentialAction"] = []map[string]any{{
+			"@type":   "OpenUri",
+			"name":    "View",
+			"targets": []map[string]string{{"os": "default", "uri": msg.Link}},
+		}}
+	}
+	return postJSON(ctx, n.Webhook, payload, nil)
+}
+
+// FeishuNotifier sends the message to a Feishu/Lark custom bot
+//
+// See also https://open.feishu.cn/document/client-docs/bot-v3/add-custom-bot
+type FeishuNotifier struct {
+	Webhook string
+}
+
+// Notify sends the message
+func (n *FeishuNotifier) Notify(ctx context.Context, msg Message) (err error) {
+	resp := &struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}{}
+	if err = postJSON(ctx, n.Webhook, map[string]any{
+		"msg_type": "text",
+		"content":  map[string]string{"text": msg.Text},
+	}, resp); err == nil && resp.Code != 0 {
+		err = fmt.Errorf("receive error response from feishu: %d %s", resp.Code, resp.Msg)
+	}
+	return
+}
+
+// WeComNotifier sends the message to a WeCom group robot
+//
+// See also https://developer.work.weixin.qq.com/document/path/91770
+type WeComNotifier struct {
+	Webhook string
+}
+
+// Notify sends the message
+func (n *WeComNotifier) Notify(ctx context.Context, msg Message) (err error) {
+	resp := &struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}{}
+	if err = postJSON(ctx, n.Webhook, map[string]any{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg.Text},
+	}, resp); err == nil && resp.ErrCode != 0 {
+		err = fmt.Errorf("receive error response from wecom: %d %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return
+}
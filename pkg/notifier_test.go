@@ -0,0 +1,194 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNotifyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		hasErr  bool
+		resolve []string
+		expect  []string
+	}{{
+		name: "normal",
+		data: `
+channels:
+  - name: rick
+    kind: dingding
+    token: token
+  - name: backend
+    kind: slack
+    webhook: https://hooks.slack.com/services/xxx
+  - name: frontend
+    kind: teams
+    webhook: https://teams/xxx
+users:
+  rick: [rick, backend]
+groups:
+  backend:
+    members: [rick, bob]
+    channels: [backend]
+  frontend:
+    members: [alice]
+    channels: [frontend]
+`,
+		resolve: []string{"rick", "bob"},
+		expect:  []string{"rick", "backend"},
+	}, {
+		name:    "group only",
+		data:    `{"channels": [{"name": "a", "kind": "feishu", "webhook": "https://feishu"}], "groups": {"g": {"members": ["bob"], "channels": ["a"]}}}`,
+		resolve: []string{"bob"},
+		expect:  []string{"a"},
+	}, {
+		name:   "unknown channel",
+		data:   `{"channels": [{"name": "a", "kind": "slack", "webhook": "https://slack"}], "users": {"rick": ["b"]}}`,
+		hasErr: true,
+	}, {
+		name:   "unsupported kind",
+		data:   `{"channels": [{"name": "a", "kind": "unknown"}]}`,
+		hasErr: true,
+	}, {
+		name:   "empty webhook",
+		data:   `{"channels": [{"name": "a", "kind": "slack"}]}`,
+		hasErr: true,
+	}, {
+		name:   "duplicated channel",
+		data:   `{"channels": [{"name": "a", "kind": "wecom", "token": "key"}, {"name": "a", "kind": "wecom", "token": "key"}]}`,
+		hasErr: true,
+	}, {
+		name:   "invalid webhook body",
+		data:   `{"channels": [{"name": "a", "kind": "webhook", "webhook": "https://hook", "body": "{{.Text"}]}`,
+		hasErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseNotifyConfig([]byte(tt.data))
+			if tt.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var names []string
+			for _, channel := range config.Resolve(tt.resolve...) {
+				names = append(names, channel.Name)
+			}
+			assert.Equal(t, tt.expect, names)
+		})
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	var payload map[string]any
+	var header http.Header
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		data, _ := io.ReadAll(r.Body)
+		payload = map[string]any{}
+		_ = json.Unmarshal(data, &payload)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+
+	msg := Message{Title: "title", Text: "hello \"world\"\n", Link: "https://link", PullRequest: &scm.PullRequest{Number: 1}}
+	tests := []struct {
+		name     string
+		channel  NotifyChannel
+		response string
+		hasErr   bool
+		verify   func(t *testing.T)
+	}{{
+		name:     "dingding",
+		channel:  NotifyChannel{Kind: NotifierDingDing, Webhook: server.URL},
+		response: `{"errcode": 0}`,
+		verify: func(t *testing.T) {
+			assert.Equal(t, map[string]any{"msgtype": "text", "text": map[string]any{"content": msg.Text}}, payload)
+		},
+	}, {
+		name:     "dingding error",
+		channel:  NotifyChannel{Kind: NotifierDingDing, Webhook: server.URL},
+		response: `{"errcode": 310000, "errmsg": "keywords not in content"}`,
+		hasErr:   true,
+	}, {
+		name:    "slack",
+		channel: NotifyChannel{Kind: NotifierSlack, Webhook: server.URL},
+		verify: func(t *testing.T) {
+			assert.Equal(t, map[string]any{"text": msg.Text}, payload)
+		},
+	}, {
+		name:    "slack error",
+		channel: NotifyChannel{Kind: NotifierSlack, Webhook: server.URL + "/fail"},
+		hasErr:  true,
+	}, {
+		name:    "teams",
+		channel: NotifyChannel{Kind: NotifierTeams, Webhook: server.URL},
+		verify: func(t *testing.T) {
+			assert.Equal(t, "MessageCard", payload["@type"])
+			assert.Equal(t, "title", payload["title"])
+			assert.NotNil(t, payload["potentialAction"])
+		},
+	}, {
+		name:     "feishu",
+		channel:  NotifyChannel{Kind: NotifierFeishu, Webhook: server.URL},
+		response: `{"code": 0, "msg": "success"}`,
+		verify: func(t *testing.T) {
+			assert.Equal(t, map[string]any{"msg_type": "text", "content": map[string]any{"text": msg.Text}}, payload)
+		},
+	}, {
+		name:     "feishu error",
+		channel:  NotifyChannel{Kind: NotifierFeishu, Webhook: server.URL},
+		response: `{"code": 19021, "msg": "sign match fail"}`,
+		hasErr:   true,
+	}, {
+		name:     "wecom",
+		channel:  NotifyChannel{Kind: NotifierWeCom, Webhook: server.URL},
+		response: `{"errcode": 0, "errmsg": "ok"}`,
+		verify: func(t *testing.T) {
+			assert.Equal(t, map[string]any{"msgtype": "text", "text": map[string]any{"content": msg.Text}}, payload)
+		},
+	}, {
+		name: "webhook with template",
+		channel: NotifyChannel{Kind: NotifierWebhook, Webhook: server.URL, Headers: map[string]string{"X-Token": "token"},
+			Body: `{"pr": {{.PullRequest.Number}}, "link": "{{.Link}}"}`},
+		verify: func(t *testing.T) {
+			assert.Equal(t, map[string]any{"pr": float64(1), "link": "https://link"}, payload)
+			assert.Equal(t, "token", header.Get("X-Token"))
+		},
+	}, {
+		name:    "webhook without template",
+		channel: NotifyChannel{Kind: NotifierWebhook, Webhook: server.URL},
+		verify: func(t *testing.T) {
+			assert.Equal(t, "title", payload["title"])
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response = tt.response
+			notifier, err := tt.channel.Notifier()
+			assert.NoError(t, err)
+
+			err = notifier.Notify(context.Background(), msg)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				tt.verify(t)
+			}
+		})
+	}
+}
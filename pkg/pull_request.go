@@ -0,0 +1,362 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// PullRequestInput is the input for creating or updating a pull request
+type PullRequestInput struct {
+	Head      string
+	Base      string
+	Title     string
+	Body      string
+	Draft     bool
+	Reviewers []string
+	Assignees []string
+	Labels    []string
+}
+
+// DefaultBranch returns the default branch of the repository
+func (s *StatusMaker) DefaultBranch(ctx context.Context) (branch string, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var repo *scm.Repository
+	if repo, _, err = scmClient.Repositories.Find(ctx, s.repo); err != nil {
+		err = fmt.Errorf("failed to find the default branch of %q: %v", s.repo, err)
+		return
+	}
+	branch = repo.Branch
+	return
+}
+
+// CreateOrUpdatePullRequest creates a pull request from the head branch, or updates the existing open one
+func (s *StatusMaker) CreateOrUpdatePullRequest(ctx context.Context, input PullRequestInput) (pr *scm.PullRequest, created bool, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	if input.Head == "" || input.Title == "" {
+		err = errors.New("the head branch and title of the pull request are required")
+		return
+	}
+
+	if input.Base == "" {
+		if input.Base, err = s.DefaultBranch(ctx); err != nil {
+			return
+		}
+	}
+
+	var existing *scm.PullRequest
+	if existing, err = s.findOpenPullRequestByHead(ctx, scmClient, input.Head); err != nil {
+		return
+	}
+
+	title := input.Title
+	if input.Draft && s.provider == "gitlab" {
+		// GitLab takes the title prefix as the draft flag
+		title = "Draft: " + title
+	}
+	prInput := &scm.PullRequestInput{
+		Title: title,
+		Head:  input.Head,
+		Base:  input.Base,
+		Body:  input.Body,
+	}
+
+	if existing != nil {
+		if pr, _, err = scmClient.PullRequests.Update(ctx, s.repo, existing.Number, prInput); err != nil {
+			err = fmt.Errorf("failed to update pull request %d: %v", existing.Number, err)
+			return
+		}
+	} else {
+		if input.Draft && s.provider == "github" {
+			pr, err = createGitHubDraftPullRequest(ctx, scmClient, s.repo, prInput)
+		} else {
+			pr, _, err = scmClient.PullRequests.Create(ctx, s.repo, prInput)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to create pull request from %q to %q: %v", input.Head, input.Base, err)
+			return
+		}
+		created = true
+	}
+
+	if len(input.Reviewers) > 0 {
+		if _, reviewErr := scmClient.PullRequests.RequestReview(ctx, s.repo, pr.Number, input.Reviewers); reviewErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to request reviewers: %v", reviewErr))
+		}
+	}
+	if len(input.Assignees) > 0 {
+		if _, assignErr := scmClient.PullRequests.AssignIssue(ctx, s.repo, pr.Number, input.Assignees); assignErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to assign: %v", assignErr))
+		}
+	}
+	for _, label := range input.Labels {
+		if _, labelErr := scmClient.PullRequests.AddLabel(ctx, s.repo, pr.Number, label); labelErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add label %q: %v", label, labelErr))
+		}
+	}
+	return
+}
+
+// findOpenPullRequestByHead returns the open pull request from the head branch. The head is a branch of the
+// repository, or a branch of a fork in the owner:branch form
+func (s *StatusMaker) findOpenPullRequestByHead(ctx context.Context, scmClient *scm.Client, head string) (pr *scm.PullRequest, err error) {
+	owner, branch := splitHead(s.repo, head)
+	if s.provider == "github" {
+		pr, err = findGitHubPullRequestByHead(ctx, scmClient, s.repo, owner+":"+branch)
+		return
+	}
+
+	var prs []*scm.PullRequest
+	if prs, err = listAllPullRequests(ctx, scmClient, s.repo, &scm.PullRequestListOptions{Open: true}); err != nil {
+		return
+	}
+
+	for _, item := range prs {
+		if item.Closed || item.Merged || (item.Head.Ref != branch && item.Source != branch) {
+			continue
+		}
+
+		headOwner := repoOwner(s.repo)
+		if fork := forkOf(s.repo, item); fork != "" {
+			headOwner = repoOwner(fork)
+		}
+		if strings.EqualFold(headOwner, owner) {
+			pr = item
+			break
+		}
+	}
+	return
+}
+
+// splitHead returns the owner and the branch of the head, the owner is the owner of the repository by default
+func splitHead(repo, head string) (owner, branch string) {
+	var ok bool
+	if owner, branch, ok = strings.Cut(head, ":"); !ok {
+		owner, branch = repoOwner(repo), head
+	}
+	return
+}
+
+// repoOwner returns the owner of the repository, it's the namespace of a GitLab project
+func repoOwner(repo string) string {
+	return repo[:max(strings.LastIndex(repo, "/"), 0)]
+}
+
+// findGitHubPullRequestByHead finds the open pull request by the head filter of GitHub, the head is in the
+// owner:branch form
+//
+// See also https://docs.github.com/en/rest/pulls/pulls#list-pull-requests
+func findGitHubPullRequestByHead(ctx context.Context, scmClient *scm.Client, repo, head string) (pr *scm.PullRequest, err error) {
+	var items []struct {
+		Number int `json:"number"`
+	}
+	if err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("repos/%s/pulls?state=open&head=%s",
+		repo, url.QueryEscape(head)), nil, &items); err != nil {
+		err = fmt.Errorf("failed to find the pull request from %q: %v", head, err)
+		return
+	}
+	if len(items) > 0 {
+		pr = &scm.PullRequest{Number: items[0].Number}
+	}
+	return
+}
+
+func listAllPullRequests(ctx context.Context, scmClient *scm.Client, repo string, opt *scm.PullRequestListOptions) (prs []*scm.PullRequest, err error) {
+	opt.Page, opt.Size = 1, 100
+	for {
+		var items []*scm.PullRequest
+		var resp *scm.Response
+		if items, resp, err = scmClient.PullRequests.List(ctx, repo, opt); err != nil {
+			err = fmt.Errorf("failed to list the pull requests of %q: %v", repo, err)
+			return
+		}
+		prs = append(prs, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+// createGitHubDraftPullRequest creates a draft pull request, go-scm does not support the draft flag
+//
+// See also https://docs.github.com/en/rest/pulls/pulls#create-a-pull-request
+func createGitHubDraftPullRequest(ctx context.Context, scmClient *scm.Client, repo string, input *scm.PullRequestInput) (pr *scm.PullRequest, err error) {
+	out := &struct {
+		Number int `json:"number"`
+	}{}
+	if err = requestJSON(ctx, scmClient, http.MethodPost, fmt.Sprintf("repos/%s/pulls", repo), map[string]any{
+		"title": input.Title,
+		"head":  input.Head,
+		"base":  input.Base,
+		"body":  input.Body,
+		"draft": true,
+	}, out); err == nil {
+		pr, _, err = scmClient.PullRequests.Find(ctx, repo, out.Number)
+	}
+	return
+}
+
+// PullRequestFilter is the filter of listing pull requests
+type PullRequestFilter struct {
+	// State is one of open, closed, merged and all
+	State   string
+	Author  string
+	Labels  []string
+	Base    string
+	HeadSha string
+	// CreatedAfter and CreatedBefore are the range of the creation time, zero means no limit
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// PullRequestStates are the supported states of the pull request filter
+var PullRequestStates = []string{"open", "closed", "merged", "all"}
+
+// Match returns true if the pull request matches the filter
+func (f PullRequestFilter) Match(pr *scm.PullRequest) bool {
+	switch f.State {
+	case "open":
+		if pr.Closed || pr.Merged {
+			return false
+		}
+	case "closed":
+		if !pr.Closed || pr.Merged {
+			return false
+		}
+	case "merged":
+		if !pr.Merged {
+			return false
+		}
+	}
+
+	if f.Author != "" && pr.Author.Login != f.Author {
+		return false
+	}
+	if f.Base != "" && pr.Base.Ref != f.Base && pr.Target != f.Base {
+		return false
+	}
+	if f.HeadSha != "" && !strings.HasPrefix(pr.Sha, f.HeadSha) && !strings.HasPrefix(pr.Head.Sha, f.HeadSha) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && pr.Created.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && pr.Created.After(f.CreatedBefore) {
+		return false
+	}
+
+	for _, label := range f.Labels {
+		found := false
+		for _, item := range pr.Labels {
+			if item.Name == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ListPullRequests lists all the pull requests which match the filter
+func (s *StatusMaker) ListPullRequests(ctx context.Context, filter PullRequestFilter) (prs []*scm.PullRequest, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	opt := &scm.PullRequestListOptions{
+		Open:   filter.State == "open" || filter.State == "all" || filter.State == "",
+		Closed: filter.State != "open",
+	}
+	if !filter.CreatedAfter.IsZero() {
+		opt.CreatedAfter = &filter.CreatedAfter
+	}
+	if !filter.CreatedBefore.IsZero() {
+		opt.CreatedBefore = &filter.CreatedBefore
+	}
+	if s.provider == "gitlab" {
+		// only GitLab supports filtering the labels on the server side
+		opt.Labels = filter.Labels
+	}
+
+	var items []*scm.PullRequest
+	if items, err = listAllPullRequests(ctx, scmClient, s.repo, opt); err != nil {
+		return
+	}
+
+	for _, pr := range items {
+		if filter.Match(pr) {
+			prs = append(prs, pr)
+		}
+	}
+	return
+}
+
+// FindPullRequestsBySha finds the pull requests which contain the commit
+func (s *StatusMaker) FindPullRequestsBySha(ctx context.Context, sha string) (prs []*scm.PullRequest, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var numbers []int
+	switch s.provider {
+	case "github":
+		// see also https://docs.github.com/en/rest/commits/commits#list-pull-requests-associated-with-a-commit
+		var out []struct {
+			Number int `json:"number"`
+		}
+		err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("repos/%s/commits/%s/pulls", s.repo, sha), nil, &out)
+		for _, item := range out {
+			numbers = append(numbers, item.Number)
+		}
+	case "gitlab":
+		// see also https://docs.gitlab.com/ee/api/commits.html#list-merge-requests-associated-with-a-commit
+		var out []struct {
+			IID int `json:"iid"`
+		}
+		err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("api/v4/projects/%s/repository/commits/%s/merge_requests",
+			gitlabProject(s.repo), sha), nil, &out)
+		for _, item := range out {
+			numbers = append(numbers, item.IID)
+		}
+	default:
+		// only the head commit of the pull requests can be matched without the provider API
+		prs, err = s.ListPullRequests(ctx, PullRequestFilter{State: "all", HeadSha: sha})
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to find the pull requests of commit %q: %v", sha, err)
+		return
+	}
+
+	for _, number := range numbers {
+		var pr *scm.PullRequest
+		if pr, _, err = scmClient.PullRequests.Find(ctx, s.repo, number); err != nil {
+			err = fmt.Errorf("failed to find pull request %d: %v", number, err)
+			return
+		}
+		prs = append(prs, pr)
+	}
+	return
+}
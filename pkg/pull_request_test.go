@@ -0,0 +1,219 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOrUpdatePullRequest(t *testing.T) {
+	ctx := context.Background()
+	client, data := fake.NewDefault()
+	maker := NewStatusMaker("owner/repo", "").WithClient(client)
+
+	_, _, err := maker.CreateOrUpdatePullRequest(ctx, PullRequestInput{Head: "feature"})
+	assert.Error(t, err)
+
+	// the pull request from the branch with the same name of a fork
+	data.PullRequests[100] = &scm.PullRequest{Number: 100, Title: "fork", Source: "feature",
+		Base: scm.PullRequestBranch{Ref: "develop", Repo: scm.Repository{FullName: "owner/repo"}},
+		Head: scm.PullRequestBranch{Ref: "feature", Repo: scm.Repository{FullName: "someone/repo"}}}
+
+	pr, created, err := maker.CreateOrUpdatePullRequest(ctx, PullRequestInput{
+		Head:      "feature",
+		Base:      "master",
+		Title:     "feat: first",
+		Body:      "body",
+		Assignees: []string{"linuxsuren"},
+		Labels:    []string{"enhancement"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "feat: first", pr.Title)
+	assert.Equal(t, []string{fmt.Sprintf("owner/repo#%d:linuxsuren", pr.Number)}, data.AssigneesAdded)
+	assert.Equal(t, []string{fmt.Sprintf("owner/repo#%d:enhancement", pr.Number)}, data.PullRequestLabelsAdded)
+
+	// the open pull request from the same head branch will be updated
+	updated, created, err := maker.CreateOrUpdatePullRequest(ctx, PullRequestInput{
+		Head:  "feature",
+		Base:  "master",
+		Title: "feat: second",
+	})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, pr.Number, updated.Number)
+	assert.Equal(t, "feat: second", data.PullRequests[pr.Number].Title)
+	assert.Equal(t, "fork", data.PullRequests[100].Title)
+	assert.Len(t, data.PullRequests, 2)
+
+	// the head of a fork is in the owner:branch form
+	updated, created, err = maker.CreateOrUpdatePullRequest(ctx, PullRequestInput{
+		Head:  "someone:feature",
+		Base:  "develop",
+		Title: "feat: fork",
+	})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, 100, updated.Number)
+	assert.Equal(t, "feat: fork", data.PullRequests[100].Title)
+}
+
+func TestFindGitHubPullRequestByHead(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/repos/owner/repo/pulls", r.URL.Path)
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"number": 3}]`))
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+	pr, err := NewStatusMaker("owner/repo", "").WithProvider("github").WithClient(client).
+		findOpenPullRequestByHead(context.Background(), client, "feature")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pr.Number)
+	assert.Equal(t, "state=open&head=owner%3Afeature", query)
+}
+
+func TestCreateGitHubDraftPullRequest(t *testing.T) {
+	var draft bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/pulls":
+			_, _ = fmt.Fprint(w, `[]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/owner/repo/pulls":
+			payload := map[string]any{}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			draft, _ = payload["draft"].(bool)
+			_, _ = fmt.Fprint(w, `{"number": 3}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/owner/repo/pulls/3":
+			_, _ = fmt.Fprint(w, `{"number": 3, "title": "wip", "draft": true}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+
+	var pr *scm.PullRequest
+	var created bool
+	pr, created, err = NewStatusMaker("owner/repo", "").WithProvider("github").WithClient(client).
+		CreateOrUpdatePullRequest(context.Background(), PullRequestInput{
+			Head:  "feature",
+			Base:  "master",
+			Title: "wip",
+			Draft: true,
+		})
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, draft)
+	assert.Equal(t, 3, pr.Number)
+}
+
+func TestListPullRequests(t *testing.T) {
+	now := time.Now()
+	repo := scm.Repository{FullName: "owner/repo"}
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{Number: 1, Sha: "abc1", Base: scm.PullRequestBranch{Ref: "master", Repo: repo},
+		Author: scm.User{Login: "rick"}, Labels: []*scm.Label{{Name: "bug"}}, Created: now.Add(-time.Hour)}
+	data.PullRequests[2] = &scm.PullRequest{Number: 2, Sha: "abc2", Base: scm.PullRequestBranch{Ref: "dev", Repo: repo},
+		Author: scm.User{Login: "rick"}, Created: now.Add(-48 * time.Hour)}
+	data.PullRequests[3] = &scm.PullRequest{Number: 3, Sha: "def3", Base: scm.PullRequestBranch{Ref: "master", Repo: repo},
+		Author: scm.User{Login: "bot"}, Closed: true, Merged: true, Created: now.Add(-time.Hour)}
+	data.PullRequests[4] = &scm.PullRequest{Number: 4, Sha: "def4", Base: scm.PullRequestBranch{Ref: "master", Repo: repo},
+		Author: scm.User{Login: "bot"}, Closed: true, Created: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name   string
+		filter PullRequestFilter
+		expect []int
+	}{{
+		name:   "open",
+		filter: PullRequestFilter{State: "open"},
+		expect: []int{1, 2},
+	}, {
+		name:   "merged",
+		filter: PullRequestFilter{State: "merged"},
+		expect: []int{3},
+	}, {
+		name:   "closed",
+		filter: PullRequestFilter{State: "closed"},
+		expect: []int{4},
+	}, {
+		name:   "author and base",
+		filter: PullRequestFilter{State: "all", Author: "rick", Base: "master"},
+		expect: []int{1},
+	}, {
+		name:   "label",
+		filter: PullRequestFilter{State: "all", Labels: []string{"bug"}},
+		expect: []int{1},
+	}, {
+		name:   "head sha prefix",
+		filter: PullRequestFilter{State: "all", HeadSha: "def"},
+		expect: []int{3, 4},
+	}, {
+		name:   "created after",
+		filter: PullRequestFilter{State: "open", CreatedAfter: now.Add(-24 * time.Hour)},
+		expect: []int{1},
+	}, {
+		name:   "created before",
+		filter: PullRequestFilter{State: "all", CreatedBefore: now.Add(-24 * time.Hour)},
+		expect: []int{2},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs, err := NewStatusMaker("owner/repo", "").WithClient(client).ListPullRequests(context.Background(), tt.filter)
+			assert.NoError(t, err)
+
+			var numbers []int
+			for _, pr := range prs {
+				numbers = append(numbers, pr.Number)
+			}
+			assert.Equal(t, tt.expect, numbers)
+		})
+	}
+}
+
+func TestFindPullRequestsBySha(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/commits/abc/pulls":
+			_, _ = fmt.Fprint(w, `[{"number": 3}]`)
+		case "/api/v3/repos/owner/repo/pulls/3":
+			_, _ = fmt.Fprint(w, `{"number": 3, "title": "fix"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+
+	prs, err := NewStatusMaker("owner/repo", "").WithProvider("github").WithClient(client).
+		FindPullRequestsBySha(context.Background(), "abc")
+	assert.NoError(t, err)
+	if assert.Len(t, prs, 1) {
+		assert.Equal(t, "fix", prs[0].Title)
+	}
+
+	fakeClient, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{Number: 1, Sha: "abc", Base: scm.PullRequestBranch{Repo: scm.Repository{FullName: "owner/repo"}}}
+	prs, err = NewStatusMaker("owner/repo", "").WithProvider("gitea").WithClient(fakeClient).
+		FindPullRequestsBySha(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Len(t, prs, 1)
+}
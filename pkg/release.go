@@ -0,0 +1,449 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// DefaultChecksumFile is the name of the checksum asset of the uploaded files
+const DefaultChecksumFile = "checksums.txt"
+
+// ReleaseOptions is the options for creating or updating a release. The empty Title and Notes, and the nil Draft and
+// Prerelease, keep the values of the existing release
+type ReleaseOptions struct {
+	Tag   string
+	Title string
+	Notes string
+	// Commitish is the branch or commit which the tag is created from if the tag does not exist
+	Commitish string
+	// Draft and Prerelease are not supported by GitLab
+	Draft      *bool
+	Prerelease *bool
+}
+
+// ReleaseAsset is an uploaded file of a release
+type ReleaseAsset struct {
+	Name   string `json:"name" yaml:"name"`
+	URL    string `json:"url" yaml:"url"`
+	Size   int    `json:"size" yaml:"size"`
+	Sha256 string `json:"sha256" yaml:"sha256"`
+}
+
+// isNotFound returns true if the error or the response means the resource does not exist
+func isNotFound(resp *scm.Response, err error) bool {
+	return errors.Is(err, scm.ErrNotFound) || (err != nil && resp != nil && resp.Status == http.StatusNotFound)
+}
+
+// FindRelease finds the release of the tag, the draft releases are included. The release is nil if it does not exist
+func (s *StatusMaker) FindRelease(ctx context.Context, tag string) (release *scm.Release, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var resp *scm.Response
+	if release, resp, err = scmClient.Releases.FindByTag(ctx, s.repo, tag); err == nil {
+		return
+	}
+	release = nil
+	if !isNotFound(resp, err) {
+		err = fmt.Errorf("failed to find the release of %q: %v", tag, err)
+		return
+	}
+
+	// the draft releases of GitHub cannot be found by tag
+	var releases []*scm.Release
+	if releases, err = s.ListReleases(ctx); err == nil {
+		for _, item := range releases {
+			if item.Tag == tag {
+				release = item
+				break
+			}
+		}
+	}
+	return
+}
+
+// ListReleases lists all the releases of the repository
+func (s *StatusMaker) ListReleases(ctx context.Context) (releases []*scm.Release, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	opt := scm.ReleaseListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Release
+		var resp *scm.Response
+		if items, resp, err = scmClient.Releases.List(ctx, s.repo, opt); err != nil {
+			if isNotFound(resp, err) {
+				err = nil
+				break
+			}
+			err = fmt.Errorf("failed to list the releases of %q: %v", s.repo, err)
+			return
+		}
+		releases = append(releases, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+// CreateRelease creates the release of the tag, or updates it if the tag already has a release
+func (s *StatusMaker) CreateRelease(ctx context.Context, opt ReleaseOptions) (release *scm.Release, created bool, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var existing *scm.Release
+	if existing, err = s.FindRelease(ctx, opt.Tag); err != nil {
+		return
+	}
+
+	input := &scm.ReleaseInput{
+		Title:       emptyThen(opt.Title, opt.Tag),
+		Description: opt.Notes,
+		Tag:         opt.Tag,
+		Commitish:   opt.Commitish,
+	}
+	if existing != nil {
+		// only override the fields which are given
+		input.Title = emptyThen(opt.Title, existing.Title)
+		input.Description = emptyThen(opt.Notes, existing.Description)
+		input.Draft = existing.Draft
+		input.Prerelease = existing.Prerelease
+	}
+	if opt.Draft != nil {
+		input.Draft = *opt.Draft
+	}
+	if opt.Prerelease != nil {
+		input.Prerelease = *opt.Prerelease
+	}
+	switch {
+	case existing == nil && s.provider == "gitlab":
+		// go-scm does not send the ref, it's required when the tag does not exist
+		// see also https://docs.gitlab.com/ee/api/releases/#create-a-release
+		created = true
+		err = requestJSON(ctx, scmClient, http.MethodPost, fmt.Sprintf("api/v4/projects/%s/releases", gitlabProject(s.repo)),
+			map[string]string{
+				"name":        input.Title,
+				"tag_name":    input.Tag,
+				"description": input.Description,
+				"ref":         input.Commitish,
+			}, nil)
+	case existing == nil:
+		created = true
+		_, _, err = scmClient.Releases.Create(ctx, s.repo, input)
+	case s.provider == "gitlab":
+		// go-scm does not escape the tag, a tag like release/v1 is a path segment of the API
+		_, _, err = scmClient.Releases.UpdateByTag(ctx, s.repo, url.PathEscape(opt.Tag), input)
+	default:
+		_, _, err = scmClient.Releases.Update(ctx, s.repo, existing.ID, input)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to create or update the release of %q: %v", opt.Tag, err)
+		return
+	}
+
+	// not all the providers return the release, find it again for the link and ID
+	if release, err = s.FindRelease(ctx, opt.Tag); err == nil && release == nil {
+		err = fmt.Errorf("the release of %q is not found after creating", opt.Tag)
+	}
+	return
+}
+
+// DeleteRelease deletes the release of the tag, the tag itself is kept
+func (s *StatusMaker) DeleteRelease(ctx context.Context, tag string) (err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	if s.provider == "gitlab" {
+		_, err = scmClient.Releases.DeleteByTag(ctx, s.repo, url.PathEscape(tag))
+	} else {
+		var release *scm.Release
+		if release, err = s.FindRelease(ctx, tag); err != nil {
+			return
+		} else if release == nil {
+			err = fmt.Errorf("the release of %q is not found", tag)
+			return
+		}
+		_, err = scmClient.Releases.Delete(ctx, s.repo, release.ID)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to delete the release of %q: %v", tag, err)
+	}
+	return
+}
+
+// UploadReleaseAssets uploads the files to the release of the tag, the assets with the same names are replaced.
+// A checksum file of the uploaded files is uploaded as well if its name is not empty
+func (s *StatusMaker) UploadReleaseAssets(ctx context.Context, tag string, files []string, checksumFile string) (assets []ReleaseAsset, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var release *scm.Release
+	if release, err = s.FindRelease(ctx, tag); err != nil {
+		return
+	} else if release == nil {
+		err = fmt.Errorf("the release of %q is not found", tag)
+		return
+	}
+
+	checksums := make(map[string]string)
+	for _, file := range files {
+		var data []byte
+		if data, err = os.ReadFile(file); err != nil {
+			return
+		}
+
+		var asset ReleaseAsset
+		if asset, err = s.uploadReleaseAsset(ctx, scmClient, release, filepath.Base(file), data); err != nil {
+			return
+		}
+		assets = append(assets, asset)
+		checksums[asset.Name] = asset.Sha256
+	}
+
+	if checksumFile != "" && len(assets) > 0 {
+		// keep the checksums of the assets which were uploaded before
+		var existing map[string]string
+		if existing, err = s.readReleaseChecksums(ctx, scmClient, release, checksumFile); err != nil {
+			return
+		}
+		for name, sum := range existing {
+			if _, ok := checksums[name]; !ok {
+				checksums[name] = sum
+			}
+		}
+
+		var asset ReleaseAsset
+		if asset, err = s.uploadReleaseAsset(ctx, scmClient, release, checksumFile, formatChecksums(checksums)); err == nil {
+			assets = append(assets, asset)
+		}
+	}
+	return
+}
+
+// readReleaseChecksums reads the checksum asset of the release, the checksums are empty if it does not exist
+func (s *StatusMaker) readReleaseChecksums(ctx context.Context, scmClient *scm.Client, release *scm.Release, name string) (
+	checksums map[string]string, err error) {
+	var items []releaseAssetItem
+	if items, err = s.listReleaseAssets(ctx, scmClient, release); err != nil {
+		return
+	}
+
+	checksums = make(map[string]string)
+	for _, item := range items {
+		if item.Name != name {
+			continue
+		}
+
+		var data []byte
+		if data, err = download(ctx, scmClient, item.downloadURL()); err != nil {
+			err = fmt.Errorf("failed to download %q: %v", name, err)
+			return
+		}
+		// the line is the checksum and the file name which are separated by two spaces, like sha256sum
+		for _, line := range strings.Split(string(data), "\n") {
+			if fields := strings.Fields(line); len(fields) == 2 {
+				checksums[fields[1]] = fields[0]
+			}
+		}
+	}
+	return
+}
+
+// formatChecksums formats the checksums like sha256sum, they are sorted by the file names
+func formatChecksums(checksums map[string]string) []byte {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	for _, name := range names {
+		buf.WriteString(fmt.Sprintf("%s  %s\n", checksums[name], name))
+	}
+	return buf.Bytes()
+}
+
+// listReleaseAssets lists the assets of the release, they are the asset links on GitLab
+func (s *StatusMaker) listReleaseAssets(ctx context.Context, scmClient *scm.Client, release *scm.Release) (
+	items []releaseAssetItem, err error) {
+	switch s.provider {
+	case "github":
+		out := &struct {
+			Assets []releaseAssetItem `json:"assets"`
+		}{}
+		err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("repos/%s/releases/%d", s.repo, release.ID), nil, out)
+		items = out.Assets
+	case "gitlab":
+		err = requestJSON(ctx, scmClient, http.MethodGet, gitlabReleaseLinksPath(s.repo, release.Tag), nil, &items)
+	case "gitea":
+		err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("api/v1/repos/%s/releases/%d/assets", s.repo, release.ID), nil, &items)
+	default:
+		err = fmt.Errorf("listing the release assets is not supported by provider %q", s.provider)
+	}
+	return
+}
+
+func (s *StatusMaker) uploadReleaseAsset(ctx context.Context, scmClient *scm.Client, release *scm.Release, name string, data []byte) (asset ReleaseAsset, err error) {
+	sum := sha256.Sum256(data)
+	asset = ReleaseAsset{Name: name, Size: len(data), Sha256: hex.EncodeToString(sum[:])}
+
+	switch s.provider {
+	case "github":
+		asset.URL, err = uploadGitHubAsset(ctx, scmClient, s.repo, release.ID, name, data)
+	case "gitlab":
+		asset.URL, err = uploadGitLabAsset(ctx, scmClient, s.repo, release.Tag, name, data)
+	case "gitea":
+		asset.URL, err = uploadGiteaAsset(ctx, scmClient, s.repo, release.ID, name, data)
+	default:
+		err = fmt.Errorf("uploading the release assets is not supported by provider %q", s.provider)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to upload %q: %v", name, err)
+	}
+	return
+}
+
+// releaseAssetItem is the asset of GitHub and Gitea, or the asset link of GitLab
+type releaseAssetItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// URL is the API URL of the GitHub asset, or the link of the GitLab asset
+	URL                string `json:"url"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// downloadURL returns the API URL if it's possible, then the private assets could be downloaded as well
+func (i releaseAssetItem) downloadURL() string {
+	if i.URL != "" {
+		return i.URL
+	}
+	return i.BrowserDownloadURL
+}
+
+// uploadGitHubAsset see also https://docs.github.com/en/rest/releases/assets#upload-a-release-asset
+func uploadGitHubAsset(ctx context.Context, scmClient *scm.Client, repo string, id int, name string, data []byte) (link string, err error) {
+	release := &struct {
+		UploadURL string             `json:"upload_url"`
+		Assets    []releaseAssetItem `json:"assets"`
+	}{}
+	if err = requestJSON(ctx, scmClient, http.MethodGet, fmt.Sprintf("repos/%s/releases/%d", repo, id), nil, release); err != nil {
+		return
+	}
+
+	for _, item := range release.Assets {
+		if item.Name == name {
+			if err = requestJSON(ctx, scmClient, http.MethodDelete, fmt.Sprintf("repos/%s/releases/assets/%d", repo, item.ID), nil, nil); err != nil {
+				return
+			}
+		}
+	}
+
+	// the upload URL is a hypermedia template, such as: https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}
+	uploadURL := strings.SplitN(release.UploadURL, "{", 2)[0]
+	out := &struct {
+		BrowserDownloadURL string `json:"browser_download_url"`
+	}{}
+	err = request(ctx, scmClient, http.MethodPost, uploadURL+"?name="+url.QueryEscape(name),
+		"application/octet-stream", bytes.NewReader(data), out)
+	link = out.BrowserDownloadURL
+	return
+}
+
+// uploadGiteaAsset see also https://try.gitea.io/api/swagger#/repository/repoCreateReleaseAttachment
+func uploadGiteaAsset(ctx context.Context, scmClient *scm.Client, repo string, id int, name string, data []byte) (link string, err error) {
+	path := fmt.Sprintf("api/v1/repos/%s/releases/%d/assets", repo, id)
+	var existing []releaseAssetItem
+	if err = requestJSON(ctx, scmClient, http.MethodGet, path, nil, &existing); err != nil {
+		return
+	}
+
+	for _, item := range existing {
+		if item.Name == name {
+			if err = requestJSON(ctx, scmClient, http.MethodDelete, fmt.Sprintf("%s/%d", path, item.ID), nil, nil); err != nil {
+				return
+			}
+		}
+	}
+
+	out := &struct {
+		BrowserDownloadURL string `json:"browser_download_url"`
+	}{}
+	err = requestMultipart(ctx, scmClient, http.MethodPost, path+"?name="+url.QueryEscape(name), "attachment", name, data, out)
+	link = out.BrowserDownloadURL
+	return
+}
+
+// uploadGitLabAsset uploads the file to the project, then links it to the release
+// see also https://docs.gitlab.com/ee/api/releases/links.html
+func uploadGitLabAsset(ctx context.Context, scmClient *scm.Client, repo, tag, name string, data []byte) (link string, err error) {
+	project := gitlabProject(repo)
+	upload := &struct {
+		URL      string `json:"url"`
+		FullPath string `json:"full_path"`
+	}{}
+	if err = requestMultipart(ctx, scmClient, http.MethodPost, fmt.Sprintf("api/v4/projects/%s/uploads", project),
+		"file", name, data, upload); err != nil {
+		return
+	}
+	// the full path is absolute from the server root, it's missing in the old versions
+	fullPath := upload.FullPath
+	if fullPath == "" {
+		fullPath = "/" + repo + upload.URL
+	}
+	link = strings.TrimSuffix(scmClient.BaseURL.String(), "/") + fullPath
+
+	linksPath := gitlabReleaseLinksPath(repo, tag)
+	var existing []releaseAssetItem
+	if err = requestJSON(ctx, scmClient, http.MethodGet, linksPath, nil, &existing); err != nil {
+		return
+	}
+	for _, item := range existing {
+		if item.Name == name {
+			if err = requestJSON(ctx, scmClient, http.MethodDelete, fmt.Sprintf("%s/%d", linksPath, item.ID), nil, nil); err != nil {
+				return
+			}
+		}
+	}
+
+	err = requestJSON(ctx, scmClient, http.MethodPost, linksPath, map[string]string{
+		"name": name,
+		"url":  link,
+	}, nil)
+	return
+}
+
+// gitlabProject returns the URL-encoded path of the project which is the ID in the GitLab API
+func gitlabProject(repo string) string {
+	return strings.ReplaceAll(repo, "/", "%2F")
+}
+
+// gitlabReleaseLinksPath returns the API path of the release asset links, the slashes of the tag are escaped as well
+func gitlabReleaseLinksPath(repo, tag string) string {
+	return fmt.Sprintf("api/v4/projects/%s/releases/%s/assets/links", gitlabProject(repo), url.PathEscape(tag))
+}
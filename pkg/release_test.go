@@ -0,0 +1,178 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	client, data := fake.NewDefault()
+	maker := NewStatusMaker("linuxsuren/gogit", "").WithClient(client)
+
+	releases, err := maker.ListReleases(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, releases)
+
+	draft, notDraft := true, false
+	release, created, err := maker.CreateRelease(ctx, ReleaseOptions{Tag: "v1.0.0", Title: "first", Notes: "notes", Draft: &draft})
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "first", release.Title)
+	assert.True(t, release.Draft)
+
+	// the fields which are not given are kept, such as adding assets to an existing release
+	release, created, err = maker.CreateRelease(ctx, ReleaseOptions{Tag: "v1.0.0"})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "first", release.Title)
+	assert.Equal(t, "notes", release.Description)
+	assert.True(t, release.Draft)
+
+	// update the existing release of the tag
+	release, created, err = maker.CreateRelease(ctx, ReleaseOptions{Tag: "v1.0.0", Notes: "new notes", Draft: &notDraft})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "first", release.Title)
+	assert.Equal(t, "new notes", release.Description)
+	assert.False(t, release.Draft)
+	assert.Len(t, data.Releases["linuxsuren/gogit"], 1)
+
+	release, _, err = maker.CreateRelease(ctx, ReleaseOptions{Tag: "v2.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.0.0", release.Title)
+	assert.NoError(t, maker.DeleteRelease(ctx, "v2.0.0"))
+
+	_, err = maker.UploadReleaseAssets(ctx, "v1.0.0", []string{"release.go"}, "")
+	assert.Error(t, err)
+	_, err = maker.UploadReleaseAssets(ctx, "v2.0.0", []string{"release.go"}, "")
+	assert.Error(t, err)
+
+	assert.NoError(t, maker.DeleteRelease(ctx, "v1.0.0"))
+	assert.Empty(t, data.Releases["linuxsuren/gogit"])
+	assert.Error(t, maker.DeleteRelease(ctx, "v1.0.0"))
+}
+
+func TestUploadGitHubReleaseAssets(t *testing.T) {
+	var requests []string
+	uploaded := make(map[string]string)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/releases/tags/v1.0.0":
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": 1, "tag_name": "v1.0.0"})
+		case "/api/v3/repos/owner/repo/releases/1":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"upload_url": server.URL + "/uploads/repos/owner/repo/releases/1/assets{?name,label}",
+				"assets": []map[string]any{{"id": 7, "name": "gogit.txt"}, {"id": 8, "name": "checksums.txt",
+					"url": server.URL + "/api/v3/repos/owner/repo/releases/assets/8"}},
+			})
+		case "/api/v3/repos/owner/repo/releases/assets/8":
+			if r.Method == http.MethodGet {
+				assert.Equal(t, "application/octet-stream", r.Header.Get("Accept"))
+				_, _ = io.WriteString(w, "aaa  gogit.txt\nbbb  gogit-linux.tar.gz\n")
+			}
+		case "/uploads/repos/owner/repo/releases/1/assets":
+			name := r.URL.Query().Get("name")
+			body, _ := io.ReadAll(r.Body)
+			uploaded[name] = string(body)
+			_ = json.NewEncoder(w).Encode(map[string]any{"browser_download_url": "https://foo.com/" + name})
+		}
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "gogit.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("hello"), 0644))
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+	maker := NewStatusMaker("owner/repo", "").WithProvider("github").WithClient(client)
+	assets, err := maker.UploadReleaseAssets(context.Background(), "v1.0.0", []string{file}, DefaultChecksumFile)
+	assert.NoError(t, err)
+	if assert.Len(t, assets, 2) {
+		assert.Equal(t, ReleaseAsset{
+			Name:   "gogit.txt",
+			URL:    "https://foo.com/gogit.txt",
+			Size:   5,
+			Sha256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		}, assets[0])
+		assert.Equal(t, "https://foo.com/checksums.txt", assets[1].URL)
+	}
+	// the checksums of the assets which were uploaded before are kept
+	assert.Equal(t, map[string]string{
+		"gogit.txt":     "hello",
+		"checksums.txt": "bbb  gogit-linux.tar.gz\n2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  gogit.txt\n",
+	}, uploaded)
+	assert.Contains(t, requests, "DELETE /api/v3/repos/owner/repo/releases/assets/7")
+	assert.Contains(t, requests, "DELETE /api/v3/repos/owner/repo/releases/assets/8")
+}
+
+func TestUploadGitLabAndGiteaAsset(t *testing.T) {
+	var requests []string
+	var link map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RawPath)
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "POST /api/v4/projects/owner%2Frepo/uploads":
+			_ = json.NewEncoder(w).Encode(map[string]any{"url": "/uploads/abc/gogit.txt"})
+		case "GET /api/v4/projects/owner%2Frepo/releases/release%2Fv1/assets/links":
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": 3, "name": "gogit.txt"}})
+		case "POST /api/v4/projects/owner%2Frepo/releases/release%2Fv1/assets/links":
+			_ = json.NewDecoder(r.Body).Decode(&link)
+		case "GET /api/v1/repos/owner/repo/releases/1/assets":
+			_ = json.NewEncoder(w).Encode([]map[string]any{})
+		case "POST /api/v1/repos/owner/repo/releases/1/assets":
+			file, header, err := r.FormFile("attachment")
+			if err == nil {
+				_ = file.Close()
+				_ = json.NewEncoder(w).Encode(map[string]any{"browser_download_url": "https://foo.com/" + header.Filename})
+			}
+		}
+	}))
+	defer server.Close()
+
+	base, err := url.Parse(server.URL + "/")
+	assert.NoError(t, err)
+	client := &scm.Client{BaseURL: base}
+
+	ctx := context.Background()
+	// the slash of the tag is escaped
+	result, err := uploadGitLabAsset(ctx, client, "owner/repo", "release/v1", "gogit.txt", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/owner/repo/uploads/abc/gogit.txt", result)
+	assert.Equal(t, map[string]string{"name": "gogit.txt", "url": result}, link)
+	assert.Contains(t, requests, "DELETE /api/v4/projects/owner%2Frepo/releases/release%2Fv1/assets/links/3")
+
+	result, err = uploadGiteaAsset(ctx, client, "owner/repo", 1, "gogit.txt", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://foo.com/gogit.txt", result)
+}
+
+func TestDeleteGitLabReleaseOfSlashTag(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		_, _ = fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("gitlab", server.URL, "token")
+	assert.NoError(t, err)
+	maker := NewStatusMaker("owner/repo", "").WithProvider("gitlab").WithClient(client)
+	assert.NoError(t, maker.DeleteRelease(context.Background(), "release/v1"))
+	assert.Equal(t, []string{"DELETE /api/v4/projects/owner%2Frepo/releases/release%2Fv1"}, requests)
+}
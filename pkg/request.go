@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// requestJSON sends a request to the git provider API which is not covered by go-scm,
+// the path could be relative to the base URL of the client or an absolute URL
+func requestJSON(ctx context.Context, scmClient *scm.Client, method, path string, in, out any) (err error) {
+	var body io.Reader
+	var contentType string
+	if in != nil {
+		var data []byte
+		if data, err = json.Marshal(in); err != nil {
+			return
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+	err = request(ctx, scmClient, method, path, contentType, body, out)
+	return
+}
+
+// requestGraphQL sends a GraphQL query to the git provider, the data of the response is decoded into out if it's not nil
+func requestGraphQL(ctx context.Context, scmClient *scm.Client, query string, variables map[string]any, out any) (err error) {
+	if scmClient.GraphQLURL == nil {
+		err = errors.New("the GraphQL endpoint is unknown")
+		return
+	}
+
+	result := &struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if err = requestJSON(ctx, scmClient, http.MethodPost, scmClient.GraphQLURL.String(), map[string]any{
+		"query":     query,
+		"variables": variables,
+	}, result); err != nil {
+		return
+	}
+
+	if len(result.Errors) > 0 {
+		err = errors.New(result.Errors[0].Message)
+	} else if out != nil && len(result.Data) > 0 {
+		err = json.Unmarshal(result.Data, out)
+	}
+	return
+}
+
+// requestMultipart sends a multipart form request with a file field to the git provider API
+func requestMultipart(ctx context.Context, scmClient *scm.Client, method, path, field, filename string, data []byte, out any) (err error) {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	var part io.Writer
+	if part, err = writer.CreateFormFile(field, filename); err != nil {
+		return
+	}
+	if _, err = part.Write(data); err != nil {
+		return
+	}
+	if err = writer.Close(); err != nil {
+		return
+	}
+	err = request(ctx, scmClient, method, path, writer.FormDataContentType(), buf, out)
+	return
+}
+
+// request sends a request with the body, the JSON response is decoded into out if it's not nil
+func request(ctx context.Context, scmClient *scm.Client, method, path, contentType string, in io.Reader, out any) (err error) {
+	req := &scm.Request{
+		Method: method,
+		Path:   path,
+		Header: http.Header{
+			"Accept": []string{"application/json"},
+		},
+		Body: in,
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	var body []byte
+	if body, err = send(ctx, scmClient, req); err == nil && out != nil && len(body) > 0 {
+		err = json.Unmarshal(body, out)
+	}
+	return
+}
+
+// download downloads the file, such as a release asset, from the git provider
+func download(ctx context.Context, scmClient *scm.Client, path string) (data []byte, err error) {
+	return send(ctx, scmClient, &scm.Request{
+		Method: http.MethodGet,
+		Path:   path,
+		Header: http.Header{
+			"Accept": []string{"application/octet-stream"},
+		},
+	})
+}
+
+// send sends the request and returns the response body, it fails if the status code is not successful
+func send(ctx context.Context, scmClient *scm.Client, req *scm.Request) (body []byte, err error) {
+	var resp *scm.Response
+	if resp, err = scmClient.Do(ctx, req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return
+	}
+
+	if resp.Status >= http.StatusMultipleChoices {
+		err = fmt.Errorf("%s %s failed, received code %d: %s", req.Method, req.Path, resp.Status, string(body))
+	}
+	return
+}
@@ -0,0 +1,507 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// Finding represents an issue which reported by a lint or static-analysis tool
+type Finding struct {
+	Path     string
+	Line     int
+	Severity string
+	Rule     string
+	Message  string
+}
+
+// Body returns the comment body of the finding
+func (f Finding) Body() (body string) {
+	body = f.Message
+	if f.Rule != "" {
+		body = fmt.Sprintf("**%s**: %s", f.Rule, body)
+	}
+	if f.Severity != "" {
+		body = fmt.Sprintf("[%s] %s", f.Severity, body)
+	}
+	return
+}
+
+// Supported report formats
+const (
+	ReportFormatSARIF      = "sarif"
+	ReportFormatCheckstyle = "checkstyle"
+	ReportFormatGolangci   = "golangci"
+)
+
+// DetectReportFormat detects the report format from its content
+func DetectReportFormat(data []byte) (format string, err error) {
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, "<"):
+		format = ReportFormatCheckstyle
+	case strings.HasPrefix(text, "{"):
+		probe := map[string]json.RawMessage{}
+		if err = json.Unmarshal(data, &probe); err != nil {
+			return
+		}
+		if _, ok := probe["runs"]; ok {
+			format = ReportFormatSARIF
+		} else if _, ok := probe["Issues"]; ok {
+			format = ReportFormatGolangci
+		}
+	}
+
+	if format == "" && err == nil {
+		err = fmt.Errorf("cannot detect the format of the report")
+	}
+	return
+}
+
+// ParseReport parses the lint report with the given format
+func ParseReport(data []byte, format string) (findings []Finding, err error) {
+	if format == "" {
+		if format, err = DetectReportFormat(data); err != nil {
+			return
+		}
+	}
+
+	switch format {
+	case ReportFormatSARIF:
+		findings, err = parseSARIF(data)
+	case ReportFormatCheckstyle:
+		findings, err = parseCheckstyle(data)
+	case ReportFormatGolangci:
+		findings, err = parseGolangci(data)
+	default:
+		err = fmt.Errorf("unsupported report format %q", format)
+	}
+	return
+}
+
+type sarifReport struct {
+	Runs []struct {
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+func parseSARIF(data []byte) (findings []Finding, err error) {
+	report := &sarifReport{}
+	if err = json.Unmarshal(data, report); err != nil {
+		err = fmt.Errorf("cannot parse the SARIF report: %v", err)
+		return
+	}
+
+	for _, run := range report.Runs {
+		for _, result := range run.Results {
+			for _, location := range result.Locations {
+				findings = append(findings, Finding{
+					Path:     strings.TrimPrefix(location.PhysicalLocation.ArtifactLocation.URI, "file://"),
+					Line:     location.PhysicalLocation.Region.StartLine,
+					Severity: result.Level,
+					Rule:     result.RuleID,
+					Message:  result.Message.Text,
+				})
+			}
+		}
+	}
+	return
+}
+
+type checkstyleReport struct {
+	Files []struct {
+		Name   string `xml:"name,attr"`
+		Errors []struct {
+			Line     int    `xml:"line,attr"`
+			Severity string `xml:"severity,attr"`
+			Message  string `xml:"message,attr"`
+			Source   string `xml:"source,attr"`
+		} `xml:"error"`
+	} `xml:"file"`
+}
+
+func parseCheckstyle(data []byte) (findings []Finding, err error) {
+	report := &checkstyleReport{}
+	if err = xml.Unmarshal(data, report); err != nil {
+		err = fmt.Errorf("cannot parse the checkstyle report: %v", err)
+		return
+	}
+
+	for _, file := range report.Files {
+		for _, item := range file.Errors {
+			findings = append(findings, Finding{
+				Path:     file.Name,
+				Line:     item.Line,
+				Severity: item.Severity,
+				Rule:     item.Source,
+				Message:  item.Message,
+			})
+		}
+	}
+	return
+}
+
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Severity   string `json:"Severity"`
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+}
+
+func parseGolangci(data []byte) (findings []Finding, err error) {
+	report := &golangciReport{}
+	if err = json.Unmarshal(data, report); err != nil {
+		err = fmt.Errorf("cannot parse the golangci-lint report: %v", err)
+		return
+	}
+
+	for _, issue := range report.Issues {
+		findings = append(findings, Finding{
+			Path:     issue.Pos.Filename,
+			Line:     issue.Pos.Line,
+			Severity: issue.Severity,
+			Rule:     issue.FromLinter,
+			Message:  issue.Text,
+		})
+	}
+	return
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ChangedLines returns the added lines of a patch, the key is the line number of
+// the new file, the value is the position of that line in the patch
+func ChangedLines(patch string) (lines map[int]int) {
+	lines = map[int]int{}
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	position, current := 0, 0
+	started := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			current, _ = strconv.Atoi(match[1])
+			if started {
+				// the following hunk headers are counted as a position as well
+				position++
+			}
+			started = true
+			continue
+		}
+		if !started {
+			continue
+		}
+
+		position++
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines[current] = position
+			current++
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+		default:
+			current++
+		}
+	}
+	return
+}
+
+// FilterFindings keeps the findings which are on the changed lines only
+func FilterFindings(findings []Finding, changes []*scm.Change) (result []Finding) {
+	changedFiles := make(map[string]map[int]int, len(changes))
+	for _, change := range changes {
+		if change.Deleted {
+			continue
+		}
+		changedFiles[change.Path] = ChangedLines(change.Patch)
+	}
+
+	for _, finding := range findings {
+		if lines, ok := changedFiles[finding.Path]; ok {
+			if _, ok = lines[finding.Line]; ok {
+				result = append(result, finding)
+			}
+		}
+	}
+	return
+}
+
+// CreateReview creates a review with inline comments against the pull request
+func CreateReview(ctx context.Context, repoInfo RepoInformation, findings []Finding, identity string) (err error) {
+	if maker := NewMaker(ctx, repoInfo); maker != nil {
+		err = maker.CreateReview(ctx, findings, identity)
+	}
+	return
+}
+
+// CreateReview creates a review which only has the findings on the changed lines, and resolves the threads of the
+// comments of the previous reviews that are not valid anymore. It's only supported by GitHub
+func (s *StatusMaker) CreateReview(ctx context.Context, findings []Finding, identity string) (err error) {
+	if s.provider != "github" {
+		err = fmt.Errorf("creating the review is not supported by %q", s.provider)
+		return
+	}
+
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var pullRequest *scm.PullRequest
+	if pullRequest, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err != nil {
+		err = fmt.Errorf("failed to find pull requests %v", err)
+		return
+	}
+
+	var changes []*scm.Change
+	if changes, err = listAllChanges(ctx, scmClient, s.repo, s.pr); err != nil {
+		return
+	}
+	positions := make(map[string]map[int]int, len(changes))
+	for _, change := range changes {
+		positions[change.Path] = ChangedLines(change.Patch)
+	}
+
+	var reviews []*scm.Review
+	if reviews, err = listAllReviews(ctx, scmClient, s.repo, s.pr); err != nil {
+		return
+	}
+
+	comments := make(map[string]*scm.ReviewCommentInput)
+	for _, finding := range FilterFindings(findings, changes) {
+		comment := &scm.ReviewCommentInput{
+			Path: finding.Path,
+			// GitHub takes the position in the patch instead of the line number
+			Line: positions[finding.Path][finding.Line],
+			Body: finding.Body(),
+		}
+		comments[reviewCommentKey(comment.Path, comment.Line, comment.Body)] = comment
+	}
+
+	// a submitted review cannot be deleted, and a commented review cannot be dismissed,
+	// so the threads of the stale comments are resolved one by one
+	var threads map[int]*githubReviewThread
+	posted := make(map[string]struct{})
+	for _, review := range reviews {
+		if !strings.HasSuffix(review.Body, identity) || review.State == scm.ReviewStateDismissed {
+			continue
+		}
+
+		var existing []githubReviewComment
+		if existing, err = listGitHubReviewComments(ctx, scmClient, s.repo, s.pr, review.ID); err != nil {
+			err = fmt.Errorf("failed to list the comments of review %d: %v", review.ID, err)
+			return
+		}
+		if threads == nil {
+			if threads, err = listGitHubReviewThreads(ctx, scmClient, s.repo, s.pr); err != nil {
+				err = fmt.Errorf("failed to list the review threads: %v", err)
+				return
+			}
+		}
+
+		for _, comment := range existing {
+			thread := threads[comment.ID]
+			if thread != nil && thread.IsResolved {
+				// the finding is posted again if it's still valid
+				continue
+			}
+
+			// the position is null once the line is not in the latest diff
+			key := reviewCommentKey(comment.Path, comment.Position, comment.Body)
+			if _, ok := comments[key]; ok {
+				// the comment is still valid, no need to post it again
+				delete(comments, key)
+				posted[key] = struct{}{}
+				continue
+			} else if _, ok = posted[key]; ok {
+				continue
+			}
+
+			if thread == nil {
+				// it should not happen, every review comment belongs to a thread
+				err = fmt.Errorf("cannot find the thread of the stale review comment %d", comment.ID)
+				return
+			}
+			if err = resolveGitHubReviewThread(ctx, scmClient, thread.ID); err != nil {
+				err = fmt.Errorf("failed to resolve the thread of the stale review comment %d: %v", comment.ID, err)
+				return
+			}
+			thread.IsResolved = true
+		}
+	}
+
+	if len(comments) == 0 {
+		return
+	}
+
+	input := &scm.ReviewInput{
+		Body:  fmt.Sprintf("Found %d issue(s) on the changed lines.\n\n%s", len(comments), identity),
+		Sha:   pullRequest.Sha,
+		Event: "COMMENT",
+	}
+	for _, comment := range comments {
+		input.Comments = append(input.Comments, comment)
+	}
+	sortReviewComments(input.Comments)
+
+	_, _, err = scmClient.Reviews.Create(ctx, s.repo, s.pr, input)
+	err = WrapError(err, "failed to create review, repo is %q, pr is %d: %v", s.repo, s.pr)
+	return
+}
+
+func listAllChanges(ctx context.Context, scmClient *scm.Client, repo string, pr int) (changes []*scm.Change, err error) {
+	opt := &scm.ListOptions{Page: 1, Size: 100}
+	for {
+		var items []*scm.Change
+		var resp *scm.Response
+		if items, resp, err = scmClient.PullRequests.ListChanges(ctx, repo, pr, opt); err != nil {
+			err = fmt.Errorf("failed to list the changes of pull request %d: %v", pr, err)
+			return
+		}
+		changes = append(changes, items...)
+
+		if resp == nil || resp.Page.Next == 0 {
+			break
+		}
+		opt.Page = resp.Page.Next
+	}
+	return
+}
+
+// githubReviewComment is a review comment with its ID, go-scm does not return the ID of the review comments
+//
+// See also https://docs.github.com/en/rest/pulls/reviews#list-comments-for-a-pull-request-review
+type githubReviewComment struct {
+	ID       int    `json:"id"`
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	Body     string `json:"body"`
+}
+
+func listGitHubReviewComments(ctx context.Context, scmClient *scm.Client, repo string, pr, review int) (comments []githubReviewComment, err error) {
+	for page := 1; page > 0; page++ {
+		var items []githubReviewComment
+		path := fmt.Sprintf("repos/%s/pulls/%d/reviews/%d/comments?page=%d&per_page=100", repo, pr, review, page)
+		if err = requestJSON(ctx, scmClient, http.MethodGet, path, nil, &items); err != nil {
+			return
+		}
+
+		comments = append(comments, items...)
+		if len(items) < 100 {
+			break
+		}
+	}
+	return
+}
+
+// githubReviewThread is the conversation of the review comments, go-scm does not support it
+//
+// See also https://docs.github.com/en/graphql/reference/objects#pullrequestreviewthread
+type githubReviewThread struct {
+	ID         string `json:"id"`
+	IsResolved bool   `json:"isResolved"`
+	Comments   struct {
+		Nodes []struct {
+			DatabaseID int `json:"databaseId"`
+		} `json:"nodes"`
+	} `json:"comments"`
+}
+
+// listGitHubReviewThreads lists the review threads of the pull request, they are mapped from the IDs of their comments
+func listGitHubReviewThreads(ctx context.Context, scmClient *scm.Client, repo string, pr int) (threads map[int]*githubReviewThread, err error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	threads = make(map[int]*githubReviewThread)
+	var cursor *string
+	for {
+		out := &struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						Nodes    []*githubReviewThread `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}{}
+		if err = requestGraphQL(ctx, scmClient, `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        nodes { id isResolved comments(first: 100) { nodes { databaseId } } }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`, map[string]any{"owner": owner, "name": name, "number": pr, "cursor": cursor}, out); err != nil {
+			return
+		}
+
+		reviewThreads := out.Repository.PullRequest.ReviewThreads
+		for _, thread := range reviewThreads.Nodes {
+			for _, comment := range thread.Comments.Nodes {
+				threads[comment.DatabaseID] = thread
+			}
+		}
+		if !reviewThreads.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &reviewThreads.PageInfo.EndCursor
+	}
+	return
+}
+
+// resolveGitHubReviewThread see also https://docs.github.com/en/graphql/reference/mutations#resolvereviewthread
+func resolveGitHubReviewThread(ctx context.Context, scmClient *scm.Client, id string) error {
+	return requestGraphQL(ctx, scmClient, `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) { thread { id } }
+}`, map[string]any{"id": id}, nil)
+}
+
+func reviewCommentKey(path string, line int, body string) string {
+	return fmt.Sprintf("%s:%d:%s", path, line, body)
+}
+
+func sortReviewComments(comments []*scm.ReviewCommentInput) {
+	sort.Slice(comments, func(i, j int) bool {
+		if comments[i].Path != comments[j].Path {
+			return comments[i].Path < comments[j].Path
+		}
+		if comments[i].Line != comments[j].Line {
+			return comments[i].Line < comments[j].Line
+		}
+		return comments[i].Body < comments[j].Body
+	})
+}
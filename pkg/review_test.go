@@ -0,0 +1,198 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		format    string
+		expect    []Finding
+		expectErr bool
+	}{{
+		name: "sarif",
+		data: `{"runs":[{"results":[{"ruleId":"G101","level":"warning","message":{"text":"hardcoded credentials"},
+"locations":[{"physicalLocation":{"artifactLocation":{"uri":"file://main.go"},"region":{"startLine":3}}}]}]}]}`,
+		expect: []Finding{{Path: "main.go", Line: 3, Severity: "warning", Rule: "G101", Message: "hardcoded credentials"}},
+	}, {
+		name: "checkstyle",
+		data: `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0"><file name="cmd/root.go"><error line="10" column="1" severity="error" message="unused" source="unused"></error></file></checkstyle>`,
+		expect: []Finding{{Path: "cmd/root.go", Line: 10, Severity: "error", Rule: "unused", Message: "unused"}},
+	}, {
+		name:   "golangci",
+		data:   `{"Issues":[{"FromLinter":"errcheck","Text":"error is not checked","Severity":"","Pos":{"Filename":"pkg/git.go","Line":5}}]}`,
+		expect: []Finding{{Path: "pkg/git.go", Line: 5, Rule: "errcheck", Message: "error is not checked"}},
+	}, {
+		name:      "unknown format",
+		data:      `{"foo":"bar"}`,
+		expectErr: true,
+	}, {
+		name:      "unsupported format",
+		data:      `{}`,
+		format:    "fake",
+		expectErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := ParseReport([]byte(tt.data), tt.format)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, findings)
+			}
+		})
+	}
+}
+
+const samplePatch = `@@ -1,3 +1,4 @@
+ package main
++import "fmt"
+
+ func main() {
+@@ -10,2 +11,3 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4`
+
+func TestChangedLines(t *testing.T) {
+	assert.Equal(t, map[int]int{2: 2, 12: 8, 13: 9}, ChangedLines(samplePatch))
+	assert.Empty(t, ChangedLines(""))
+}
+
+func TestFilterFindings(t *testing.T) {
+	changes := []*scm.Change{{
+		Path:  "main.go",
+		Patch: samplePatch,
+	}, {
+		Path:    "deleted.go",
+		Patch:   "@@ -1 +0,0 @@\n-package main",
+		Deleted: true,
+	}}
+	findings := []Finding{
+		{Path: "main.go", Line: 2},
+		{Path: "main.go", Line: 3},
+		{Path: "main.go", Line: 13},
+		{Path: "other.go", Line: 2},
+		{Path: "deleted.go", Line: 1},
+	}
+	assert.Equal(t, []Finding{{Path: "main.go", Line: 2}, {Path: "main.go", Line: 13}},
+		FilterFindings(findings, changes))
+}
+
+func TestFindingBody(t *testing.T) {
+	assert.Equal(t, "msg", Finding{Message: "msg"}.Body())
+	assert.Equal(t, "[error] **lint**: msg", Finding{Message: "msg", Rule: "lint", Severity: "error"}.Body())
+}
+
+func TestCreateReview(t *testing.T) {
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{Number: 1, Sha: "sha"}
+	data.PullRequestChanges[1] = []*scm.Change{{Path: "main.go", Patch: samplePatch}}
+
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithProvider("github").WithClient(client)
+	err := maker.CreateReview(context.Background(), []Finding{
+		{Path: "main.go", Line: 13, Message: "second"},
+		{Path: "main.go", Line: 2, Message: "first"},
+		{Path: "main.go", Line: 1, Message: "not changed"},
+	}, CommentEndMarker)
+	assert.NoError(t, err)
+	if assert.Len(t, data.Reviews[1], 1) {
+		assert.Contains(t, data.Reviews[1][0].Body, CommentEndMarker)
+	}
+
+	// nothing to review
+	data.Reviews[1] = nil
+	err = maker.CreateReview(context.Background(), []Finding{{Path: "main.go", Line: 1}}, CommentEndMarker)
+	assert.NoError(t, err)
+	assert.Empty(t, data.Reviews[1])
+}
+
+func TestCreateReviewAgain(t *testing.T) {
+	var requests, resolved []string
+	var created map[string]any
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v3/repos/owner/repo/pulls/1":
+			_ = json.NewEncoder(w).Encode(map[string]any{"number": 1, "head": map[string]any{"sha": "sha"}})
+		case "GET /api/v3/repos/owner/repo/pulls/1/files":
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"filename": "main.go", "patch": samplePatch}})
+		case "GET /api/v3/repos/owner/repo/pulls/1/reviews":
+			if r.URL.Query().Get("page") == "2" {
+				_ = json.NewEncoder(w).Encode([]map[string]any{{"id": 2, "body": "lgtm", "state": "APPROVED"}})
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, r.URL.Path))
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": 1, "body": "Found 3 issue(s)\n\n" + CommentEndMarker, "state": "COMMENTED"}})
+		case "GET /api/v3/repos/owner/repo/pulls/1/reviews/1/comments":
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"id": 11, "path": "main.go", "position": 2, "body": "first"},
+				{"id": 12, "path": "main.go", "position": nil, "body": "outdated"},
+				{"id": 13, "path": "main.go", "position": 9, "body": "fixed"},
+				{"id": 14, "path": "main.go", "position": 9, "body": "second"},
+			})
+		case "POST /api/graphql":
+			body := map[string]any{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			variables := body["variables"].(map[string]any)
+			if id, ok := variables["id"]; ok {
+				resolved = append(resolved, id.(string))
+				_, _ = fmt.Fprint(w, `{"data": {"resolveReviewThread": {"thread": {"id": "x"}}}}`)
+				return
+			}
+			assert.Equal(t, map[string]any{"owner": "owner", "name": "repo", "number": float64(1), "cursor": nil}, variables)
+			// the comment 14 is in a resolved thread, the finding is posted again
+			_, _ = fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+  "nodes": [
+    {"id": "t11", "isResolved": false, "comments": {"nodes": [{"databaseId": 11}]}},
+    {"id": "t12", "isResolved": false, "comments": {"nodes": [{"databaseId": 12}, {"databaseId": 15}]}},
+    {"id": "t13", "isResolved": false, "comments": {"nodes": [{"databaseId": 13}]}},
+    {"id": "t14", "isResolved": true, "comments": {"nodes": [{"databaseId": 14}]}}
+  ],
+  "pageInfo": {"hasNextPage": false, "endCursor": "abc"}
+}}}}}`)
+		case "POST /api/v3/repos/owner/repo/pulls/1/reviews":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": 3})
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithProvider("github").WithClient(client)
+	err = maker.CreateReview(context.Background(), []Finding{
+		{Path: "main.go", Line: 2, Message: "first"},
+		{Path: "main.go", Line: 13, Message: "second"},
+	}, CommentEndMarker)
+	assert.NoError(t, err)
+
+	assert.Contains(t, requests, "GET /api/v3/repos/owner/repo/pulls/1/reviews")
+	assert.NotContains(t, requests, "GET /api/v3/repos/owner/repo/pulls/1/reviews/2/comments")
+	// the stale comments are resolved instead of deleted
+	assert.Equal(t, []string{"t12", "t13"}, resolved)
+	for _, request := range requests {
+		assert.NotContains(t, request, "DELETE")
+	}
+	assert.Equal(t, []any{map[string]any{"body": "second", "path": "main.go", "position": float64(9)}}, created["comments"])
+
+	err = NewStatusMaker("owner/repo", "").WithPR(1).WithProvider("gitlab").WithClient(client).
+		CreateReview(context.Background(), nil, CommentEndMarker)
+	assert.ErrorContains(t, err, `creating the review is not supported by "gitlab"`)
+}
@@ -0,0 +1,237 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Version is a semantic version, see also https://semver.org
+type Version struct {
+	// Prefix is the prefix of the tag, such as v
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+var semverRegexp = regexp.MustCompile(`^([a-zA-Z]*)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// ParseVersion parses the semantic version with an optional prefix, such as v1.2.3-rc.1+build.5
+func ParseVersion(text string) (version Version, err error) {
+	groups := semverRegexp.FindStringSubmatch(text)
+	if groups == nil {
+		err = fmt.Errorf("%q is not a semantic version", text)
+		return
+	}
+
+	version = Version{Prefix: groups[1], Prerelease: groups[5], Build: groups[6]}
+	version.Major, _ = strconv.Atoi(groups[2])
+	version.Minor, _ = strconv.Atoi(groups[3])
+	version.Patch, _ = strconv.Atoi(groups[4])
+	return
+}
+
+// String returns the version with the prefix
+func (v Version) String() string {
+	text := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		text += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		text += "+" + v.Build
+	}
+	return text
+}
+
+// Compare returns -1, 0 or 1 by the precedence of the versions, the prefix and build metadata are ignored
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			return compareInt(pair[0], pair[1])
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	left, right := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if left[i] == right[i] {
+			continue
+		}
+		leftNum, leftErr := strconv.Atoi(left[i])
+		rightNum, rightErr := strconv.Atoi(right[i])
+		switch {
+		case leftErr == nil && rightErr == nil:
+			return compareInt(leftNum, rightNum)
+		case leftErr == nil:
+			// the numeric identifiers have lower precedence
+			return -1
+		case rightErr == nil:
+			return 1
+		default:
+			return strings.Compare(left[i], right[i])
+		}
+	}
+	return compareInt(len(left), len(right))
+}
+
+func compareInt(left, right int) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+	return 0
+}
+
+// Version bumps
+const (
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+// Bump returns the next release version, the prerelease and build metadata are dropped.
+// The release version of a prerelease is not bumped again if it's already bumped enough, such as 1.3.0-rc.1 to 1.3.0
+func (v Version) Bump(bump string) (next Version) {
+	next = Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	prerelease := v.Prerelease != ""
+	switch bump {
+	case BumpMajor:
+		if !prerelease || v.Minor != 0 || v.Patch != 0 {
+			next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+		}
+	case BumpMinor:
+		if !prerelease || v.Patch != 0 {
+			next.Minor, next.Patch = v.Minor+1, 0
+		}
+	default:
+		if !prerelease {
+			next.Patch = v.Patch + 1
+		}
+	}
+	return
+}
+
+// CommitBump returns the bump of the conventional commits, the breaking changes bump the major version,
+// the features bump the minor version, and the others bump the patch version
+func CommitBump(commits []*object.Commit) (bump string) {
+	bump = BumpPatch
+	for _, commit := range commits {
+		conventional, _ := ParseConventionalCommit(commit.Message)
+		if conventional.Breaking {
+			return BumpMajor
+		} else if conventional.Type == "feat" {
+			bump = BumpMinor
+		}
+	}
+	return
+}
+
+// NextVersionOptions is the options for computing the next version
+type NextVersionOptions struct {
+	// Prerelease is the prerelease identifier, such as rc, the number is increased for the same release version
+	Prerelease string
+	Build      string
+	// Prefix is the prefix of the version if there is no tag yet
+	Prefix string
+}
+
+// LatestVersionTag returns the tag of the highest semantic version which is reachable from HEAD, so the tags of
+// the other branches are ignored, such as the newer releases when HEAD is on a maintenance branch.
+// The tag is empty if not found
+func LatestVersionTag(dir string) (tag string, version Version, err error) {
+	var repo *git.Repository
+	if repo, err = git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true}); err != nil {
+		err = fmt.Errorf("cannot open git repository %q: %v", dir, err)
+		return
+	}
+
+	var head *plumbing.Reference
+	if head, err = repo.Head(); err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			// there is no commit yet
+			err = nil
+		}
+		return
+	}
+
+	var tags map[plumbing.Hash][]string
+	if tags, err = commitTags(repo); err != nil {
+		return
+	}
+
+	var shallow, ancestors map[plumbing.Hash]struct{}
+	if shallow, err = shallowCommits(repo); err != nil {
+		return
+	}
+	if ancestors, err = NewDescriber(repo).ancestors(head.Hash(), shallow); err != nil {
+		return
+	}
+
+	for hash, names := range tags {
+		if _, ok := ancestors[hash]; !ok {
+			continue
+		}
+		for _, name := range names {
+			if current, parseErr := ParseVersion(name); parseErr == nil && (tag == "" || current.Compare(version) > 0 ||
+				current.Compare(version) == 0 && name < tag) {
+				tag, version = name, current
+			}
+		}
+	}
+	return
+}
+
+// NextVersion computes the next version from the latest tag and the conventional commits since then. The current
+// version is returned if there is no commit since the latest tag, unless it promotes a prerelease to the release
+func NextVersion(dir string, opt NextVersionOptions) (latest string, next Version, err error) {
+	var current Version
+	if latest, current, err = LatestVersionTag(dir); err != nil {
+		return
+	}
+	if latest == "" {
+		current.Prefix = opt.Prefix
+	}
+
+	var commits []*object.Commit
+	if commits, err = ListCommits(dir, latest, "HEAD"); err != nil {
+		return
+	}
+
+	if latest != "" && len(commits) == 0 && (current.Prerelease == "" || opt.Prerelease != "") {
+		next = current
+		return
+	}
+
+	next = current.Bump(CommitBump(commits))
+	if opt.Prerelease != "" {
+		// continue the numbering of the prerelease of the same release version, such as rc.1 to rc.2
+		number := 1
+		sameRelease := current.Major == next.Major && current.Minor == next.Minor && current.Patch == next.Patch
+		if last, parseErr := strconv.Atoi(strings.TrimPrefix(current.Prerelease, opt.Prerelease+".")); sameRelease &&
+			parseErr == nil && strings.HasPrefix(current.Prerelease, opt.Prerelease+".") {
+			number = last + 1
+		}
+		next.Prerelease = fmt.Sprintf("%s.%d", opt.Prerelease, number)
+	}
+	next.Build = opt.Build
+	return
+}
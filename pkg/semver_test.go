@@ -0,0 +1,178 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	version, err := ParseVersion("v1.2.3-rc.1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Build: "build.5"}, version)
+	assert.Equal(t, "v1.2.3-rc.1+build.5", version.String())
+
+	version, err = ParseVersion("0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1.0", version.String())
+
+	for _, invalid := range []string{"v1.2", "1.02.3", "v1.2.3-", "latest"} {
+		_, err = ParseVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// see also https://semver.org/#spec-item-11
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "v1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		left, err := ParseVersion(ordered[i-1])
+		assert.NoError(t, err)
+		right, err := ParseVersion(ordered[i])
+		assert.NoError(t, err)
+		assert.Equal(t, -1, left.Compare(right), "%s < %s", ordered[i-1], ordered[i])
+		assert.Equal(t, 1, right.Compare(left), "%s > %s", ordered[i], ordered[i-1])
+	}
+
+	left, _ := ParseVersion("v1.0.0+build.1")
+	right, _ := ParseVersion("1.0.0")
+	assert.Equal(t, 0, left.Compare(right))
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    string
+		expect  string
+	}{
+		{"v1.2.3", BumpPatch, "v1.2.4"},
+		{"v1.2.3", BumpMinor, "v1.3.0"},
+		{"v1.2.3+build", BumpMajor, "v2.0.0"},
+		{"v1.3.0-rc.1", BumpPatch, "v1.3.0"},
+		{"v1.3.0-rc.1", BumpMinor, "v1.3.0"},
+		{"v1.3.0-rc.1", BumpMajor, "v2.0.0"},
+		{"v2.0.0-rc.1", BumpMajor, "v2.0.0"},
+		{"v1.3.1-rc.1", BumpMinor, "v1.4.0"},
+	}
+	for _, tt := range tests {
+		version, err := ParseVersion(tt.version)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expect, version.Bump(tt.bump).String(), "%s %s", tt.version, tt.bump)
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+
+	commitAll(t, repo, "init")
+	_, next, err := NextVersion(dir, NextVersionOptions{Prefix: "v"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.0.1", next.String())
+
+	tag := func(name string) {
+		head, err := repo.Head()
+		assert.NoError(t, err)
+		_, err = repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+			Message: name,
+			Tagger:  &object.Signature{Name: "Rick", When: time.Now()},
+		})
+		assert.NoError(t, err)
+	}
+	tag("v1.2.0")
+	tag("not-a-version")
+
+	// no commit since the latest tag
+	latest, next, err := NextVersion(dir, NextVersionOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0", latest)
+	assert.Equal(t, "v1.2.0", next.String())
+	_, next, err = NextVersion(dir, NextVersionOptions{Prerelease: "rc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0", next.String())
+
+	commitAll(t, repo, "fix: the bug")
+
+	latest, next, err = NextVersion(dir, NextVersionOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0", latest)
+	assert.Equal(t, "v1.2.1", next.String())
+
+	commitAll(t, repo, "feat(cmd): add version command")
+	_, next, err = NextVersion(dir, NextVersionOptions{Prerelease: "rc", Build: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0-rc.1+abc", next.String())
+
+	tag("v1.3.0-rc.1")
+	// promote the prerelease without any new commit
+	_, next, err = NextVersion(dir, NextVersionOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", next.String())
+
+	commitAll(t, repo, "fix: the feature")
+	_, next, err = NextVersion(dir, NextVersionOptions{Prerelease: "rc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0-rc.2", next.String())
+
+	_, next, err = NextVersion(dir, NextVersionOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", next.String())
+
+	commitAll(t, repo, "refactor!: drop the flag")
+	_, next, err = NextVersion(dir, NextVersionOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.0.0", next.String())
+
+	_, _, err = NextVersion(t.TempDir(), NextVersionOptions{})
+	assert.Error(t, err)
+}
+
+func TestLatestVersionTagOfMaintenanceBranch(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	tag := func(name string, hash plumbing.Hash) {
+		_, err := repo.CreateTag(name, hash, &git.CreateTagOptions{
+			Message: name,
+			Tagger:  &object.Signature{Name: "Rick", When: time.Now()},
+		})
+		assert.NoError(t, err)
+	}
+	release := commitAll(t, repo, "init")
+	tag("v1.0.0", release)
+	tag("v2.0.0", commitAll(t, repo, "feat!: drop the flag"))
+
+	// fix the bug on the maintenance branch of v1
+	assert.NoError(t, worktree.Checkout(&git.CheckoutOptions{
+		Hash:   release,
+		Branch: plumbing.NewBranchReferenceName("release-1.0"),
+		Create: true,
+	}))
+	commitAll(t, repo, "fix: the bug")
+
+	latest, version, err := LatestVersionTag(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", latest)
+	assert.Equal(t, "v1.0.0", version.String())
+
+	_, next, err := NextVersion(dir, NextVersionOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.1", next.String())
+
+	// there is no commit yet
+	dir = t.TempDir()
+	_, err = git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	latest, _, err = LatestVersionTag(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, latest)
+}
@@ -0,0 +1,198 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// DefaultStatsIgnore are the patterns of the generated and vendor files, they are not counted in the size
+var DefaultStatsIgnore = []string{"vendor/", "node_modules/", "go.sum", "package-lock.json", "yarn.lock",
+	"*.pb.go", "zz_generated*", "*.min.js"}
+
+// SizeLabelPrefix is the prefix of the size labels, such as size/M
+const SizeLabelPrefix = "size/"
+
+// StatsCommentMarker is the identity of the sticky comment about the huge pull request
+const StatsCommentMarker = "Size check from [gogit](https://github.com/linuxsuren/gogit)."
+
+// sizeThresholds are the upper bounds (exclusive) of the changed lines of each size, the last one has no bound
+var sizeThresholds = []struct {
+	size  string
+	lines int
+}{{"XS", 10}, {"S", 30}, {"M", 100}, {"L", 500}, {"XL", 1000}, {"XXL", 0}}
+
+// StatsOptions is the options for computing the diff statistics
+type StatsOptions struct {
+	// Groups are the path globs which the statistics are aggregated by, a file belongs to the first matched one
+	Groups []string
+	// Ignore are the path globs which are not counted in the size, such as the generated files
+	Ignore []string
+}
+
+// FileStats is the statistics of a changed file
+type FileStats struct {
+	Path      string `json:"path" yaml:"path"`
+	Additions int    `json:"additions" yaml:"additions"`
+	Deletions int    `json:"deletions" yaml:"deletions"`
+	Ignored   bool   `json:"ignored,omitempty" yaml:"ignored,omitempty"`
+}
+
+// GroupStats is the statistics of the files which match a path glob
+type GroupStats struct {
+	Pattern   string `json:"pattern" yaml:"pattern"`
+	Files     int    `json:"files" yaml:"files"`
+	Additions int    `json:"additions" yaml:"additions"`
+	Deletions int    `json:"deletions" yaml:"deletions"`
+}
+
+// DiffStats is the statistics of a pull request, the ignored files are not counted in the total
+type DiffStats struct {
+	Additions int          `json:"additions" yaml:"additions"`
+	Deletions int          `json:"deletions" yaml:"deletions"`
+	Size      string       `json:"size" yaml:"size"`
+	Groups    []GroupStats `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Files     []FileStats  `json:"files" yaml:"files"`
+}
+
+// Lines returns the number of the changed lines
+func (d *DiffStats) Lines() int {
+	return d.Additions + d.Deletions
+}
+
+// SizeOf returns the size of the changed lines, one of XS, S, M, L, XL and XXL
+func SizeOf(lines int) (size string) {
+	for _, threshold := range sizeThresholds {
+		size = threshold.size
+		if lines < threshold.lines {
+			break
+		}
+	}
+	return
+}
+
+// ComputeDiffStats computes the statistics of the changes
+func ComputeDiffStats(changes []*scm.Change, opt StatsOptions) (stats *DiffStats, err error) {
+	var ignore, groups []*regexp.Regexp
+	if ignore, err = compilePathGlobs(opt.Ignore); err != nil {
+		return
+	}
+	if groups, err = compilePathGlobs(opt.Groups); err != nil {
+		return
+	}
+
+	stats = &DiffStats{}
+	for _, pattern := range opt.Groups {
+		stats.Groups = append(stats.Groups, GroupStats{Pattern: pattern})
+	}
+	for _, change := range changes {
+		file := FileStats{Path: change.Path, Additions: change.Additions, Deletions: change.Deletions}
+		if file.Additions == 0 && file.Deletions == 0 {
+			// not all the providers return the numbers
+			file.Additions, file.Deletions = countPatchLines(change.Patch)
+		}
+		file.Ignored = matchAny(ignore, file.Path)
+		stats.Files = append(stats.Files, file)
+		if file.Ignored {
+			continue
+		}
+
+		stats.Additions += file.Additions
+		stats.Deletions += file.Deletions
+		for i, group := range groups {
+			if group.MatchString(file.Path) {
+				stats.Groups[i].Files++
+				stats.Groups[i].Additions += file.Additions
+				stats.Groups[i].Deletions += file.Deletions
+				break
+			}
+		}
+	}
+	stats.Size = SizeOf(stats.Lines())
+	return
+}
+
+func compilePathGlobs(patterns []string) (globs []*regexp.Regexp, err error) {
+	for _, pattern := range patterns {
+		var glob *regexp.Regexp
+		if glob, err = codeOwnersRegexp(pattern); err != nil {
+			err = fmt.Errorf("invalid path glob %q: %v", pattern, err)
+			return
+		}
+		globs = append(globs, glob)
+	}
+	return
+}
+
+func matchAny(globs []*regexp.Regexp, path string) bool {
+	for _, glob := range globs {
+		if glob.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// countPatchLines counts the added and removed lines of the unified diff
+func countPatchLines(patch string) (additions, deletions int) {
+	// the file headers, such as "--- a/main.go", are only before the first hunk
+	started := false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			started = true
+		case !started:
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return
+}
+
+// DiffStats computes the statistics of the pull request
+func (s *StatusMaker) DiffStats(ctx context.Context, opt StatsOptions) (stats *DiffStats, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	var changes []*scm.Change
+	if changes, err = listAllChanges(ctx, scmClient, s.repo, s.pr); err == nil {
+		stats, err = ComputeDiffStats(changes, opt)
+	}
+	return
+}
+
+// ApplySizeLabel adds the size label to the pull request, and removes the other size labels
+func (s *StatusMaker) ApplySizeLabel(ctx context.Context, size string) (err error) {
+	var labels []*scm.Label
+	if labels, err = s.ListLabels(ctx); err != nil {
+		return
+	}
+
+	target := SizeLabelPrefix + size
+	var stale []string
+	found := false
+	for _, label := range labels {
+		if label.Name == target {
+			found = true
+		} else if strings.HasPrefix(label.Name, SizeLabelPrefix) {
+			stale = append(stale, label.Name)
+		}
+	}
+
+	if len(stale) > 0 {
+		if err = s.RemoveLabels(ctx, stale...); err != nil {
+			return
+		}
+	}
+	if !found {
+		err = s.AddLabels(ctx, target)
+	}
+	return
+}
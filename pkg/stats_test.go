@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeOf(t *testing.T) {
+	assert.Equal(t, "XS", SizeOf(0))
+	assert.Equal(t, "S", SizeOf(10))
+	assert.Equal(t, "M", SizeOf(99))
+	assert.Equal(t, "L", SizeOf(100))
+	assert.Equal(t, "XL", SizeOf(999))
+	assert.Equal(t, "XXL", SizeOf(1000))
+}
+
+func TestComputeDiffStats(t *testing.T) {
+	changes := []*scm.Change{
+		{Path: "pkg/git.go", Additions: 20, Deletions: 5},
+		{Path: "cmd/root.go", Patch: "@@ -1,2 +1,2 @@\n-old\n+new\n+line\n context"},
+		{Path: "vendor/github.com/a/b.go", Additions: 1000},
+		{Path: "api/v1/zz_generated.deepcopy.go", Additions: 300},
+		{Path: "README.md", Additions: 1},
+	}
+
+	stats, err := ComputeDiffStats(changes, StatsOptions{Groups: []string{"pkg/", "*.go"}, Ignore: DefaultStatsIgnore})
+	assert.NoError(t, err)
+	assert.Equal(t, 23, stats.Additions)
+	assert.Equal(t, 6, stats.Deletions)
+	assert.Equal(t, "S", stats.Size)
+	assert.Equal(t, []GroupStats{
+		{Pattern: "pkg/", Files: 1, Additions: 20, Deletions: 5},
+		{Pattern: "*.go", Files: 1, Additions: 2, Deletions: 1},
+	}, stats.Groups)
+	if assert.Len(t, stats.Files, 5) {
+		assert.Equal(t, FileStats{Path: "cmd/root.go", Additions: 2, Deletions: 1}, stats.Files[1])
+		assert.True(t, stats.Files[2].Ignored)
+		assert.True(t, stats.Files[3].Ignored)
+	}
+}
+
+func TestCountPatchLines(t *testing.T) {
+	additions, deletions := countPatchLines("--- a/query.sql\n+++ b/query.sql\n@@ -1,2 +1,2 @@\n--- SQL comment\n+++i;\n select 1")
+	assert.Equal(t, 1, additions)
+	assert.Equal(t, 1, deletions)
+
+	additions, deletions = countPatchLines("@@ -1 +1 @@\n-a\n+b\n@@ -10 +10 @@\n-c\n+d")
+	assert.Equal(t, 2, additions)
+	assert.Equal(t, 2, deletions)
+}
+
+func TestDiffStatsAndSizeLabel(t *testing.T) {
+	client, data := fake.NewDefault()
+	ctx := context.Background()
+	data.PullRequestChanges[1] = []*scm.Change{{Path: "main.go", Additions: 200}}
+	data.PullRequestLabelsExisting = []string{"owner/repo#1:size/XS", "owner/repo#1:bug"}
+
+	maker := NewStatusMaker("owner/repo", "").WithPR(1).WithClient(client)
+	stats, err := maker.DiffStats(ctx, StatsOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "L", stats.Size)
+
+	assert.NoError(t, maker.ApplySizeLabel(ctx, stats.Size))
+	assert.Equal(t, []string{"owner/repo#1:size/XS"}, data.PullRequestLabelsRemoved)
+	assert.Equal(t, []string{"owner/repo#1:size/L"}, data.PullRequestLabelsAdded)
+}
@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// SubmoduleOptions is the options for updating the submodules
+type SubmoduleOptions struct {
+	// Depth is the number of commits to fetch, 0 means the full history
+	Depth int
+	// Recursive updates the nested submodules as well
+	Recursive bool
+	// Remote checks out the tip of the tracked branch instead of the recorded commit, like `git submodule update --remote`.
+	// The submodules without a tracked branch are still checked out at the recorded commit
+	Remote bool
+	// Auth returns the auth method of the submodule URL, no auth if it's nil
+	Auth     func(gitURL string) (transport.AuthMethod, error)
+	Progress io.Writer
+}
+
+// UpdatedSubmodule is a submodule which is checked out
+type UpdatedSubmodule struct {
+	// Path is relative to the top-level repository
+	Path string
+	URL  string
+	// Branch is the tracked branch, it's empty if the submodule does not track a branch
+	Branch string
+	Commit string
+}
+
+var scpLikeURL = regexp.MustCompile(`^(?:([\w.-]+)@)?([\w.-]+):(.*)$`)
+
+// ResolveSubmoduleURL resolves the URL of a submodule against the URL of the parent repository, the relative URL
+// starts with ./ or ../. The SCP-like URLs, such as git@github.com:linuxsuren/gogit.git, are converted to ssh:// URLs
+func ResolveSubmoduleURL(parentURL, submoduleURL string) (resolved string, err error) {
+	if !strings.HasPrefix(submoduleURL, "./") && !strings.HasPrefix(submoduleURL, "../") {
+		resolved = normalizeGitURL(submoduleURL)
+		return
+	}
+	if parentURL == "" {
+		err = fmt.Errorf("cannot resolve the relative submodule URL %q without the remote URL of the parent", submoduleURL)
+		return
+	}
+
+	var base *url.URL
+	if base, err = url.Parse(normalizeGitURL(parentURL)); err != nil {
+		err = fmt.Errorf("invalid git URL %q: %v", parentURL, err)
+		return
+	}
+	// the parent URL is treated as a directory, see also https://git-scm.com/docs/git-submodule#_options
+	base.Path = path.Join(strings.TrimSuffix(base.Path, "/"), submoduleURL)
+	resolved = base.String()
+	return
+}
+
+func normalizeGitURL(gitURL string) string {
+	if strings.Contains(gitURL, "://") || strings.HasPrefix(gitURL, "/") {
+		return gitURL
+	}
+	if groups := scpLikeURL.FindStringSubmatch(gitURL); groups != nil {
+		user := ""
+		if groups[1] != "" {
+			user = groups[1] + "@"
+		}
+		return fmt.Sprintf("ssh://%s%s/%s", user, groups[2], strings.TrimPrefix(groups[3], "/"))
+	}
+	return gitURL
+}
+
+// UpdateSubmodules initializes, fetches and checks out the submodules of the git repository
+func UpdateSubmodules(ctx context.Context, repo *git.Repository, opt SubmoduleOptions) (updated []UpdatedSubmodule, err error) {
+	var worktree *git.Worktree
+	if worktree, err = repo.Worktree(); err != nil {
+		return
+	}
+
+	var submodules git.Submodules
+	if submodules, err = worktree.Submodules(); err != nil || len(submodules) == 0 {
+		return
+	}
+
+	// go-git resolves the relative URLs against the first remote as well
+	var parentURL, parentBranch string
+	var remotes []*git.Remote
+	if remotes, err = repo.Remotes(); err != nil {
+		return
+	}
+	if len(remotes) > 0 && len(remotes[0].Config().URLs) > 0 {
+		parentURL = remotes[0].Config().URLs[0]
+	}
+	if head, headErr := repo.Head(); headErr == nil && head.Name().IsBranch() {
+		parentBranch = head.Name().Short()
+	}
+
+	for _, submodule := range submodules {
+		cfg := submodule.Config()
+		if cfg.URL, err = ResolveSubmoduleURL(parentURL, cfg.URL); err != nil {
+			return
+		}
+		if err = submodule.Init(); err != nil && err != git.ErrSubmoduleAlreadyInitialized {
+			err = fmt.Errorf("failed to init submodule %q: %v", cfg.Path, err)
+			return
+		}
+
+		var status *git.SubmoduleStatus
+		if status, err = submodule.Status(); err != nil {
+			err = fmt.Errorf("failed to get the status of submodule %q: %v", cfg.Path, err)
+			return
+		}
+
+		var subRepo *git.Repository
+		if subRepo, err = submodule.Repository(); err != nil {
+			return
+		}
+
+		item := UpdatedSubmodule{Path: cfg.Path, URL: cfg.URL, Branch: cfg.Branch}
+		if item.Branch == "." {
+			// the submodule tracks the same branch as the parent
+			item.Branch = parentBranch
+		}
+
+		var hash plumbing.Hash
+		if hash, err = fetchSubmodule(ctx, subRepo, status.Expected, item, opt); err != nil {
+			return
+		}
+
+		var subWorktree *git.Worktree
+		if subWorktree, err = subRepo.Worktree(); err != nil {
+			return
+		}
+		if err = subWorktree.Checkout(&git.CheckoutOptions{Hash: hash, Force: true}); err != nil {
+			err = fmt.Errorf("failed to checkout %s in submodule %q: %v", hash, cfg.Path, err)
+			return
+		}
+		item.Commit = hash.String()
+		updated = append(updated, item)
+
+		if opt.Recursive {
+			var nested []UpdatedSubmodule
+			if nested, err = UpdateSubmodules(ctx, subRepo, opt); err != nil {
+				return
+			}
+			for _, nestedItem := range nested {
+				nestedItem.Path = path.Join(cfg.Path, nestedItem.Path)
+				updated = append(updated, nestedItem)
+			}
+		}
+	}
+	return
+}
+
+// submoduleFetchHead is the ref of the recorded commit which is fetched by its SHA
+const submoduleFetchHead plumbing.ReferenceName = "FETCH_HEAD"
+
+// unshallowDepth fetches the full history of a shallow clone like `git fetch --unshallow`, go-git does not fetch
+// the commits which are missing in a shallow clone if the depth is 0
+const unshallowDepth = math.MaxInt32
+
+// fetchSubmodule fetches the tracked branch, or all the branches, and returns the commit to check out
+func fetchSubmodule(ctx context.Context, repo *git.Repository, expected plumbing.Hash, item UpdatedSubmodule,
+	opt SubmoduleOptions) (hash plumbing.Hash, err error) {
+	var auth transport.AuthMethod
+	if opt.Auth != nil {
+		if auth, err = opt.Auth(item.URL); err != nil {
+			return
+		}
+	}
+
+	var refSpecs []config.RefSpec
+	if item.Branch != "" {
+		refSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/%[1]s:refs/remotes/%[2]s/%[1]s",
+			item.Branch, git.DefaultRemoteName))}
+	}
+	if err = repo.FetchContext(ctx, &git.FetchOptions{
+		Auth:     auth,
+		RefSpecs: refSpecs,
+		Depth:    opt.Depth,
+		Progress: opt.Progress,
+	}); err != nil && err != git.NoErrAlreadyUpToDate {
+		err = fmt.Errorf("failed to fetch submodule %q from %q: %v", item.Path, item.URL, err)
+		return
+	}
+	err = nil
+
+	if opt.Remote && item.Branch != "" {
+		var ref *plumbing.Reference
+		if ref, err = repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, item.Branch), true); err != nil {
+			err = fmt.Errorf("cannot find the branch %q of submodule %q: %v", item.Branch, item.Path, err)
+			return
+		}
+		hash = ref.Hash()
+		return
+	}
+
+	hash = expected
+	if _, commitErr := repo.CommitObject(hash); commitErr != plumbing.ErrObjectNotFound {
+		return
+	}
+
+	// the recorded commit is not in the fetched history, such as it's not a branch tip of a shallow clone
+	fetchOptions := &git.FetchOptions{
+		Auth:     auth,
+		RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", hash, submoduleFetchHead))},
+		Depth:    opt.Depth,
+		Progress: opt.Progress,
+	}
+	if err = repo.FetchContext(ctx, fetchOptions); err == git.ErrExactSHA1NotSupported {
+		// the commit might be anywhere in the history of the branches
+		fetchOptions.RefSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*",
+			git.DefaultRemoteName))}
+		if opt.Depth > 0 {
+			fetchOptions.Depth = unshallowDepth
+		}
+		err = repo.FetchContext(ctx, fetchOptions)
+	}
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		err = fmt.Errorf("failed to fetch commit %s of submodule %q: %v", hash, item.Path, err)
+		return
+	}
+	if _, err = repo.CommitObject(hash); err != nil {
+		err = fmt.Errorf("cannot find commit %s of submodule %q: %v", hash, item.Path, err)
+	}
+	return
+}
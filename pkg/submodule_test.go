@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		parentURL    string
+		submoduleURL string
+		expect       string
+		hasErr       bool
+	}{{
+		name:         "absolute URL",
+		parentURL:    "https://github.com/linuxsuren/gogit.git",
+		submoduleURL: "https://gitlab.com/linuxsuren/lib.git",
+		expect:       "https://gitlab.com/linuxsuren/lib.git",
+	}, {
+		name:         "sibling repository",
+		parentURL:    "https://github.com/linuxsuren/gogit.git",
+		submoduleURL: "../lib.git",
+		expect:       "https://github.com/linuxsuren/lib.git",
+	}, {
+		name:         "nested repository",
+		parentURL:    "https://github.com/linuxsuren/gogit/",
+		submoduleURL: "./lib",
+		expect:       "https://github.com/linuxsuren/gogit/lib",
+	}, {
+		name:         "SCP-like parent URL",
+		parentURL:    "git@github.com:linuxsuren/gogit.git",
+		submoduleURL: "../../other/lib.git",
+		expect:       "ssh://git@github.com/other/lib.git",
+	}, {
+		name:         "SCP-like submodule URL",
+		submoduleURL: "git@github.com:linuxsuren/lib.git",
+		expect:       "ssh://git@github.com/linuxsuren/lib.git",
+	}, {
+		name:         "local path",
+		parentURL:    "/tmp/repos/gogit",
+		submoduleURL: "../lib",
+		expect:       "/tmp/repos/lib",
+	}, {
+		name:         "relative URL without parent",
+		submoduleURL: "../lib",
+		hasErr:       true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := ResolveSubmoduleURL(tt.parentURL, tt.submoduleURL)
+			assert.Equal(t, tt.hasErr, err != nil, err)
+			assert.Equal(t, tt.expect, resolved)
+		})
+	}
+}
+
+func TestUpdateSubmodules(t *testing.T) {
+	root := t.TempDir()
+	nested, nestedRepo := initRepo(t, root, "nested")
+	nestedHead := commitAll(t, nestedRepo, "nested")
+
+	lib, libRepo := initRepo(t, root, "lib")
+	addSubmodule(t, libRepo, lib, "nested", "../nested", "", nestedHead)
+	libFirst := commitAll(t, libRepo, "first")
+	libSecond := commitAll(t, libRepo, "second")
+
+	app, appRepo := initRepo(t, root, "app")
+	addSubmodule(t, appRepo, app, "lib", "../lib", "master", libFirst)
+	commitAll(t, appRepo, "add lib")
+
+	clone := func(t *testing.T) *git.Repository {
+		repo, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: app})
+		assert.NoError(t, err)
+		return repo
+	}
+
+	t.Run("recorded commits", func(t *testing.T) {
+		repo := clone(t)
+		var authURLs []string
+		updated, err := UpdateSubmodules(context.TODO(), repo, SubmoduleOptions{
+			Recursive: true,
+			Auth: func(gitURL string) (auth transport.AuthMethod, err error) {
+				authURLs = append(authURLs, gitURL)
+				return
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []UpdatedSubmodule{
+			{Path: "lib", URL: lib, Branch: "master", Commit: libFirst.String()},
+			{Path: "lib/nested", URL: nested, Commit: nestedHead.String()},
+		}, updated)
+		assert.Equal(t, []string{lib, nested}, authURLs)
+
+		worktree, err := repo.Worktree()
+		assert.NoError(t, err)
+		_, err = worktree.Filesystem.Stat("lib/nested/nested.txt")
+		assert.NoError(t, err)
+	})
+
+	t.Run("tracked branch", func(t *testing.T) {
+		updated, err := UpdateSubmodules(context.TODO(), clone(t), SubmoduleOptions{Remote: true, Depth: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, updated, 1) {
+			assert.Equal(t, libSecond.String(), updated[0].Commit)
+		}
+	})
+
+	t.Run("recorded commit is not a branch tip of a shallow clone", func(t *testing.T) {
+		repo := clone(t)
+		updated, err := UpdateSubmodules(context.TODO(), repo, SubmoduleOptions{Depth: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, updated, 1) {
+			assert.Equal(t, libFirst.String(), updated[0].Commit)
+		}
+
+		worktree, err := repo.Worktree()
+		assert.NoError(t, err)
+		subRepo, err := git.PlainOpen(filepath.Join(worktree.Filesystem.Root(), "lib"))
+		assert.NoError(t, err)
+		_, err = subRepo.Reference(plumbing.ReferenceName(libFirst.String()), false)
+		assert.Equal(t, plumbing.ErrReferenceNotFound, err)
+	})
+}
+
+func initRepo(t *testing.T, root, name string) (dir string, repo *git.Repository) {
+	dir = filepath.Join(root, name)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name+".txt"), []byte(name), 0644))
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	_, err = worktree.Add(name + ".txt")
+	assert.NoError(t, err)
+	return
+}
+
+// addSubmodule stages a submodule like `git submodule add`, but without cloning it
+func addSubmodule(t *testing.T, repo *git.Repository, dir, name, url, branch string, hash plumbing.Hash) {
+	gitmodules := fmt.Sprintf("[submodule %q]\n\tpath = %s\n\turl = %s\n", name, name, url)
+	if branch != "" {
+		gitmodules += fmt.Sprintf("\tbranch = %s\n", branch)
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".gitmodules"), []byte(gitmodules), 0644))
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	_, err = worktree.Add(".gitmodules")
+	assert.NoError(t, err)
+
+	idx, err := repo.Storer.Index()
+	assert.NoError(t, err)
+	idx.Entries = append(idx.Entries, &index.Entry{Name: name, Hash: hash, Mode: filemode.Submodule})
+	assert.NoError(t, repo.Storer.SetIndex(idx))
+}
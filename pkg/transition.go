@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// StatusTransition is the change of a commit status which is worth notifying
+type StatusTransition struct {
+	Label string `json:"label"`
+	// Previous is scm.StateUnknown if there is no previous status
+	Previous scm.State `json:"previous"`
+	Current  scm.State `json:"current"`
+	Target   string    `json:"target"`
+}
+
+// NewStatusTransition returns the transition from the previous status to the current one. It returns nil unless
+// the status goes from success to failure, from failure back to success, or the first terminal state arrives
+func NewStatusTransition(previous *scm.Status, current *scm.StatusInput) (transition *StatusTransition) {
+	previousState := scm.StateUnknown
+	target := current.Target
+	if previous != nil {
+		previousState = previous.State
+		if target == "" {
+			target = previous.Target
+		}
+	}
+
+	var changed bool
+	switch {
+	case !isTerminalState(current.State):
+	case !isTerminalState(previousState):
+		changed = true
+	case previousState == scm.StateSuccess:
+		changed = isFailedState(current.State)
+	case isFailedState(previousState):
+		changed = current.State == scm.StateSuccess
+	}
+
+	if changed {
+		transition = &StatusTransition{
+			Label:    current.Label,
+			Previous: previousState,
+			Current:  current.State,
+			Target:   target,
+		}
+	}
+	return
+}
+
+// NotifiedStatusSuffix is appended to the label of the status which is recorded by RecordStatus, the status set
+// by the CI is kept as it is
+const NotifiedStatusSuffix = "/gogit-notified"
+
+// NotifiedStatusLabel returns the label of the status which records the last notified state of the label
+func NotifiedStatusLabel(label string) string {
+	return label + NotifiedStatusSuffix
+}
+
+// FindStatusTransition compares the current state with the last notified state of the head commit. For a new push,
+// it's compared with the last notified or terminal state of the label on the earlier commits of the pull request
+func (s *StatusMaker) FindStatusTransition(ctx context.Context, scmClient *scm.Client, sha string,
+	current *scm.StatusInput) (transition *StatusTransition, err error) {
+	var previous *scm.Status
+	// the status of the label on the head commit is set by the CI, it might be the current state already
+	if previous, err = s.findLastState(ctx, scmClient, sha, current.Label, false); err != nil {
+		return
+	}
+	if previous == nil {
+		if previous, err = s.findEarlierTerminalStatus(ctx, scmClient, sha, current.Label); err != nil {
+			return
+		}
+	}
+	transition = NewStatusTransition(previous, current)
+	return
+}
+
+// findEarlierTerminalStatus returns the latest terminal status of the label on the commits of the pull request
+// except the head commit
+func (s *StatusMaker) findEarlierTerminalStatus(ctx context.Context, scmClient *scm.Client, sha,
+	label string) (status *scm.Status, err error) {
+	var commits []*scm.Commit
+	if commits, err = s.listPullRequestCommits(ctx, scmClient); err != nil {
+		return
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Committer.Date.After(commits[j].Committer.Date)
+	})
+
+	for _, commit := range commits {
+		if commit.Sha == sha {
+			continue
+		}
+		if status, err = s.findLastState(ctx, scmClient, commit.Sha, label, true); err != nil || status != nil {
+			return
+		}
+	}
+	return
+}
+
+// findLastState returns the terminal status which is recorded by RecordStatus. The terminal status of the label
+// itself is returned if there is no recorded one and withLabel is true
+func (s *StatusMaker) findLastState(ctx context.Context, scmClient *scm.Client, sha, label string,
+	withLabel bool) (status *scm.Status, err error) {
+	var statuses []*scm.Status
+	if statuses, _, err = scmClient.Repositories.ListStatus(ctx, s.repo, sha, &scm.ListOptions{Page: 1, Size: 100}); err != nil {
+		err = fmt.Errorf("failed to list the status of %s, error: %v", shortSha(sha), err)
+		return
+	}
+
+	for _, item := range statuses {
+		if !isTerminalState(item.State) {
+			continue
+		}
+		if item.Label == NotifiedStatusLabel(label) {
+			status = item
+			return
+		}
+		if withLabel && item.Label == label && status == nil {
+			status = item
+		}
+	}
+	return
+}
+
+// RecordStatus records the notified terminal state on the head commit with the label NotifiedStatusLabel, then the
+// next notification is compared with it. It should be called only if the notification is sent
+func (s *StatusMaker) RecordStatus(ctx context.Context, scmClient *scm.Client, sha string, current *scm.StatusInput) (err error) {
+	if !isTerminalState(current.State) {
+		return
+	}
+
+	input := &scm.StatusInput{
+		Label:  NotifiedStatusLabel(current.Label),
+		State:  current.State,
+		Target: current.Target,
+		Desc:   fmt.Sprintf("%s is notified by gogit", current.Label),
+	}
+	if input.Target == "" {
+		// link to the build of the CI
+		var existing *scm.Status
+		if existing, err = s.FindPreviousStatus(ctx, scmClient, sha, current.Label); err != nil {
+			return
+		}
+		if existing != nil {
+			input.Target = existing.Target
+		}
+	}
+	if _, _, err = scmClient.Repositories.CreateStatus(ctx, s.repo, sha, input); err != nil {
+		err = fmt.Errorf("failed to record the status %q: %v", input.Label, err)
+	}
+	return
+}
+
+func isTerminalState(state scm.State) bool {
+	return state == scm.StateSuccess || state == scm.StateCanceled || isFailedState(state)
+}
+
+func isFailedState(state scm.State) bool {
+	return state == scm.StateFailure || state == scm.StateError
+}
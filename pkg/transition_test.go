@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatusTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous *scm.Status
+		current  scm.State
+		changed  bool
+	}{{
+		name:    "first terminal state",
+		current: scm.StateSuccess,
+		changed: true,
+	}, {
+		name:     "pending to failure",
+		previous: &scm.Status{State: scm.StatePending},
+		current:  scm.StateFailure,
+		changed:  true,
+	}, {
+		name:     "success to failure",
+		previous: &scm.Status{State: scm.StateSuccess},
+		current:  scm.StateFailure,
+		changed:  true,
+	}, {
+		name:     "error to success",
+		previous: &scm.Status{State: scm.StateError},
+		current:  scm.StateSuccess,
+		changed:  true,
+	}, {
+		name:     "success to success",
+		previous: &scm.Status{State: scm.StateSuccess},
+		current:  scm.StateSuccess,
+	}, {
+		name:     "failure to error",
+		previous: &scm.Status{State: scm.StateFailure},
+		current:  scm.StateError,
+	}, {
+		name:     "success to pending",
+		previous: &scm.Status{State: scm.StateSuccess},
+		current:  scm.StatePending,
+	}, {
+		name:     "canceled to failure",
+		previous: &scm.Status{State: scm.StateCanceled},
+		current:  scm.StateFailure,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition := NewStatusTransition(tt.previous, &scm.StatusInput{Label: "build", State: tt.current})
+			assert.Equal(t, tt.changed, transition != nil)
+		})
+	}
+}
+
+func TestFindStatusTransition(t *testing.T) {
+	statuses := map[string][]map[string]any{}
+	var recorded []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v3/repos/owner/repo/pulls/1/commits":
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"sha": "older", "commit": map[string]any{"committer": map[string]any{"date": "2023-01-01T00:00:00Z"}}},
+				{"sha": "head", "commit": map[string]any{"committer": map[string]any{"date": "2023-01-03T00:00:00Z"}}},
+				{"sha": "old", "commit": map[string]any{"committer": map[string]any{"date": "2023-01-02T00:00:00Z"}}},
+			})
+		case strings.HasPrefix(r.URL.Path, "/api/v3/repos/owner/repo/statuses/"):
+			sha := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/owner/repo/statuses/")
+			if r.Method == http.MethodPost {
+				status := map[string]any{}
+				_ = json.NewDecoder(r.Body).Decode(&status)
+				recorded = append(recorded, status)
+				statuses[sha] = append([]map[string]any{status}, statuses[sha]...)
+				_ = json.NewEncoder(w).Encode(status)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(statuses[sha])
+		}
+	}))
+	defer server.Close()
+
+	client, err := factory.NewClient("github", server.URL, "token")
+	assert.NoError(t, err)
+	maker := NewStatusMaker("owner/repo", "token").WithProvider("github").WithPR(1)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		statuses map[string][]map[string]any
+		current  scm.State
+		expect   *StatusTransition
+	}{{
+		name: "new push, the previous commit was success, now success",
+		statuses: map[string][]map[string]any{
+			"old":   {{"context": "build", "state": "success", "target_url": "https://ci/2"}},
+			"older": {{"context": "build", "state": "failure"}},
+		},
+		current: scm.StateSuccess,
+	}, {
+		name: "new push, the last terminal state was success, now failure",
+		statuses: map[string][]map[string]any{
+			"head":  {{"context": "build", "state": "pending"}},
+			"old":   {{"context": "build", "state": "pending"}},
+			"older": {{"context": "build", "state": "success", "target_url": "https://ci/2"}},
+		},
+		current: scm.StateFailure,
+		expect:  &StatusTransition{Label: "build", Previous: scm.StateSuccess, Current: scm.StateFailure, Target: "https://ci/2"},
+	}, {
+		name: "the status set by CI on the head commit is not compared",
+		statuses: map[string][]map[string]any{
+			"head": {{"context": "build", "state": "failure", "target_url": "https://ci/3"}},
+			"old":  {{"context": "build", "state": "success", "target_url": "https://ci/2"}},
+		},
+		current: scm.StateFailure,
+		expect:  &StatusTransition{Label: "build", Previous: scm.StateSuccess, Current: scm.StateFailure, Target: "https://ci/2"},
+	}, {
+		name: "the recorded status of the head commit",
+		statuses: map[string][]map[string]any{
+			"head": {
+				{"context": "build", "state": "success"},
+				{"context": "build/gogit-notified", "state": "failure", "target_url": "https://ci/3"},
+			},
+			"old": {{"context": "build", "state": "success"}},
+		},
+		current: scm.StateSuccess,
+		expect:  &StatusTransition{Label: "build", Previous: scm.StateFailure, Current: scm.StateSuccess, Target: "https://ci/3"},
+	}, {
+		name: "the recorded status of an earlier commit takes precedence",
+		statuses: map[string][]map[string]any{
+			"old": {
+				{"context": "build", "state": "success"},
+				{"context": "build/gogit-notified", "state": "failure"},
+			},
+		},
+		current: scm.StateSuccess,
+		expect:  &StatusTransition{Label: "build", Previous: scm.StateFailure, Current: scm.StateSuccess},
+	}, {
+		name:    "the first terminal state",
+		current: scm.StateError,
+		expect:  &StatusTransition{Label: "build", Previous: scm.StateUnknown, Current: scm.StateError},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses = tt.statuses
+			if statuses == nil {
+				statuses = map[string][]map[string]any{}
+			}
+			transition, err := maker.FindStatusTransition(ctx, client, "head", &scm.StatusInput{Label: "build", State: tt.current})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, transition)
+		})
+	}
+
+	t.Run("record the status", func(t *testing.T) {
+		statuses = map[string][]map[string]any{
+			"head": {{"context": "build", "state": "success", "target_url": "https://ci/3"}},
+		}
+		recorded = nil
+		assert.NoError(t, maker.RecordStatus(ctx, client, "head", &scm.StatusInput{Label: "build", State: scm.StatePending}))
+		assert.Empty(t, recorded)
+
+		current := &scm.StatusInput{Label: "build", State: scm.StateSuccess}
+		assert.NoError(t, maker.RecordStatus(ctx, client, "head", current))
+		if assert.Len(t, recorded, 1) {
+			assert.Equal(t, "build/gogit-notified", recorded[0]["context"])
+			assert.Equal(t, "https://ci/3", recorded[0]["target_url"])
+		}
+		// the status set by the CI is kept
+		assert.Equal(t, "build", statuses["head"][1]["context"])
+
+		// the same status is not notified again
+		transition, err := maker.FindStatusTransition(ctx, client, "head", current)
+		assert.NoError(t, err)
+		assert.Nil(t, transition)
+	})
+}
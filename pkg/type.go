@@ -33,6 +33,10 @@ type RepoInformation struct {
 	Repo     string
 	PrNumber int
 
+	// IssueNumber and Sha are the alternative comment targets of the pull request
+	IssueNumber int
+	Sha         string
+
 	Username, Token string
 
 	Status      string
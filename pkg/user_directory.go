@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UserDirectory maps the git logins across providers to the people and their chat identities
+type UserDirectory struct {
+	Users []DirectoryUser `yaml:"users" json:"users"`
+
+	// index is the users by provider and login
+	index map[string]*DirectoryUser
+}
+
+// DirectoryUser is a person in the user directory
+type DirectoryUser struct {
+	Name string `yaml:"name" json:"name"`
+	// Logins maps the provider to the git login, such as github: linuxsuren
+	Logins map[string]string `yaml:"logins" json:"logins"`
+	Email  string            `yaml:"email" json:"email"`
+	Mobile string            `yaml:"mobile" json:"mobile"`
+	// ChatIDs maps the notifier kind to the user ID of the chat tool, such as dingding: user-id
+	ChatIDs map[string]string `yaml:"chatIds" json:"chatIds"`
+	// Channels are the personal notify channels, such as the DingDing robot with a token
+	Channels []NotifyChannel `yaml:"channels" json:"channels"`
+
+	QuietHours *QuietHours `yaml:"quietHours" json:"quietHours"`
+	// MutedRepos are the patterns of the repositories which will not be notified, such as linuxsuren/*
+	MutedRepos []string `yaml:"mutedRepos" json:"mutedRepos"`
+}
+
+// QuietHours is the daily time range without notifications, it could cross midnight
+type QuietHours struct {
+	// Start and End are in format 15:04
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end" json:"end"`
+	// Timezone is the IANA name, such as Asia/Shanghai. The local timezone will be used if it's empty
+	Timezone string `yaml:"timezone" json:"timezone"`
+}
+
+// ParseUserDirectory parses the user directory from YAML or JSON
+func ParseUserDirectory(data []byte) (directory *UserDirectory, err error) {
+	directory = &UserDirectory{}
+	if err = yaml.Unmarshal(data, directory); err != nil {
+		err = fmt.Errorf("cannot parse the user directory: %v", err)
+		return
+	}
+
+	directory.index = make(map[string]*DirectoryUser)
+	for i := range directory.Users {
+		user := &directory.Users[i]
+		for provider, login := range user.Logins {
+			key := provider + "/" + login
+			if _, ok := directory.index[key]; ok {
+				err = fmt.Errorf("the login %q of %s is duplicated", login, provider)
+				return
+			}
+			directory.index[key] = user
+		}
+
+		if user.QuietHours != nil {
+			if _, err = user.QuietHours.Contains(time.Now()); err != nil {
+				err = fmt.Errorf("invalid quiet hours of %q: %v", user.Name, err)
+				return
+			}
+		}
+		for _, pattern := range user.MutedRepos {
+			if _, err = path.Match(pattern, ""); err != nil {
+				err = fmt.Errorf("invalid muted repo %q of %q: %v", pattern, user.Name, err)
+				return
+			}
+		}
+		for _, channel := range user.Channels {
+			if _, err = channel.Notifier(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Find returns the user by provider and login, it returns nil if not found
+func (d *UserDirectory) Find(provider, login string) *DirectoryUser {
+	if d == nil {
+		return nil
+	}
+	return d.index[provider+"/"+login]
+}
+
+// Muted returns true if the user does not want to be notified about the repository at the moment
+func (u *DirectoryUser) Muted(repo string, now time.Time) bool {
+	for _, pattern := range u.MutedRepos {
+		if ok, _ := path.Match(pattern, repo); ok {
+			return true
+		}
+	}
+
+	if u.QuietHours != nil {
+		quiet, _ := u.QuietHours.Contains(now)
+		return quiet
+	}
+	return false
+}
+
+// Contains returns true if the time is in the quiet hours
+func (q *QuietHours) Contains(now time.Time) (ok bool, err error) {
+	location := time.Local
+	if q.Timezone != "" {
+		if location, err = time.LoadLocation(q.Timezone); err != nil {
+			return
+		}
+	}
+
+	var start, end time.Time
+	if start, err = time.Parse("15:04", q.Start); err != nil {
+		return
+	}
+	if end, err = time.Parse("15:04", q.End); err != nil {
+		return
+	}
+
+	now = now.In(location)
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+	if startMinutes <= endMinutes {
+		ok = minutes >= startMinutes && minutes < endMinutes
+	} else {
+		ok = minutes >= startMinutes || minutes < endMinutes
+	}
+	return
+}
+
+// NotifyResolver resolves the recipients and notifiers of the involved users through the notify config and
+// the user directory, both of them are optional
+type NotifyResolver struct {
+	Provider  string
+	Repo      string
+	Config    *NotifyConfig
+	Directory *UserDirectory
+}
+
+// Resolve returns the users who want to be notified, and the notifiers keyed by the channel name
+func (r *NotifyResolver) Resolve(logins []string, now time.Time) (recipients []string, notifiers map[string]Notifier, err error) {
+	notifiers = make(map[string]Notifier)
+	for _, login := range logins {
+		user := r.Directory.Find(r.Provider, login)
+		if user == nil {
+			recipients = append(recipients, login)
+			continue
+		}
+		if user.Muted(r.Repo, now) {
+			continue
+		}
+		recipients = append(recipients, login)
+
+		for _, channel := range user.Channels {
+			name := channel.Name
+			if name == "" {
+				name = channel.Kind
+			}
+			if notifiers[login+"/"+name], err = r.withMentions(channel, login).Notifier(); err != nil {
+				return
+			}
+		}
+	}
+
+	if r.Config != nil {
+		for _, channel := range r.Config.Resolve(recipients...) {
+			if notifiers[channel.Name], err = r.withMentions(channel, recipients...).Notifier(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// withMentions fills the DingDing mentions or the email addresses of the users from the directory,
+// the existing ones take precedence
+func (r *NotifyResolver) withMentions(channel NotifyChannel, logins ...string) NotifyChannel {
+	if r.Directory == nil {
+		return channel
+	}
+	switch channel.Kind {
+	case NotifierEmail:
+		return r.withEmails(channel, logins...)
+	case NotifierDingDing:
+	default:
+		return channel
+	}
+
+	mobiles := make(map[string]string, len(channel.Mobiles))
+	userIDs := make(map[string]string, len(channel.UserIDs))
+	for _, login := range logins {
+		if user := r.Directory.Find(r.Provider, login); user != nil {
+			if user.Mobile != "" {
+				mobiles[login] = user.Mobile
+			}
+			if userID := user.ChatIDs[NotifierDingDing]; userID != "" {
+				userIDs[login] = userID
+			}
+		}
+	}
+	for login, mobile := range channel.Mobiles {
+		mobiles[login] = mobile
+	}
+	for login, userID := range channel.UserIDs {
+		userIDs[login] = userID
+	}
+	channel.Mobiles, channel.UserIDs = mobiles, userIDs
+	return channel
+}
+
+// withEmails fills the addresses of the users from the directory into the SMTP config
+func (r *NotifyResolver) withEmails(channel NotifyChannel, logins ...string) NotifyChannel {
+	if channel.SMTP == nil {
+		return channel
+	}
+
+	smtp := *channel.SMTP
+	smtp.Addresses = make(map[string]string, len(channel.SMTP.Addresses))
+	for _, login := range logins {
+		if user := r.Directory.Find(r.Provider, login); user != nil && user.Email != "" {
+			smtp.Addresses[login] = user.Email
+		}
+	}
+	for login, email := range channel.SMTP.Addresses {
+		smtp.Addresses[login] = email
+	}
+	channel.SMTP = &smtp
+	return channel
+}
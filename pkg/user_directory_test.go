@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleUserDirectory = `
+users:
+  - name: Rick
+    logins:
+      github: linuxsuren
+      gitlab: rick
+    email: rick@example.com
+    mobile: "13800000000"
+    chatIds:
+      dingding: rick-id
+    channels:
+      - kind: dingding
+        token: rick-token
+  - name: Bob
+    logins:
+      github: bob
+    mutedRepos: [linuxsuren/*]
+  - name: Alice
+    logins:
+      github: alice
+    quietHours:
+      start: "22:00"
+      end: "08:00"
+      timezone: Asia/Shanghai
+`
+
+func TestParseUserDirectory(t *testing.T) {
+	directory, err := ParseUserDirectory([]byte(sampleUserDirectory))
+	assert.NoError(t, err)
+	assert.Equal(t, "Rick", directory.Find("gitlab", "rick").Name)
+	assert.Equal(t, "Rick", directory.Find("github", "linuxsuren").Name)
+	assert.Nil(t, directory.Find("gitlab", "linuxsuren"))
+
+	var nilDirectory *UserDirectory
+	assert.Nil(t, nilDirectory.Find("github", "linuxsuren"))
+
+	for _, data := range []string{
+		`users: [{logins: {github: a}}, {logins: {github: a}}]`,
+		`users: [{quietHours: {start: "25:00", end: "08:00"}}]`,
+		`users: [{quietHours: {start: "22:00", end: "08:00", timezone: Invalid/Zone}}]`,
+		`users: [{mutedRepos: ["["]}]`,
+		`users: [{channels: [{kind: slack}]}]`,
+		`users: {}`,
+	} {
+		_, err = ParseUserDirectory([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestDirectoryUserMuted(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+
+	user := &DirectoryUser{
+		MutedRepos: []string{"linuxsuren/*"},
+		QuietHours: &QuietHours{Start: "22:00", End: "08:00", Timezone: "Asia/Shanghai"},
+	}
+	day := time.Date(2023, 1, 1, 12, 0, 0, 0, shanghai)
+	assert.True(t, user.Muted("linuxsuren/gogit", day))
+	assert.False(t, user.Muted("other/gogit", day))
+	assert.True(t, user.Muted("other/gogit", time.Date(2023, 1, 1, 23, 0, 0, 0, shanghai)))
+	assert.True(t, user.Muted("other/gogit", time.Date(2023, 1, 1, 7, 59, 0, 0, shanghai)))
+	assert.False(t, user.Muted("other/gogit", time.Date(2023, 1, 1, 8, 0, 0, 0, shanghai)))
+	// 23:00 in Shanghai is 15:00 in UTC
+	assert.True(t, user.Muted("other/gogit", time.Date(2023, 1, 1, 15, 0, 0, 0, time.UTC)))
+
+	daytime := &QuietHours{Start: "12:00", End: "13:00", Timezone: "UTC"}
+	ok, err := daytime.Contains(time.Date(2023, 1, 1, 12, 30, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = daytime.Contains(time.Date(2023, 1, 1, 13, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNotifyResolver(t *testing.T) {
+	directory, err := ParseUserDirectory([]byte(sampleUserDirectory))
+	assert.NoError(t, err)
+	config, err := ParseNotifyConfig([]byte(`
+channels:
+  - name: team
+    kind: dingding
+    token: team-token
+    userIds:
+      bob: bob-id
+users:
+  alice: [team]
+  linuxsuren: [team]`))
+	assert.NoError(t, err)
+
+	resolver := &NotifyResolver{
+		Provider:  "github",
+		Repo:      "linuxsuren/gogit",
+		Config:    config,
+		Directory: directory,
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+
+	// bob muted the repository, alice is in the quiet hours
+	recipients, notifiers, err := resolver.Resolve([]string{"linuxsuren", "bob", "alice", "unknown"},
+		time.Date(2023, 1, 1, 23, 0, 0, 0, shanghai))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"linuxsuren", "unknown"}, recipients)
+	assert.Len(t, notifiers, 2)
+
+	if personal, ok := notifiers["linuxsuren/dingding"].(*DingDingNotifier); assert.True(t, ok) {
+		assert.Equal(t, dingdingWebhook+"rick-token", personal.Client.Webhook)
+		assert.Equal(t, map[string]string{"linuxsuren": "13800000000"}, personal.Mobiles)
+	}
+	if team, ok := notifiers["team"].(*DingDingNotifier); assert.True(t, ok) {
+		assert.Equal(t, map[string]string{"linuxsuren": "13800000000"}, team.Mobiles)
+		assert.Equal(t, map[string]string{"linuxsuren": "rick-id", "bob": "bob-id"}, team.UserIDs)
+	}
+
+	// works without the config and directory
+	recipients, notifiers, err = (&NotifyResolver{}).Resolve([]string{"linuxsuren"}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"linuxsuren"}, recipients)
+	assert.Empty(t, notifiers)
+}
+
+type fakeNotifier struct {
+	err      error
+	messages []Message
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, msg Message) error {
+	f.messages = append(f.messages, msg)
+	return f.err
+}
+
+func TestSendNotifications(t *testing.T) {
+	good, bad := &fakeNotifier{}, &fakeNotifier{err: errors.New("fake")}
+	buf := new(bytes.Buffer)
+
+	err := SendNotifications(context.Background(), map[string]Notifier{"good": good, "bad": bad}, Message{Text: "hello"}, buf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `send message to "bad" failed: fake`)
+	assert.Equal(t, "send message to \"good\" successfully\n", buf.String())
+	assert.Len(t, good.messages, 1)
+	assert.Len(t, bad.messages, 1)
+}
+
+func TestNotifyPullRequest(t *testing.T) {
+	client, data := fake.NewDefault()
+	data.PullRequests[1] = &scm.PullRequest{
+		Number:    1,
+		Title:     "feat: notify",
+		Link:      "https://github.com/linuxsuren/gogit/pull/1",
+		Author:    scm.User{Login: "linuxsuren"},
+		Reviewers: []scm.User{{Login: "bob"}, {Login: "linuxsuren"}},
+		Assignees: []scm.User{{Login: "alice"}},
+	}
+	assert.Equal(t, []string{"linuxsuren", "bob", "alice"}, PullRequestLogins(data.PullRequests[1]))
+
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	resolver := &NotifyResolver{Config: &NotifyConfig{
+		Channels: []NotifyChannel{{Name: "hook", Kind: NotifierWebhook, Webhook: server.URL}},
+		Users:    map[string][]string{"alice": {"hook"}},
+	}}
+	maker := NewStatusMaker("linuxsuren/gogit", "").WithPR(1).WithClient(client)
+	err := maker.NotifyPullRequest(context.Background(), resolver, "done", new(bytes.Buffer))
+	assert.NoError(t, err)
+	assert.Equal(t, "done", received.Text)
+	assert.Equal(t, "feat: notify", received.Title)
+	assert.Equal(t, data.PullRequests[1].Link, received.Link)
+	assert.Equal(t, []string{"linuxsuren", "bob", "alice"}, received.Recipients)
+
+	err = NewStatusMaker("linuxsuren/gogit", "").WithPR(2).WithClient(client).
+		NotifyPullRequest(context.Background(), resolver, "done", new(bytes.Buffer))
+	assert.Error(t, err)
+}